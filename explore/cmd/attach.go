@@ -11,7 +11,10 @@ import (
 var attach = cli.Command{
 	Name:  "attach",
 	Usage: "attach to a process",
-	Flags: []cli.Flag{
+	Description: `Opens a terminal over the process. The load configuration flags set how much
+of values is read by get in the terminal, e.g. with --max-string-len 1024
+strings are read up to 1KB unless get is given other options.`,
+	Flags: append([]cli.Flag{
 		cli.BoolFlag{
 			Name:  "logFlag, f",
 			Usage: "enable debug logging",
@@ -26,7 +29,7 @@ var attach = cli.Command{
 			Usage: "specify the log file path",
 			Value: logflags.DefaultLogDesc,
 		},
-	},
+	}, loadConfigFlags...),
 	Action: func(context *cli.Context) error {
 		if err := utils.CheckArgs(context, 1, utils.ExactArgs, attachArgsCheck); err != nil {
 			return err
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"explore/utils"
+	"fmt"
+	"github.com/urfave/cli"
+	"os"
+)
+
+var core = cli.Command{
+	Name:      "core",
+	Usage:     "inspect a core file",
+	ArgsUsage: "[<executable>] <core file>",
+	Description: `Opens a terminal over a process saved in an ELF core file, e.g. one written
+by the kernel when the process crashed, by gcore or by the dump command.
+get, ls, goroutines and goroutine work as on a live process, the memory can
+not be changed.
+
+	core ./server core.1234
+
+The executable can be omitted for dumps written by the dump command, the path
+recorded in the dump is used.`,
+	Flags: loadConfigFlags,
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 1, utils.MinArgs, coreArgsCheck); err != nil {
+			return err
+		}
+
+		return exec(Core, 0, context)
+	},
+}
+
+func coreArgsCheck(args cli.Args) error {
+	if len(args) > 2 {
+		return fmt.Errorf("expected an executable and a core file, got %d arguments", len(args))
+	}
+	for _, path := range args {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("could not open %s: %v", path, err)
+		}
+	}
+
+	return nil
+}
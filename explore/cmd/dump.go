@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"explore/utils"
+	"fmt"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+var dump = cli.Command{
+	Name:      "dump",
+	Usage:     "save the state of a process to a dump file",
+	ArgsUsage: "<pid>",
+	Description: `Saves the readable memory of the process, the registers of its threads, its
+auxiliary vector and the build IDs of its executable to an ELF core file.
+The process is stopped while the dump is written. The dump can be opened
+anywhere with the core command, the executable is checked to be the one the
+dump was taken from:
+
+	dump -o server.core <pid>
+	core ./server server.core`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "path of the dump file, core.<pid> by default",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 1, utils.ExactArgs, listArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Dump, pid, context)
+	},
+}
+
+func dumpPath(ctx *cli.Context, pid int) string {
+	if path := ctx.String("output"); path != "" {
+		return path
+	}
+	return fmt.Sprintf("core.%d", pid)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"explore/pkg/prowler"
+	"explore/utils"
+	"fmt"
+	"github.com/urfave/cli"
+	"strconv"
+	"strings"
+)
+
+var examine = cli.Command{
+	Name:      "x",
+	Aliases:   []string{"examine"},
+	Usage:     "print the raw memory of the process",
+	ArgsUsage: "<pid> <address or expression>",
+	Description: `Prints the memory of the process at an address or at the address a Go
+expression evaluates to: the memory pointed to by pointers, the backing
+array of slices and strings, the value of integers and otherwise the memory
+of the value itself:
+
+	x -fmt hex -count 32 <pid> 0xc000012340
+	x -fmt ptr -count 4 <pid> main.handlers
+	x -fmt str -count 128 <pid> main.buf
+
+Pointers printed with -fmt ptr that land on functions or package variables
+are annotated with their names.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "fmt",
+			Value: "hex",
+			Usage: "how the memory is printed: hex, dec, char, ptr or str",
+		},
+		cli.IntFlag{
+			Name:  "count",
+			Usage: "number of values printed, 16 by default and 64 for str",
+		},
+		cli.IntFlag{
+			Name:  "size",
+			Usage: "size in bytes of each value: 1, 2, 4 or 8, the size of pointers for ptr",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 2, utils.MinArgs, examineArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Examine, pid, context)
+	},
+}
+
+// examineOptions returns the options of x given on the command line.
+func examineOptions(ctx *cli.Context) prowler.ExamineOptions {
+	return prowler.ExamineOptions{
+		Format: ctx.String("fmt"),
+		Count:  ctx.Int("count"),
+		Size:   ctx.Int("size"),
+	}
+}
+
+// examineExpr returns the address or the expression examined, which can
+// span several arguments.
+func examineExpr(args cli.Args) string {
+	return strings.Join(args.Tail(), " ")
+}
+
+func examineArgsCheck(args cli.Args) error {
+	if strings.TrimSpace(examineExpr(args)) == "" {
+		return fmt.Errorf("an address or an expression is needed")
+	}
+	return listArgsCheck(args)
+}
@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"encoding/json"
+	"explore/pkg/proc/desc"
 	"explore/pkg/prowler"
 	"explore/pkg/terminal"
 	"explore/service"
 	"explore/service/http"
 	"explore/utils"
+	"fmt"
 	"github.com/urfave/cli"
 	"log"
 	"net"
+	"os"
 )
 
 type ExecType int
@@ -17,14 +21,93 @@ const (
 	Get ExecType = iota
 	Set
 	List
+	Goroutines
+	Goroutine
 	Attach
 	Conn
+	Core
+	Dump
+	Static
+	Examine
+	Find
+	Refs
+	Heap
+	Retained
+	Types
+	Whatis
+	Layout
+	GenTypes
+	Func
+	Disassemble
 )
 
 const (
 	defaultAddr = "127.0.0.1:0"
 )
 
+// loadConfigFlags set how much of values is read, for a get or as the
+// default of a server.
+var loadConfigFlags = []cli.Flag{
+	cli.BoolTFlag{
+		Name:  "follow-pointers",
+		Usage: "read the values pointed to, use --follow-pointers=false to only print the addresses",
+	},
+	cli.IntFlag{
+		Name:  "max-variable-recurse",
+		Value: prowler.DefaultLoadConfig.MaxVariableRecurse,
+		Usage: "how many levels of nested structs, arrays, slices and maps are read",
+	},
+	cli.IntFlag{
+		Name:  "max-string-len",
+		Value: prowler.DefaultLoadConfig.MaxStringLen,
+		Usage: "maximum number of bytes read from a string",
+	},
+	cli.IntFlag{
+		Name:  "max-array-values",
+		Value: prowler.DefaultLoadConfig.MaxArrayValues,
+		Usage: "maximum number of elements read from an array, a slice or a map",
+	},
+	cli.IntFlag{
+		Name:  "max-struct-fields",
+		Value: prowler.DefaultLoadConfig.MaxStructFields,
+		Usage: "maximum number of fields read from a struct, -1 for all",
+	},
+	cli.IntFlag{
+		Name:  "max-map-buckets",
+		Value: prowler.DefaultLoadConfig.MaxMapBuckets,
+		Usage: "maximum number of buckets of a map scanned for its elements, 0 for all",
+	},
+}
+
+// loadOptions returns the load configuration flags given on the command
+// line.
+func loadOptions(ctx *cli.Context) (*prowler.LoadOptions, error) {
+	opts := &prowler.LoadOptions{}
+	for _, name := range prowler.LoadOptionNames {
+		if !ctx.IsSet(name) {
+			continue
+		}
+		if err := opts.Set(name, ctx.String(name)); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// freezeFlags are the flags of the commands that can stop the process while
+// they read or write its memory.
+var freezeFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "freeze",
+		Usage: "stop all the threads of the process during the whole read or write, so that the value is consistent",
+	},
+	cli.DurationFlag{
+		Name:  "freeze-timeout",
+		Value: prowler.DefaultFreezeTimeout,
+		Usage: "longest time the memory of the process is read or written while it is stopped with --freeze, the memory accesses after it fail and so does the command",
+	},
+}
+
 type executor struct {
 	et      ExecType
 	pid     int
@@ -32,14 +115,48 @@ type executor struct {
 	prowler *prowler.Prowler
 }
 
-func newExecutor(et ExecType, pid int, ctx *cli.Context) *executor {
-	p, _ := prowler.NewProwler(pid)
-	return &executor{
-		et:      et,
-		pid:     pid,
-		ctx:     ctx,
-		prowler: p,
+func newExecutor(et ExecType, pid int, ctx *cli.Context) (*executor, error) {
+	e := &executor{
+		et:  et,
+		pid: pid,
+		ctx: ctx,
 	}
+
+	var err error
+	switch et {
+	case Conn:
+		// the process is inspected by the server we connect to
+	case Core:
+		args := ctx.Args()
+		if len(args) == 1 {
+			e.prowler, err = prowler.NewCoreProwler("", args.Get(0))
+		} else {
+			e.prowler, err = prowler.NewCoreProwler(args.Get(0), args.Get(1))
+		}
+	case Static:
+		e.prowler, err = prowler.NewStaticProwler(ctx.Args().First())
+	case GenTypes:
+		if pid != 0 {
+			e.prowler, err = prowler.NewProwler(pid)
+		} else {
+			e.prowler, err = prowler.NewStaticProwler(ctx.Args().First())
+		}
+	default:
+		e.prowler, err = prowler.NewProwler(pid)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if e.prowler != nil {
+		opts, err := loadOptions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		e.prowler.LoadConfig = opts.Apply(e.prowler.LoadConfig)
+	}
+
+	return e, nil
 }
 
 func (e *executor) run() error {
@@ -50,8 +167,36 @@ func (e *executor) run() error {
 		return e.set()
 	case List:
 		return e.list()
-	case Attach:
+	case Goroutines:
+		return e.goroutines()
+	case Goroutine:
+		return e.goroutine()
+	case Examine:
+		return e.examine()
+	case Find:
+		return e.find()
+	case Refs:
+		return e.refs()
+	case Heap:
+		return e.heap()
+	case Retained:
+		return e.retained()
+	case Types:
+		return e.types()
+	case Whatis:
+		return e.whatis()
+	case Layout:
+		return e.layout()
+	case GenTypes:
+		return e.gentypes()
+	case Func:
+		return e.function()
+	case Disassemble:
+		return e.disassemble()
+	case Attach, Core, Static:
 		return e.attach()
+	case Dump:
+		return e.dump()
 	case Conn:
 		args := e.ctx.Args()
 		return e.connect(args.First())
@@ -61,33 +206,121 @@ func (e *executor) run() error {
 }
 
 func exec(et ExecType, pid int, ctx *cli.Context) error {
-	ex := newExecutor(et, pid, ctx)
+	ex, err := newExecutor(et, pid, ctx)
+	if err != nil {
+		return err
+	}
 	return ex.run()
 }
 
+// freeze runs fn with the threads of the process stopped if --freeze is
+// given.
+func (e *executor) freeze(fn func() error) error {
+	if !e.ctx.Bool("freeze") {
+		return fn()
+	}
+	return e.prowler.Freeze(e.ctx.Duration("freeze-timeout"), fn)
+}
+
 func (e *executor) get() error {
 	args := e.ctx.Args()
 	r := rArgs(args)
+	if format := e.ctx.String("graph"); format != "" {
+		if e.ctx.String("as") != "" {
+			return fmt.Errorf("--as can not be used with --graph")
+		}
+		return e.graph(r.name, format)
+	}
+	page, paged, err := pageOptions(e.ctx)
+	if err != nil {
+		return err
+	}
+	as := e.ctx.String("as")
+	if as != "" && paged {
+		return fmt.Errorf("--as can not be used with --offset, --limit or --cursor")
+	}
 
-	v, err := e.prowler.Get(r.name)
+	var v *desc.Variable
+	var ret *desc.Retained
+	err = e.freeze(func() (err error) {
+		if as != "" {
+			v, err = e.prowler.GetAs(as, r.name, e.prowler.LoadConfig)
+		} else if paged {
+			v, err = e.prowler.GetPage(r.name, e.prowler.LoadConfig, page)
+		} else {
+			v, err = e.prowler.Get(r.name)
+		}
+		if err == nil && e.ctx.Bool("retained") {
+			ret, err = e.prowler.Retained(r.name)
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
 	utils.PrintVariable(v)
+	if next := v.NextPage(); paged && next != "" {
+		fmt.Printf("next page: %s\n", next)
+	}
+	if ret != nil {
+		utils.PrintStringLine(ret.String())
+	}
+	if e.ctx.Bool("stats") {
+		fmt.Fprintln(os.Stderr, e.prowler.CacheStats())
+	}
+	return nil
+}
+
+// graph prints the graph of the objects reachable from name in format, dot
+// or json.
+func (e *executor) graph(name, format string) error {
+	if format != "dot" && format != "json" {
+		return fmt.Errorf("unknown graph format %q, expected dot or json", format)
+	}
+
+	var g *desc.Graph
+	err := e.freeze(func() (err error) {
+		g, err = e.prowler.Graph(name, e.ctx.Int("depth"))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == "dot" {
+		utils.PrintStringLine(g.DOT())
+		return nil
+	}
+	bs, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	utils.PrintStringLine(string(bs))
 	return nil
 }
 
 func (e *executor) set() error {
-	args := e.ctx.Args()
-	w := wArgs(args)
+	w, err := setArgs(e.ctx.Args())
+	if err != nil {
+		return err
+	}
+
+	var v *desc.Variable
+	err = e.freeze(func() (err error) {
+		if err := e.prowler.Write(w); err != nil {
+			return err
+		}
 
-	err := e.prowler.Set(w.name, w.value)
+		v, err = e.prowler.Get(w.Name)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
-	return e.get()
+	utils.PrintVariable(v)
+	return nil
 }
 
 func (e *executor) list() error {
@@ -99,6 +332,183 @@ func (e *executor) list() error {
 	return nil
 }
 
+func (e *executor) goroutines() error {
+	gs, err := e.prowler.Goroutines(prowler.GoroutineFilter{
+		Status:    e.ctx.String("status"),
+		StartFunc: e.ctx.String("start"),
+	})
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(gs.String())
+	return nil
+}
+
+func (e *executor) goroutine() error {
+	g, err := gArgs(e.ctx.Args())
+	if err != nil {
+		return err
+	}
+
+	if g.cmd == "frame" {
+		vars, err := e.prowler.FrameVariables(g.id, g.frame, g.vars == "args")
+		if err != nil {
+			return err
+		}
+
+		utils.PrintStringLine(vars.String())
+		return nil
+	}
+
+	st, err := e.prowler.Stacktrace(g.id, e.ctx.Int("depth"))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(st.String())
+	return nil
+}
+
+func (e *executor) examine() error {
+	m, err := e.prowler.Examine(examineExpr(e.ctx.Args()), examineOptions(e.ctx))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(m.String())
+	return nil
+}
+
+func (e *executor) find() error {
+	pat, err := findPattern(e.ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := e.prowler.Find(pat, e.ctx.Int("max"))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(res.String())
+	return nil
+}
+
+func (e *executor) refs() error {
+	res, err := e.prowler.References(examineExpr(e.ctx.Args()), e.ctx.Int("max"))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(res.String())
+	return nil
+}
+
+func (e *executor) heap() error {
+	inv, err := e.prowler.HeapInventory(e.ctx.Int("top"))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(inv.String())
+	return nil
+}
+
+func (e *executor) retained() error {
+	r, err := e.prowler.RetainedReport(e.ctx.Int("top"))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(r.String())
+	return nil
+}
+
+func (e *executor) types() error {
+	ts, err := e.prowler.Types(e.ctx.Args().Get(1), e.ctx.String("kind"))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(ts.String())
+	return nil
+}
+
+func (e *executor) whatis() error {
+	d, err := e.prowler.Whatis(e.ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(d.String())
+	return nil
+}
+
+func (e *executor) layout() error {
+	l, err := e.prowler.Layout(e.ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(l.String())
+	return nil
+}
+
+func (e *executor) gentypes() error {
+	pkgs := e.ctx.StringSlice("pkg")
+	if len(pkgs) == 0 {
+		pkgs = []string{"main"}
+	}
+	src, err := e.prowler.GenTypes(pkgs, e.ctx.String("name"))
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(src)
+	return err
+}
+
+func (e *executor) function() error {
+	f, err := e.prowler.Function(e.ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(f.String())
+	return nil
+}
+
+func (e *executor) disassemble() error {
+	d, err := e.prowler.Disassemble(e.ctx.Args().Get(1), e.ctx.String("flavour"))
+	if err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(d.String())
+	return nil
+}
+
+func (e *executor) dump() error {
+	path := dumpPath(e.ctx, e.pid)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := e.prowler.Dump(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	utils.PrintStringLine(fmt.Sprintf("process %d dumped to %s", e.pid, path))
+	return nil
+}
+
 func (e *executor) attach() error {
 	var server service.Server
 	ctx := e.ctx
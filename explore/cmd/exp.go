@@ -15,7 +15,23 @@ func NewExp() *cli.App {
 		read,
 		write,
 		list,
+		goroutines,
+		goroutine,
+		examine,
+		find,
+		refs,
+		heap,
+		retained,
+		types,
+		whatis,
+		layout,
+		gentypes,
+		function,
+		disassemble,
 		attach,
+		core,
+		dump,
+		static,
 		conn,
 	}
 
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"explore/pkg/prowler"
+	"explore/utils"
+	"fmt"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+var find = cli.Command{
+	Name:      "find",
+	Usage:     "search the memory of the process for a string, bytes or an integer",
+	ArgsUsage: "<pid>",
+	Description: `Searches the readable memory of the process for one pattern:
+
+	find --string "tenant-42" <pid>
+	find --hex "de ad be ef" <pid>
+	find --uint64 0xc000012340 <pid>
+
+The matches are grouped by mapping and kind of memory (heap, stack, data,
+bss, ...) along with the package variable, the heap object or the goroutine
+stack holding them when known. Integers are only matched at addresses
+aligned on 8 bytes.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "string",
+			Usage: "search for the bytes of a string",
+		},
+		cli.StringFlag{
+			Name:  "hex",
+			Usage: "search for bytes written in hexadecimal, e.g. \"de ad be ef\"",
+		},
+		cli.StringFlag{
+			Name:  "uint64",
+			Usage: "search for a little endian 64 bit integer, e.g. an ID or a pointer",
+		},
+		cli.IntFlag{
+			Name:  "max",
+			Value: prowler.DefaultMaxMatches,
+			Usage: "number of matches after which the search stops",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 1, utils.ExactArgs, listArgsCheck); err != nil {
+			return err
+		}
+		if _, err := findPattern(context); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Find, pid, context)
+	},
+}
+
+// findPattern returns the pattern given with one of --string, --hex and
+// --uint64.
+func findPattern(ctx *cli.Context) (prowler.Pattern, error) {
+	var pat prowler.Pattern
+	n := 0
+	for _, f := range []struct {
+		name  string
+		parse func(string) (prowler.Pattern, error)
+	}{
+		{"string", prowler.StringPattern},
+		{"hex", prowler.HexPattern},
+		{"uint64", prowler.Uint64Pattern},
+	} {
+		if !ctx.IsSet(f.name) {
+			continue
+		}
+		var err error
+		if pat, err = f.parse(ctx.String(f.name)); err != nil {
+			return prowler.Pattern{}, err
+		}
+		n++
+	}
+	if n != 1 {
+		return prowler.Pattern{}, fmt.Errorf("exactly one of --string, --hex and --uint64 is needed")
+	}
+	return pat, nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"explore/utils"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+var function = cli.Command{
+	Name:      "func",
+	Usage:     "print the location and the signature of a function",
+	ArgsUsage: "<pid> <function>",
+	Description: `Prints the entry and end addresses of a function of the process, the file
+and line of its declaration, its parameters and results, whether it was
+compiled with optimizations and at how many call sites it was inlined:
+
+	func <pid> main.main
+	func <pid> 'main.(*Server).Serve'`,
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 2, utils.ExactArgs, listArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Func, pid, context)
+	},
+}
+
+var disassemble = cli.Command{
+	Name:      "disassemble",
+	Usage:     "print the machine instructions of a function",
+	ArgsUsage: "<pid> <function>",
+	Description: `Prints the machine instructions of a function of the process, each run of
+them preceded by the file and line they were compiled from, and by the
+source line when the file is on this machine:
+
+	disassemble <pid> main.main
+	disassemble --flavour go <pid> 'main.(*Server).Serve'`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "flavour, f",
+			Value: "intel",
+			Usage: "the assembly syntax, intel, gnu or go",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 2, utils.ExactArgs, listArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Disassemble, pid, context)
+	},
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"explore/utils"
+	"fmt"
+	"github.com/urfave/cli"
+	"os"
+	"strconv"
+)
+
+var gentypes = cli.Command{
+	Name:      "gentypes",
+	Usage:     "print Go declarations of the types of a process or an executable",
+	ArgsUsage: "<pid | executable>",
+	Description: `Prints Go source declaring the named types of the packages given with
+--pkg, as found in the debug information of a process or of an executable:
+
+	gentypes --pkg github.com/acme/svc/... --name svc <pid> > types.go
+
+The declared structs have the fields, and so the layout, of the ones of the
+process: their values can be read from raw memory, or written with set and
+as JSON. The types of other packages are imported from the standard library
+when possible and otherwise declared as opaque types of the same size. The
+methods of interfaces are not in the debug information.`,
+	Flags: []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "pkg, p",
+			Usage: "import path of a package whose types are declared, path/... for the packages below path too (default: main)",
+		},
+		cli.StringFlag{
+			Name:  "name, n",
+			Value: "types",
+			Usage: "name of the package of the generated source",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 1, utils.ExactArgs, gentypesArgsCheck); err != nil {
+			return err
+		}
+
+		// a process, or an executable opened as with the static command
+		arg := context.Args().First()
+		pid := 0
+		if utils.CheckPid(arg) {
+			pid, _ = strconv.Atoi(arg)
+		}
+
+		return exec(GenTypes, pid, context)
+	},
+}
+
+func gentypesArgsCheck(args cli.Args) error {
+	arg := args.First()
+	if utils.CheckPid(arg) {
+		return nil
+	}
+	if _, err := os.Stat(arg); err != nil {
+		return fmt.Errorf("%s is neither a process nor an executable", arg)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"explore/utils"
+	"fmt"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+var goroutines = cli.Command{
+	Name:  "goroutines",
+	Usage: "list the goroutines of the process",
+	Description: `Lists the goroutines of the process with their status, wait reason, the
+location of the go statement that started them and their start function.
+
+	goroutines --status 'chan receive' --start example.com/pkg/queue. <pid>`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "status, s",
+			Usage: "only list goroutines with this status or wait reason, e.g. runnable or 'chan receive'",
+		},
+		cli.StringFlag{
+			Name:  "start, f",
+			Usage: "only list goroutines whose start function begins with this prefix",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 1, utils.ExactArgs, listArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Goroutines, pid, context)
+	},
+}
+
+var goroutine = cli.Command{
+	Name:      "goroutine",
+	Usage:     "inspect a goroutine of the process",
+	ArgsUsage: "<pid> <goroutine id> stack | frame <n> locals|args",
+	Description: `Prints the stack of a parked goroutine, unwound from the registers saved by
+the runtime, without stopping the process:
+
+	goroutine --depth 20 <pid> 18 stack
+
+or the local variables or arguments of one of its frames, numbered as in
+the stack:
+
+	goroutine <pid> 18 frame 2 locals
+	goroutine <pid> 18 frame 2 args`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "depth, d",
+			Value: 50,
+			Usage: "maximum number of frames",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 3, utils.MinArgs, goroutineArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Goroutine, pid, context)
+	},
+}
+
+type goroutineArgs struct {
+	id    int64
+	cmd   string
+	frame int
+	vars  string
+}
+
+func gArgs(args cli.Args) (*goroutineArgs, error) {
+	id, err := strconv.ParseInt(args.Get(1), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid goroutine id %q", args.Get(1))
+	}
+
+	g := &goroutineArgs{
+		id:  id,
+		cmd: args.Get(2),
+	}
+	if g.cmd == "frame" {
+		g.frame, err = strconv.Atoi(args.Get(3))
+		if err != nil {
+			return nil, fmt.Errorf("invalid frame %q", args.Get(3))
+		}
+		g.vars = args.Get(4)
+	}
+
+	return g, nil
+}
+
+func goroutineArgsCheck(args cli.Args) error {
+	g, err := gArgs(args)
+	if err != nil {
+		return err
+	}
+	switch g.cmd {
+	case "stack":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: goroutine <pid> <goroutine id> stack")
+		}
+	case "frame":
+		if len(args) != 5 || (g.vars != "locals" && g.vars != "args") {
+			return fmt.Errorf("usage: goroutine <pid> <goroutine id> frame <n> locals|args")
+		}
+	default:
+		return fmt.Errorf("unknown goroutine command %q, expected stack or frame", g.cmd)
+	}
+
+	return listArgsCheck(args)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"explore/pkg/prowler"
+	"explore/utils"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+var heap = cli.Command{
+	Name:      "heap",
+	Usage:     "count the objects of the heap by type",
+	ArgsUsage: "<pid>",
+	Description: `Walks the spans of the Go heap and prints the number and the bytes of the
+allocated objects of each type, the largest first:
+
+	heap --top 30 <pid>
+
+The type of small objects without pointers, and of objects of at most 512
+bytes, is not recorded by the runtime: they are counted by size class.
+The allocated objects include the garbage not swept yet by the collector.`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "top",
+			Value: prowler.DefaultHeapTop,
+			Usage: "number of types printed, 0 for all",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 1, utils.ExactArgs, listArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Heap, pid, context)
+	},
+}
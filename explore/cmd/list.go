@@ -15,7 +15,7 @@ var list = cli.Command{
 		cli.IntFlag{
 			Name:  "type, t",
 			Value: int(prowler.Vac),
-			Usage: "this selection specifies whether constants, variables, or all are listed",
+			Usage: "this selection specifies whether variables and constants (0), variables (1), constants (2), functions (3) or types (4) are listed",
 		},
 		cli.StringSliceFlag{
 			Name:  "prefixes, p",
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"explore/pkg/prowler"
 	"explore/utils"
 	"fmt"
 	"github.com/urfave/cli"
@@ -11,6 +12,75 @@ import (
 var read = cli.Command{
 	Name:  "get",
 	Usage: "read to processes",
+	Description: `Prints the value of a Go expression over the package variables of the process:
+
+	get <pid> main.cfg.Servers[2].Addr
+
+The process keeps running while a large value is read, with --freeze all its
+threads are stopped until the whole value is read:
+
+	get --freeze <pid> main.cache
+
+Strings are read up to 64 bytes, collections up to 64 elements and nested
+values one level deep by default, more is read with the load configuration
+flags:
+
+	get --max-variable-recurse 3 --max-string-len 4096 <pid> main.cfg
+
+Large arrays, slices, strings and maps are read a page at a time, the
+command prints the options reading the next page:
+
+	get --offset 1000 --limit 50 <pid> main.users
+	get --limit 50 --cursor 50:0:6:3:c000123400 <pid> main.cache
+
+An address, e.g. from a log, a panic or the x command, is read as a value
+of the type given with --as, which is the same as the expression form:
+
+	get --as main.Config <pid> 0xc000123400
+	get <pid> '*(*main.Config)(0xc000123400)'
+
+With --retained, the bytes that would be freed if the variable were cleared
+are printed after its value, see the retained command.
+
+With --graph, the objects reachable from the value are printed as a graph
+in the Graphviz DOT language or in JSON, up to --depth pointers away:
+
+	get --graph dot --depth 4 <pid> main.lru | dot -Tsvg > lru.svg`,
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "stats",
+			Usage: "print the hits and misses of the cache of the memory of the process",
+		},
+		cli.Int64Flag{
+			Name:  "offset",
+			Usage: "index of the first element of an array, slice or string read, number of entries of a map skipped",
+		},
+		cli.Int64Flag{
+			Name:  "limit",
+			Usage: "maximum number of elements read from an array, slice, string or map, by default --max-array-values",
+		},
+		cli.StringFlag{
+			Name:  "cursor",
+			Usage: "position of the next page of a map, printed by a previous get",
+		},
+		cli.StringFlag{
+			Name:  "as",
+			Usage: "read the memory at the address given instead of the expression as a value of this type, e.g. main.Config",
+		},
+		cli.StringFlag{
+			Name:  "graph",
+			Usage: "print the graph of the objects reachable from the value instead of the value: dot or json",
+		},
+		cli.IntFlag{
+			Name:  "depth",
+			Value: prowler.DefaultGraphDepth,
+			Usage: "number of pointers followed from the value by --graph",
+		},
+		cli.BoolFlag{
+			Name:  "retained",
+			Usage: "print the bytes retained by the variable, or by the heap object it points to",
+		},
+	}, append(loadConfigFlags, freezeFlags...)...),
 	Action: func(context *cli.Context) error {
 		if err := utils.CheckArgs(context, 2, utils.ExactArgs, readArgsCheck); err != nil {
 			return err
@@ -26,6 +96,23 @@ var read = cli.Command{
 	},
 }
 
+// pageOptions returns the page of the value selected on the command line,
+// false if the whole value is read.
+func pageOptions(ctx *cli.Context) (prowler.Page, bool, error) {
+	var page prowler.Page
+	set := false
+	for _, name := range prowler.PageOptionNames {
+		if !ctx.IsSet(name) {
+			continue
+		}
+		if err := page.Set(name, ctx.String(name)); err != nil {
+			return page, false, err
+		}
+		set = true
+	}
+	return page, set, nil
+}
+
 type readArgs struct {
 	name string
 }
@@ -44,6 +131,10 @@ func readArgsCheck(args cli.Args) error {
 		return fmt.Errorf("pid %s does not exist", pid)
 	}
 
+	if _, err := strconv.ParseUint(name, 0, 64); err == nil {
+		// an address read with --as
+		return nil
+	}
 	if !strings.Contains(name, ".") {
 		return fmt.Errorf("variable name must contain '.'")
 	}
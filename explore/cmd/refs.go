@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"explore/pkg/prowler"
+	"explore/utils"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+var refs = cli.Command{
+	Name:      "refs",
+	Aliases:   []string{"references"},
+	Usage:     "find the variables and the heap objects holding a pointer to an object",
+	ArgsUsage: "<pid> <address or expression>",
+	Description: `Walks the objects reachable from the package variables, following typed
+pointers, and prints the pointers to the object containing an address or
+the address a Go expression evaluates to, along with their access path:
+
+	refs <pid> 0xc000123400
+	refs <pid> main.registry.handlers["x"]
+
+The expression is read as by x: pointers are looked up by the address they
+point to. The variables of the runtime and goroutine stacks are not
+searched.`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "max",
+			Value: prowler.DefaultMaxReferences,
+			Usage: "number of references after which the lookup stops",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 2, utils.MinArgs, examineArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Refs, pid, context)
+	},
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"explore/pkg/prowler"
+	"explore/utils"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+var retained = cli.Command{
+	Name:      "retained",
+	Usage:     "print the memory retained by the package variables",
+	ArgsUsage: "<pid>",
+	Description: `Walks the objects reachable from the package variables, following typed
+pointers, and prints the bytes each variable retains: the heap objects
+only reachable through it, which would be freed if it were cleared:
+
+	retained --top 20 <pid>
+
+Objects reachable from several variables are retained by none of them. The
+variables of the runtime and goroutine stacks are not roots. The memory
+retained by a single variable is printed by get --retained.`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "top",
+			Value: prowler.DefaultRetainedTop,
+			Usage: "number of variables printed, 0 for all",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 1, utils.ExactArgs, listArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Retained, pid, context)
+	},
+}
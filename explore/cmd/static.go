@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"explore/utils"
+	"fmt"
+	"github.com/urfave/cli"
+	"os"
+)
+
+var static = cli.Command{
+	Name:      "static",
+	Usage:     "inspect an executable without running it",
+	ArgsUsage: "<executable>",
+	Description: `Opens a terminal over the debug information of an executable, with no
+process. ls lists its package variables, constants, functions and types, get
+prints the initial value of a package variable, as written by the linker in
+the .data, .noptrdata and .rodata sections. Variables initialized at run time,
+e.g. in init functions, are zero. The memory can not be changed.
+
+	static ./server`,
+	Flags: loadConfigFlags,
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 1, utils.ExactArgs, staticArgsCheck); err != nil {
+			return err
+		}
+
+		return exec(Static, 0, context)
+	},
+}
+
+func staticArgsCheck(args cli.Args) error {
+	path := args.First()
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("could not open %s: %v", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"explore/utils"
+	"fmt"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+var types = cli.Command{
+	Name:      "types",
+	Usage:     "list the types of the process",
+	ArgsUsage: "<pid> [regexp]",
+	Description: `Lists the types of the process with their kind and size, only the ones
+whose name matches the regular expression if one is given:
+
+	types --kind struct <pid> '^main\.'`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "kind, k",
+			Usage: "only list types of this kind, e.g. struct, map or int64",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 1, utils.MinArgs, listArgsCheck); err != nil {
+			return err
+		}
+		if context.NArg() > 2 {
+			return fmt.Errorf("types takes at most one regular expression")
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Types, pid, context)
+	},
+}
+
+var whatis = cli.Command{
+	Name:      "whatis",
+	Usage:     "print the declaration of a type or of the type of an expression",
+	ArgsUsage: "<pid> <type or expression>",
+	Description: `Prints the declaration of a type of the process, or of the type of a Go
+expression over its package variables, with the dynamic type of interface
+values:
+
+	whatis <pid> main.Config
+	whatis <pid> main.cfg.Strategy`,
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 2, utils.ExactArgs, listArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Whatis, pid, context)
+	},
+}
+
+var layout = cli.Command{
+	Name:      "layout",
+	Usage:     "print the memory layout of a struct type",
+	ArgsUsage: "<pid> <type or expression>",
+	Description: `Prints the offset, the size and the alignment of each field of a struct
+type, or of the type of a Go expression, and the padding left between them
+by the compiler:
+
+	layout <pid> main.Config
+
+The offsets are the ones to use when writing raw bytes, see the x and set
+commands.`,
+	Action: func(context *cli.Context) error {
+		if err := utils.CheckArgs(context, 2, utils.ExactArgs, listArgsCheck); err != nil {
+			return err
+		}
+
+		pid, err := strconv.Atoi(context.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return exec(Layout, pid, context)
+	},
+}
@@ -1,16 +1,33 @@
 package cmd
 
 import (
+	"explore/pkg/prowler"
 	"explore/utils"
 	"github.com/urfave/cli"
 	"strconv"
+	"strings"
 )
 
 var write = cli.Command{
 	Name:  "set",
 	Usage: "writing a process variable is unsafe, as unexpected situations may occur if multiple command lines are concurrent.",
+	Description: `Either set a variable to a raw value:
+
+	set <pid> main.limit 200
+
+or evaluate a Go assignment, both sides being expressions over package variables:
+
+	set <pid> 'main.cfg.Limits["api"].Burst = 200'
+
+Assigning to a key that is not in a map inserts it, and keys are removed with delete:
+
+	set <pid> 'main.flags["beta"] = true'
+	set <pid> 'delete(main.flags, "beta")'
+
+With --freeze all the threads of the process are stopped during the write.`,
+	Flags: freezeFlags,
 	Action: func(context *cli.Context) error {
-		if err := utils.CheckArgs(context, 3, utils.ExactArgs, writeArgsCheck); err != nil {
+		if err := utils.CheckArgs(context, 2, utils.MinArgs, writeArgsCheck); err != nil {
 			return err
 		}
 
@@ -23,18 +40,15 @@ var write = cli.Command{
 	},
 }
 
-type writeArgs struct {
-	name  string
-	value string
+// setArgs returns the arguments of a set after the pid.
+func setArgs(args cli.Args) (*prowler.SetArgs, error) {
+	return prowler.ParseSet(strings.Join(args.Tail(), " "), args.Tail())
 }
 
-func wArgs(args cli.Args) *writeArgs {
-	return &writeArgs{
-		name:  args.Get(1),
-		value: args.Get(2),
+func writeArgsCheck(args cli.Args) error {
+	if _, err := setArgs(args); err != nil {
+		return err
 	}
-}
 
-func writeArgsCheck(args cli.Args) error {
 	return readArgsCheck(args)
 }
@@ -34,7 +34,7 @@ type Arch struct {
 	fixFrameUnwindContext func(*frame.FrameContext, uint64, *BinaryInfo) *frame.FrameContext
 	// switchStack will use the current frame to determine if it's time to
 	// switch between the system stack and the goroutine stack or vice versa.
-	//switchStack func(it *stackIterator, callFrameRegs *op.DwarfRegisters) bool
+	switchStack func(it *stackIterator, callFrameRegs *op.DwarfRegisters) bool
 	// regSize returns the size (in bytes) of register regnum.
 	regSize func(uint64) int
 	// RegistersToDwarfRegisters maps hardware registers to DWARF registers.
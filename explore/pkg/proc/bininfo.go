@@ -12,6 +12,7 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/parser"
 	"go/token"
 	"hash/crc32"
 	"io"
@@ -617,6 +618,40 @@ func (fn *Function) CompilationUnitName() string {
 	return fn.cu.name
 }
 
+// FunctionParam is a parameter or a result of a function.
+type FunctionParam struct {
+	Name string
+	Type godwarf.Type
+}
+
+// Signature returns the parameters and the results of fn, in the order of
+// its declaration, read from the debug information.
+func (fn *Function) Signature() (params, results []FunctionParam, err error) {
+	if fn.cu == nil || fn.cu.image.Stripped() {
+		return nil, nil, errors.New("no debug information for " + fn.Name)
+	}
+	tree, err := fn.cu.image.getDwarfTree(fn.offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, child := range tree.Children {
+		if child.Tag != dwarf.TagFormalParameter {
+			continue
+		}
+		name, _ := child.Val(dwarf.AttrName).(string)
+		typ, err := child.Type(fn.cu.image.dwarf, fn.cu.image.index, fn.cu.image.typeCache)
+		if err != nil {
+			return nil, nil, err
+		}
+		if isret, _ := child.Val(dwarf.AttrVarParam).(bool); isret {
+			results = append(results, FunctionParam{Name: name, Type: typ})
+		} else {
+			params = append(params, FunctionParam{Name: name, Type: typ})
+		}
+	}
+	return params, results, nil
+}
+
 func rangeParentName(fnname string) int {
 	const rangeSuffix = "-range"
 	ridx := strings.Index(fnname, rangeSuffix)
@@ -847,6 +882,24 @@ func (so *Image) DwarfReader() *reader.Reader {
 	return reader.New(so.dwarf)
 }
 
+// FindType returns the type named name, written either as in the debug
+// information, e.g. io/fs.PathError or main.List[int], or as in Go
+// expressions, e.g. fs.PathError, *main.Config or [16]byte.
+func (bi *BinaryInfo) FindType(name string) (godwarf.Type, error) {
+	if typ, err := bi.findType(name); err == nil {
+		return typ, nil
+	}
+	expr, err := parser.ParseExpr(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %s: %v", name, err)
+	}
+	typ, err := bi.findTypeExpr(expr)
+	if err != nil {
+		return nil, fmt.Errorf("could not find type %s: %v", name, err)
+	}
+	return typ, nil
+}
+
 // Types returns list of types present in the debugged program.
 func (bi *BinaryInfo) Types() ([]string, error) {
 	types := make([]string, 0, len(bi.types))
@@ -2930,6 +2983,50 @@ func escapePackagePath(pkg string) string {
 	return pkg[:slash] + strings.ReplaceAll(pkg[slash:], ".", "%2e")
 }
 
+// SymLookup returns the name and the address of the function starting at
+// addr or of the package variable containing it, an empty name if there is
+// none. Unlike symLookup, addresses past the end of the variable before
+// them, e.g. in unnamed static data, have no name.
+func (bi *BinaryInfo) SymLookup(addr uint64) (string, uint64) {
+	if fn := bi.PCToFunc(addr); fn != nil {
+		if fn.Entry == addr {
+			return fn.Name, fn.Entry
+		}
+		return "", 0
+	}
+	if sym, ok := bi.SymNames[addr]; ok {
+		return sym.Name, addr
+	}
+	i := sort.Search(len(bi.packageVars), func(i int) bool {
+		return bi.packageVars[i].addr > addr
+	}) - 1
+	if i < 0 || bi.packageVars[i].addr == 0 {
+		return "", 0
+	}
+	pv := &bi.packageVars[i]
+	typ, err := pv.typ()
+	if err != nil || addr >= pv.addr+uint64(max(typ.Size(), 1)) {
+		return "", 0
+	}
+	return pv.name, pv.addr
+}
+
+// typ returns the type of the package variable v.
+func (v *packageVar) typ() (godwarf.Type, error) {
+	image := v.cu.image
+	rdr := image.DwarfReader()
+	rdr.Seek(v.offset)
+	entry, err := rdr.Next()
+	if err != nil {
+		return nil, err
+	}
+	off, ok := entry.Val(dwarf.AttrType).(dwarf.Offset)
+	if !ok {
+		return nil, fmt.Errorf("no type for %s", v.name)
+	}
+	return image.Type(off)
+}
+
 // Looks up symbol (either functions or global variables) at address addr.
 // Used by disassembly formatter.
 func (bi *BinaryInfo) symLookup(addr uint64) (string, uint64) {
@@ -0,0 +1,47 @@
+package proc
+
+import (
+	"testing"
+
+	"explore/pkg/dwarf/godwarf"
+)
+
+func TestFindType(t *testing.T) {
+	bi := fixtureScope(t).BinInfo
+	for _, test := range []struct {
+		name string
+		want string
+		size int64
+	}{
+		// names of the debug information
+		{"io/fs.PathError", "io/fs.PathError", 48},
+		{"main.Config", "main.Config", 112},
+		{"*main.Config", "*main.Config", 8},
+		// Go expressions, with the name of packages instead of their path
+		{"fs.PathError", "io/fs.PathError", 48},
+		{"*fs.PathError", "*io/fs.PathError", 8},
+		{"[16]byte", "[16]uint8", 16},
+		{"[]*main.Point", "[]*main.Point", 24},
+	} {
+		typ, err := bi.FindType(test.name)
+		if err != nil {
+			t.Errorf("FindType(%q): %v", test.name, err)
+			continue
+		}
+		if got := typ.Common().Name; got != test.want || typ.Size() != test.size {
+			t.Errorf("FindType(%q) = %s of %d bytes, want %s of %d bytes", test.name, got, typ.Size(), test.want, test.size)
+		}
+	}
+
+	// arrays not in the debug information are made up
+	typ, err := bi.FindType("[4]main.Point")
+	if arr, ok := typ.(*godwarf.ArrayType); err != nil || !ok || arr.Count != 4 || arr.Type.Common().Name != "main.Point" || arr.Size() != 64 {
+		t.Errorf("FindType(\"[4]main.Point\") = %v, %v", typ, err)
+	}
+
+	for _, name := range []string{"main.Missing", "fs.Missing", "[x]byte", "map["} {
+		if typ, err := bi.FindType(name); err == nil {
+			t.Errorf("FindType(%q) = %s, want an error", name, typ)
+		}
+	}
+}
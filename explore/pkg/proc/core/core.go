@@ -0,0 +1,218 @@
+// Package core reads the memory and the threads of a process from an ELF
+// core file, so that it can be inspected like a live process.
+package core
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"explore/pkg/proc"
+)
+
+// ErrReadOnly is returned when writing to the memory of a core file.
+var ErrReadOnly = errors.New("core files are read-only")
+
+// ErrUnrecognizedFormat is returned when the file is not an ELF core file
+// of a supported architecture.
+var ErrUnrecognizedFormat = errors.New("unrecognized core format")
+
+// Process is a process saved in a core file. Its memory is the union of
+// the PT_LOAD segments of the core and, for the read-only mappings that
+// the kernel did not dump, of the contents of the mapped files listed in
+// the NT_FILE note.
+type Process struct {
+	// Pid is the id of the process, from the first NT_PRSTATUS note
+	Pid int
+	// GOARCH is the architecture of the process, e.g. "amd64"
+	GOARCH string
+	// Auxv is the auxiliary vector of the process, from the NT_AUXV note
+	Auxv []byte
+	// Info describes the process, only for dumps written by Write
+	Info *DumpInfo
+	// Exe is the path of the executable
+	Exe string
+
+	mem     *splicedMemory
+	threads []proc.Thread
+	files   []io.Closer
+}
+
+// Open opens the core file corePath of a process running the executable
+// exePath. For dumps written by Write exePath can be empty, the path of the
+// executable recorded in the dump is used, and the executable is checked
+// to be the one the dump was taken from.
+func Open(corePath, exePath string) (*Process, error) {
+	cf, err := os.Open(corePath)
+	if err != nil {
+		return nil, err
+	}
+	p := &Process{mem: &splicedMemory{}, files: []io.Closer{cf}}
+	if err := p.load(cf, exePath); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Process) load(cf *os.File, exePath string) error {
+	core, err := elf.NewFile(cf)
+	if err != nil {
+		if _, ok := err.(*elf.FormatError); ok {
+			return ErrUnrecognizedFormat
+		}
+		return err
+	}
+	if core.Type != elf.ET_CORE {
+		return fmt.Errorf("%s is not a core file", cf.Name())
+	}
+	switch core.Machine {
+	case elf.EM_X86_64:
+		p.GOARCH = "amd64"
+	case elf.EM_AARCH64:
+		p.GOARCH = "arm64"
+	default:
+		return ErrUnrecognizedFormat
+	}
+
+	notes, err := readNotes(core, p.GOARCH)
+	if err != nil {
+		return err
+	}
+	if len(notes.threads) == 0 {
+		return errors.New("no NT_PRSTATUS note in core file")
+	}
+	p.Pid = notes.threads[0].pid
+	p.Auxv = notes.auxv
+	p.Info = notes.info
+	if p.Info != nil {
+		if exePath == "" {
+			exePath = p.Info.Exe
+		}
+		if err := checkBuildID(p.Info, exePath); err != nil {
+			return err
+		}
+	}
+	if exePath == "" {
+		return errors.New("the path of the executable is needed to open a core file")
+	}
+	p.Exe = exePath
+	for _, th := range notes.threads {
+		p.threads = append(p.threads, th)
+	}
+
+	// mappings of the executable not saved in the core, usually its text
+	// and read-only data, are read from the executable itself
+	if exe, err := os.Open(exePath); err == nil {
+		p.files = append(p.files, exe)
+		for _, m := range notes.files {
+			if sameFile(m.path, exePath) {
+				p.mem.add(m.start, m.end-m.start, exe, m.off)
+			}
+		}
+	}
+
+	for _, prog := range core.Progs {
+		if prog.Type != elf.PT_LOAD || prog.Filesz == 0 {
+			continue
+		}
+		// a segment shorter in the file than in memory was only partially
+		// dumped, the rest is read from the mapped file if known
+		p.mem.add(prog.Vaddr, prog.Filesz, prog.ReaderAt, 0)
+	}
+	return nil
+}
+
+// sameFile reports whether the path of a mapping, as recorded on the
+// machine the core was taken on, is the executable at exePath.
+func sameFile(mapped, exePath string) bool {
+	if abs, err := filepath.Abs(exePath); err == nil && abs == mapped {
+		return true
+	}
+	return filepath.Base(mapped) == filepath.Base(exePath)
+}
+
+// Threads returns the threads of the process, from the NT_PRSTATUS notes.
+func (p *Process) Threads() []proc.Thread {
+	return p.threads
+}
+
+// ReadMemory reads len(data) bytes of the memory of the process at addr.
+func (p *Process) ReadMemory(data []byte, addr uint64) (int, error) {
+	return p.mem.ReadMemory(data, addr)
+}
+
+// WriteMemory always fails, the memory of a core file can not be changed.
+func (p *Process) WriteMemory(addr uint64, data []byte) (int, error) {
+	return 0, ErrReadOnly
+}
+
+// Close closes the core file and the executable.
+func (p *Process) Close() error {
+	var err error
+	for _, f := range p.files {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// splicedMemory is the memory of the process made of regions backed by
+// different files, a region added later takes precedence over the
+// regions it overlaps.
+type splicedMemory struct {
+	regions []memRegion
+}
+
+type memRegion struct {
+	start, end uint64
+	r          io.ReaderAt
+	off        uint64
+}
+
+func (m *splicedMemory) add(start, size uint64, r io.ReaderAt, off uint64) {
+	if size == 0 {
+		return
+	}
+	m.regions = append(m.regions, memRegion{start: start, end: start + size, r: r, off: off})
+}
+
+func (m *splicedMemory) ReadMemory(data []byte, addr uint64) (int, error) {
+	n := 0
+	for n < len(data) {
+		cur := addr + uint64(n)
+		i := m.find(cur)
+		if i < 0 {
+			return n, fmt.Errorf("could not read memory at %#x: address not in core file", cur)
+		}
+		reg := m.regions[i]
+		end := min(reg.end, addr+uint64(len(data)))
+		// stop at the start of any region that takes precedence
+		for _, o := range m.regions[i+1:] {
+			if o.start > cur && o.start < end {
+				end = o.start
+			}
+		}
+		read, err := reg.r.ReadAt(data[n:n+int(end-cur)], int64(reg.off+cur-reg.start))
+		n += read
+		if err != nil && !(err == io.EOF && read == int(end-cur)) {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// find returns the index of the region containing addr that takes
+// precedence, -1 if there is none.
+func (m *splicedMemory) find(addr uint64) int {
+	for i := len(m.regions) - 1; i >= 0; i-- {
+		if addr >= m.regions[i].start && addr < m.regions[i].end {
+			return i
+		}
+	}
+	return -1
+}
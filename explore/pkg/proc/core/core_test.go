@@ -0,0 +1,114 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplicedMemory(t *testing.T) {
+	// a mapped file with a page partially overwritten by a dumped segment
+	file := bytes.NewReader(bytes.Repeat([]byte{0xf}, 0x30))
+	dumped := bytes.NewReader(bytes.Repeat([]byte{0xd}, 0x10))
+
+	m := &splicedMemory{}
+	m.add(0x1000, 0x30, file, 0)
+	m.add(0x1010, 0x10, dumped, 0)
+
+	buf := make([]byte, 0x30)
+	n, err := m.ReadMemory(buf, 0x1000)
+	if err != nil || n != len(buf) {
+		t.Fatalf("ReadMemory = %d, %v", n, err)
+	}
+	want := append(append(bytes.Repeat([]byte{0xf}, 0x10), bytes.Repeat([]byte{0xd}, 0x10)...), bytes.Repeat([]byte{0xf}, 0x10)...)
+	if !bytes.Equal(buf, want) {
+		t.Errorf("read %x, want %x", buf, want)
+	}
+
+	if _, err := m.ReadMemory(buf[:8], 0x102c); err == nil {
+		t.Error("read past the end of the memory did not fail")
+	}
+}
+
+// holeMemory is 3 pages of memory at 0x10000 with an unreadable middle page.
+type holeMemory struct{}
+
+func (holeMemory) ReadMemory(data []byte, addr uint64) (int, error) {
+	for i := range data {
+		cur := addr + uint64(i)
+		if cur < 0x10000 || cur >= 0x13000 || (cur >= 0x11000 && cur < 0x12000) {
+			return i, errors.New("unreadable")
+		}
+		data[i] = byte(cur >> 12)
+	}
+	return len(data), nil
+}
+
+func TestWriteOpen(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gnu, goID, err := ExeBuildIDs(exe)
+	if err != nil {
+		t.Skipf("test binary is not an ELF file: %v", err)
+	}
+
+	d := &Dump{
+		Info:     DumpInfo{Pid: 42, Exe: exe, GOOS: "linux", GOARCH: "amd64", BuildID: gnu, GoBuildID: goID},
+		Threads:  []ThreadRegs{{Tid: 42, Regs: make([]uint64, amd64NumRegs)}, {Tid: 43, Regs: make([]uint64, amd64NumRegs)}},
+		Mappings: []Mapping{{Start: 0x10000, End: 0x13000, Perms: "rw-p"}, {Start: 0x20000, End: 0x21000, Perms: "---p"}},
+		Mem:      holeMemory{},
+	}
+	d.Threads[1].Regs[16] = 0x401000 // rip
+
+	path := filepath.Join(t.TempDir(), "dump")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(f, d); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	p, err := Open(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if p.Pid != 42 || p.Info == nil || p.Info.Exe != exe {
+		t.Errorf("pid %d, info %+v", p.Pid, p.Info)
+	}
+	if threads := p.Threads(); len(threads) != 2 {
+		t.Errorf("%d threads, want 2", len(threads))
+	} else if regs, _ := threads[1].Registers(); threads[1].ThreadID() != 43 || regs.PC() != 0x401000 {
+		t.Errorf("thread %d with pc %#x", threads[1].ThreadID(), regs.PC())
+	}
+
+	buf := make([]byte, 2)
+	if _, err := p.ReadMemory(buf, 0x10fff); err == nil {
+		t.Error("read of an unreadable page did not fail")
+	}
+	if _, err := p.ReadMemory(buf, 0x12ffe); err != nil || buf[0] != 0x12 {
+		t.Errorf("ReadMemory = %x, %v", buf, err)
+	}
+
+	if gnu != "" || goID != "" {
+		if _, err := Open(path, "/proc/self/exe"); err != nil {
+			t.Errorf("opening with the same executable: %v", err)
+		}
+		d.Info.BuildID, d.Info.GoBuildID = "00", "00"
+		f, _ := os.Create(path)
+		if err := Write(f, d); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		if _, err := Open(path, ""); !errors.Is(err, errBuildIDMismatch) {
+			t.Errorf("opening with another executable: %v", err)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package core
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"explore/pkg/proc"
+)
+
+// Notes written in dumps, besides the standard ones, under the name
+// exploreNoteName.
+const (
+	exploreNoteName = "EXPLORE"
+	ntExploreInfo   = 1
+)
+
+// dumpChunkSize is the size of the reads of the memory of the process
+// while dumping it, unreadable chunks are retried one page at a time.
+const dumpChunkSize = 1 << 20
+
+const pageSize = 4096
+
+// DumpInfo describes the process a dump was taken from. It is saved as a
+// JSON note in the dump.
+type DumpInfo struct {
+	Pid    int    `json:"pid"`
+	Exe    string `json:"exe"`
+	GOOS   string `json:"goos"`
+	GOARCH string `json:"goarch"`
+	// BuildID is the GNU build ID of the executable, if it has one
+	BuildID string `json:"buildID,omitempty"`
+	// GoBuildID is the build ID written by the Go linker
+	GoBuildID string    `json:"goBuildID,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Mapping is a memory mapping of a process, as listed in /proc/<pid>/maps.
+type Mapping struct {
+	Start, End uint64
+	Perms      string // e.g. "r-xp"
+	Offset     uint64 // offset in the mapped file
+	Path       string // mapped file, empty for anonymous mappings
+}
+
+// ThreadRegs are the general purpose registers of a thread, in the order
+// of the pr_reg field of a NT_PRSTATUS note: struct user_regs_struct on
+// amd64, struct user_pt_regs on arm64.
+type ThreadRegs struct {
+	Tid  int
+	Regs []uint64
+}
+
+// Dump is the state of a process saved by Write.
+type Dump struct {
+	Info     DumpInfo
+	Threads  []ThreadRegs
+	Auxv     []byte
+	Mappings []Mapping
+	// Mem is the memory of the process, the readable parts of every
+	// mapping are saved.
+	Mem proc.MemoryReader
+}
+
+// Write saves d as an ELF core file, which can be opened with Open. Pages
+// that can not be read are left out of the file.
+func Write(w io.WriteSeeker, d *Dump) error {
+	var machine elf.Machine
+	switch d.Info.GOARCH {
+	case "amd64":
+		machine = elf.EM_X86_64
+	case "arm64":
+		machine = elf.EM_AARCH64
+	default:
+		return fmt.Errorf("dumps of %s processes are not supported", d.Info.GOARCH)
+	}
+
+	cw := &coreWriter{w: w}
+	// the program headers are written last, at the end of the file, once
+	// the readable parts of the memory are known
+	cw.off = int64(binary.Size(elf.Header64{}))
+	if _, err := w.Seek(cw.off, io.SeekStart); err != nil {
+		return err
+	}
+
+	notes, err := d.notes()
+	if err != nil {
+		return err
+	}
+	if err := cw.write(notes); err != nil {
+		return err
+	}
+	cw.progs = append(cw.progs, elf.Prog64{Type: uint32(elf.PT_NOTE), Off: uint64(cw.off) - uint64(len(notes)), Filesz: uint64(len(notes)), Align: 4})
+
+	for _, m := range d.Mappings {
+		if len(m.Perms) == 0 || m.Perms[0] != 'r' {
+			continue
+		}
+		if err := cw.writeMapping(d.Mem, m); err != nil {
+			return err
+		}
+	}
+
+	phoff := cw.off
+	for _, prog := range cw.progs {
+		if err := binary.Write(w, binary.LittleEndian, prog); err != nil {
+			return err
+		}
+	}
+
+	hdr := elf.Header64{
+		Type:      uint16(elf.ET_CORE),
+		Machine:   uint16(machine),
+		Version:   uint32(elf.EV_CURRENT),
+		Phoff:     uint64(phoff),
+		Ehsize:    uint16(binary.Size(elf.Header64{})),
+		Phentsize: uint16(binary.Size(elf.Prog64{})),
+		Phnum:     uint16(len(cw.progs)),
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	hdr.Ident[elf.EI_OSABI] = byte(elf.ELFOSABI_NONE)
+	if _, err := w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, hdr)
+}
+
+type coreWriter struct {
+	w     io.WriteSeeker
+	off   int64
+	progs []elf.Prog64
+}
+
+func (cw *coreWriter) write(b []byte) error {
+	n, err := cw.w.Write(b)
+	cw.off += int64(n)
+	return err
+}
+
+// writeMapping saves the readable parts of m, each run of readable pages
+// becomes a PT_LOAD segment.
+func (cw *coreWriter) writeMapping(mem proc.MemoryReader, m Mapping) error {
+	var flags elf.ProgFlag
+	for i, f := range []elf.ProgFlag{elf.PF_R, elf.PF_W, elf.PF_X} {
+		if len(m.Perms) > i && m.Perms[i] != '-' {
+			flags |= f
+		}
+	}
+
+	seg := elf.Prog64{Type: uint32(elf.PT_LOAD), Flags: uint32(flags), Align: pageSize}
+	flush := func(end uint64) {
+		if seg.Filesz > 0 {
+			cw.progs = append(cw.progs, seg)
+		}
+		seg.Vaddr, seg.Off, seg.Filesz, seg.Memsz = end, uint64(cw.off), 0, 0
+	}
+	flush(m.Start)
+
+	buf := make([]byte, dumpChunkSize)
+	for addr := m.Start; addr < m.End; {
+		chunk := buf[:min(uint64(len(buf)), m.End-addr)]
+		if n, err := mem.ReadMemory(chunk, addr); err == nil && n == len(chunk) {
+			if err := cw.write(chunk); err != nil {
+				return err
+			}
+			seg.Filesz += uint64(len(chunk))
+			seg.Memsz = seg.Filesz
+			addr += uint64(len(chunk))
+			continue
+		}
+		// retry page by page, an unreadable page ends the segment
+		for end := addr + uint64(len(chunk)); addr < end; addr += pageSize {
+			page := buf[:min(pageSize, end-addr)]
+			if n, err := mem.ReadMemory(page, addr); err != nil || n != len(page) {
+				flush(addr + uint64(len(page)))
+				continue
+			}
+			if err := cw.write(page); err != nil {
+				return err
+			}
+			seg.Filesz += uint64(len(page))
+			seg.Memsz = seg.Filesz
+		}
+	}
+	flush(m.End)
+	return nil
+}
+
+// notes returns the contents of the PT_NOTE segment of the dump.
+func (d *Dump) notes() ([]byte, error) {
+	var buf bytes.Buffer
+
+	regsOff := prStatusRegsOff
+	for _, th := range d.Threads {
+		desc := make([]byte, regsOff+len(th.Regs)*8+8) // pr_reg followed by pr_fpvalid and padding
+		binary.LittleEndian.PutUint32(desc[prStatusPidOff:], uint32(th.Tid))
+		for i, r := range th.Regs {
+			binary.LittleEndian.PutUint64(desc[regsOff+i*8:], r)
+		}
+		writeNote(&buf, "CORE", ntPrStatus, desc)
+	}
+
+	if d.Auxv != nil {
+		writeNote(&buf, "CORE", ntAuxv, d.Auxv)
+	}
+
+	var files []Mapping
+	for _, m := range d.Mappings {
+		if m.Path != "" && m.Path[0] == '/' {
+			files = append(files, m)
+		}
+	}
+	if len(files) > 0 {
+		var desc bytes.Buffer
+		binary.Write(&desc, binary.LittleEndian, []uint64{uint64(len(files)), pageSize})
+		for _, m := range files {
+			binary.Write(&desc, binary.LittleEndian, []uint64{m.Start, m.End, m.Offset / pageSize})
+		}
+		for _, m := range files {
+			desc.WriteString(m.Path)
+			desc.WriteByte(0)
+		}
+		writeNote(&buf, "CORE", ntFile, desc.Bytes())
+	}
+
+	info, err := json.Marshal(d.Info)
+	if err != nil {
+		return nil, err
+	}
+	writeNote(&buf, exploreNoteName, ntExploreInfo, info)
+
+	return buf.Bytes(), nil
+}
+
+func writeNote(buf *bytes.Buffer, name string, typ uint32, desc []byte) {
+	namesz := uint32(len(name) + 1)
+	binary.Write(buf, binary.LittleEndian, []uint32{namesz, uint32(len(desc)), typ})
+	buf.WriteString(name)
+	buf.Write(make([]byte, alignNote(namesz)-namesz+1))
+	buf.Write(desc)
+	buf.Write(make([]byte, alignNote(uint32(len(desc)))-uint32(len(desc))))
+}
+
+// ExeBuildIDs returns the GNU and the Go build IDs of the executable at
+// path, empty if the executable does not have one.
+func ExeBuildIDs(path string) (gnu, goID string, err error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	read := func(name string) []byte {
+		sec := f.Section(name)
+		if sec == nil {
+			return nil
+		}
+		data, err := sec.Data()
+		if err != nil || len(data) < 12 {
+			return nil
+		}
+		namesz := alignNote(binary.LittleEndian.Uint32(data[0:]))
+		descsz := binary.LittleEndian.Uint32(data[4:])
+		if 12+uint64(namesz)+uint64(descsz) > uint64(len(data)) {
+			return nil
+		}
+		return data[12+namesz : 12+namesz+descsz]
+	}
+
+	gnu = fmt.Sprintf("%x", read(".note.gnu.build-id"))
+	goID = string(bytes.TrimRight(read(".note.go.buildid"), "\x00"))
+	return gnu, goID, nil
+}
+
+// errBuildIDMismatch is returned by Open when a dump was not taken from
+// the executable it is opened with.
+var errBuildIDMismatch = errors.New("build ID mismatch")
+
+// checkBuildID verifies that the executable at exePath is the one info
+// was recorded from.
+func checkBuildID(info *DumpInfo, exePath string) error {
+	gnu, goID, err := ExeBuildIDs(exePath)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.BuildID != "" && gnu != info.BuildID:
+		return fmt.Errorf("%w: the dump was taken from %s with build ID %s, %s has build ID %q", errBuildIDMismatch, info.Exe, info.BuildID, exePath, gnu)
+	case info.GoBuildID != "" && goID != info.GoBuildID:
+		return fmt.Errorf("%w: the dump was taken from %s with Go build ID %s, %s has Go build ID %q", errBuildIDMismatch, info.Exe, info.GoBuildID, exePath, goID)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"explore/pkg/proc"
+)
+
+// Note types of Linux core files, see include/uapi/linux/elf.h.
+const (
+	ntPrStatus = 1
+	ntAuxv     = 6
+	ntFile     = 0x46494c45 // "FILE"
+)
+
+// prStatusRegsOff is the offset of pr_reg in struct elf_prstatus, after
+// the signal information, the ids of the process and its CPU times.
+const prStatusRegsOff = 112
+
+// prStatusPidOff is the offset of pr_pid in struct elf_prstatus.
+const prStatusPidOff = 32
+
+type coreNotes struct {
+	threads []*thread
+	auxv    []byte
+	files   []fileMapping
+	info    *DumpInfo // only in dumps written by Write
+}
+
+// fileMapping is a file mapped in the memory of the process, from the
+// NT_FILE note.
+type fileMapping struct {
+	start, end uint64
+	off        uint64
+	path       string
+}
+
+// readNotes parses the PT_NOTE segments of core.
+func readNotes(core *elf.File, goarch string) (*coreNotes, error) {
+	notes := &coreNotes{}
+	for _, prog := range core.Progs {
+		if prog.Type != elf.PT_NOTE {
+			continue
+		}
+		data, err := io.ReadAll(prog.Open())
+		if err != nil {
+			return nil, fmt.Errorf("could not read notes: %v", err)
+		}
+		for len(data) >= 12 {
+			namesz := binary.LittleEndian.Uint32(data[0:])
+			descsz := binary.LittleEndian.Uint32(data[4:])
+			typ := binary.LittleEndian.Uint32(data[8:])
+			data = data[12:]
+			if uint64(alignNote(namesz))+uint64(alignNote(descsz)) > uint64(len(data)) {
+				return nil, errors.New("malformed note in core file")
+			}
+			name := strings.TrimRight(string(data[:namesz]), "\x00")
+			desc := data[alignNote(namesz) : alignNote(namesz)+descsz]
+			data = data[alignNote(namesz)+alignNote(descsz):]
+
+			if name == exploreNoteName {
+				if typ == ntExploreInfo {
+					notes.info = &DumpInfo{}
+					if err := json.Unmarshal(desc, notes.info); err != nil {
+						return nil, fmt.Errorf("malformed dump information: %v", err)
+					}
+				}
+				continue
+			}
+
+			switch typ {
+			case ntPrStatus:
+				th, err := readPrStatus(desc, goarch)
+				if err != nil {
+					return nil, err
+				}
+				notes.threads = append(notes.threads, th)
+			case ntAuxv:
+				notes.auxv = desc
+			case ntFile:
+				notes.files = readFileNote(desc)
+			}
+		}
+	}
+	return notes, nil
+}
+
+func alignNote(n uint32) uint32 {
+	return (n + 3) &^ 3
+}
+
+// readFileNote parses a NT_FILE note: the number of mappings and the page
+// size, followed by the start, end and page offset of each mapping and
+// then by their paths.
+func readFileNote(desc []byte) []fileMapping {
+	word := func(i int) uint64 { return binary.LittleEndian.Uint64(desc[i*8:]) }
+	if len(desc) < 16 {
+		return nil
+	}
+	count, pageSize := int(word(0)), word(1)
+	if len(desc) < (2+3*count)*8 {
+		return nil
+	}
+	paths := strings.Split(string(desc[(2+3*count)*8:]), "\x00")
+	if len(paths) < count {
+		return nil
+	}
+
+	files := make([]fileMapping, count)
+	for i := range files {
+		files[i] = fileMapping{
+			start: word(2 + 3*i),
+			end:   word(3 + 3*i),
+			off:   word(4+3*i) * pageSize,
+			path:  paths[i],
+		}
+	}
+	return files
+}
+
+// readPrStatus parses a NT_PRSTATUS note, the state of a thread.
+func readPrStatus(desc []byte, goarch string) (*thread, error) {
+	nregs := amd64NumRegs
+	if goarch == "arm64" {
+		nregs = arm64NumRegs
+	}
+	if len(desc) < prStatusRegsOff+nregs*8 {
+		return nil, errors.New("malformed NT_PRSTATUS note in core file")
+	}
+
+	th := &thread{pid: int(int32(binary.LittleEndian.Uint32(desc[prStatusPidOff:])))}
+	regs := make([]uint64, nregs)
+	if err := binary.Read(bytes.NewReader(desc[prStatusRegsOff:]), binary.LittleEndian, regs); err != nil {
+		return nil, err
+	}
+	if goarch == "arm64" {
+		th.regs = arm64Registers(regs)
+	} else {
+		th.regs = amd64Registers(regs)
+	}
+	return th, nil
+}
+
+// thread is a thread saved in the core file.
+type thread struct {
+	pid  int
+	regs proc.Registers
+}
+
+func (t *thread) ThreadID() int {
+	return t.pid
+}
+
+func (t *thread) Registers() (proc.Registers, error) {
+	return t.regs, nil
+}
+
+// amd64Registers are the registers of struct user_regs_struct, in order.
+type amd64Registers []uint64
+
+const amd64NumRegs = 27
+
+var amd64RegNames = [amd64NumRegs]string{
+	"R15", "R14", "R13", "R12", "Rbp", "Rbx", "R11", "R10", "R9", "R8",
+	"Rax", "Rcx", "Rdx", "Rsi", "Rdi", "Orig_rax", "Rip", "Cs", "Eflags",
+	"Rsp", "Ss", "Fs_base", "Gs_base", "Ds", "Es", "Fs", "Gs",
+}
+
+func (r amd64Registers) PC() uint64  { return r[16] }
+func (r amd64Registers) SP() uint64  { return r[19] }
+func (r amd64Registers) BP() uint64  { return r[4] }
+func (r amd64Registers) LR() uint64  { return 0 }
+func (r amd64Registers) TLS() uint64 { return r[21] }
+
+func (r amd64Registers) GAddr() (uint64, bool) { return 0, false }
+
+func (r amd64Registers) Slice(floatingPoint bool) ([]proc.Register, error) {
+	var out []proc.Register
+	for i, name := range amd64RegNames {
+		out = proc.AppendUint64Register(out, name, r[i])
+	}
+	return out, nil
+}
+
+func (r amd64Registers) Copy() (proc.Registers, error) {
+	return slices.Clone(r), nil
+}
+
+// arm64Registers are the registers of struct user_pt_regs: x0 to x30, sp,
+// pc and pstate.
+type arm64Registers []uint64
+
+const arm64NumRegs = 34
+
+func (r arm64Registers) PC() uint64  { return r[32] }
+func (r arm64Registers) SP() uint64  { return r[31] }
+func (r arm64Registers) BP() uint64  { return r[29] }
+func (r arm64Registers) LR() uint64  { return r[30] }
+func (r arm64Registers) TLS() uint64 { return 0 }
+
+// GAddr returns the value of x28, the register holding the current g in
+// Go code.
+func (r arm64Registers) GAddr() (uint64, bool) { return r[28], true }
+
+func (r arm64Registers) Slice(floatingPoint bool) ([]proc.Register, error) {
+	var out []proc.Register
+	for i := 0; i <= 30; i++ {
+		out = proc.AppendUint64Register(out, fmt.Sprintf("X%d", i), r[i])
+	}
+	out = proc.AppendUint64Register(out, "SP", r[31])
+	out = proc.AppendUint64Register(out, "PC", r[32])
+	out = proc.AppendUint64Register(out, "Pstate", r[33])
+	return out, nil
+}
+
+func (r arm64Registers) Copy() (proc.Registers, error) {
+	return slices.Clone(r), nil
+}
@@ -0,0 +1,114 @@
+package desc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Param is a parameter or a result of a function.
+type Param struct {
+	// Name is empty for unnamed parameters, and ~r0, ~r1... for unnamed
+	// results
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Function is a function of the process, from its debug information.
+type Function struct {
+	Name string `json:"name"`
+	// Entry and End are the addresses of the code of the function, equal
+	// when all its calls are inlined
+	Entry   uint64  `json:"entry"`
+	End     uint64  `json:"end"`
+	File    string  `json:"file,omitempty"`
+	Line    int     `json:"line,omitempty"`
+	Params  []Param `json:"params"`
+	Results []Param `json:"results"`
+	// Optimized is set when the package of the function was compiled with
+	// optimizations, the default
+	Optimized bool `json:"optimized"`
+	// Inlined is the number of the calls to the function inlined by the
+	// compiler
+	Inlined int `json:"inlined"`
+}
+
+func (f *Function) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "func %s(%s)", f.Name, params(f.Params))
+	switch {
+	case len(f.Results) == 1 && unnamed(f.Results[0].Name):
+		fmt.Fprintf(&buf, " %s", f.Results[0].Type)
+	case len(f.Results) > 0:
+		fmt.Fprintf(&buf, " (%s)", params(f.Results))
+	}
+	if f.Entry == f.End {
+		buf.WriteString("\nno code")
+	} else {
+		fmt.Fprintf(&buf, "\n%s:%d\n", f.File, f.Line)
+		fmt.Fprintf(&buf, "entry %#x, end %#x, %d bytes", f.Entry, f.End, f.End-f.Entry)
+	}
+	if f.Optimized {
+		buf.WriteString(", optimized")
+	}
+	if f.Inlined > 0 {
+		fmt.Fprintf(&buf, ", inlined at %d call site", f.Inlined)
+		if f.Inlined > 1 {
+			buf.WriteString("s")
+		}
+	}
+	return buf.String()
+}
+
+// params returns the parameters ps as in a function signature.
+func params(ps []Param) string {
+	s := make([]string, len(ps))
+	for i, p := range ps {
+		if unnamed(p.Name) {
+			s[i] = p.Type
+		} else {
+			s[i] = p.Name + " " + p.Type
+		}
+	}
+	return strings.Join(s, ", ")
+}
+
+func unnamed(name string) bool {
+	return name == "" || name == "_" || strings.HasPrefix(name, "~r")
+}
+
+// Instruction is a machine instruction of a function.
+type Instruction struct {
+	PC    uint64 `json:"pc"`
+	Bytes []byte `json:"bytes"`
+	Text  string `json:"text"`
+	File  string `json:"file"`
+	Line  int    `json:"line"`
+}
+
+// Disassembly are the instructions of a function, with the lines of the
+// source they were compiled from.
+type Disassembly struct {
+	Function     string        `json:"function"`
+	Flavour      string        `json:"flavour"`
+	Instructions []Instruction `json:"instructions"`
+	// Source are the lines of the source files by file and line, when the
+	// files could be read
+	Source map[string]map[int]string `json:"-"`
+}
+
+func (d *Disassembly) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "TEXT %s", d.Function)
+	file, line := "", -1
+	for _, inst := range d.Instructions {
+		if inst.File != file || inst.Line != line {
+			file, line = inst.File, inst.Line
+			fmt.Fprintf(&buf, "\n%s:%d", file, line)
+			if src, ok := d.Source[file][line]; ok {
+				fmt.Fprintf(&buf, "\t%s", strings.TrimSpace(src))
+			}
+		}
+		fmt.Fprintf(&buf, "\n  %#x\t% -30x\t%s", inst.PC, inst.Bytes, inst.Text)
+	}
+	return buf.String()
+}
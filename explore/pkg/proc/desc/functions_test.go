@@ -0,0 +1,37 @@
+package desc
+
+import "testing"
+
+func TestFunctionString(t *testing.T) {
+	tests := []struct {
+		f    Function
+		want string
+	}{
+		{
+			f: Function{
+				Name: "main.div", Entry: 0x49d960, End: 0x49da14, File: "/src/main.go", Line: 12,
+				Params:    []Param{{Name: "a", Type: "int"}, {Name: "b", Type: "int"}},
+				Results:   []Param{{Name: "~r0", Type: "int"}, {Name: "~r1", Type: "error"}},
+				Optimized: true,
+			},
+			want: `func main.div(a int, b int) (int, error)
+/src/main.go:12
+entry 0x49d960, end 0x49da14, 180 bytes, optimized`,
+		},
+		{
+			f: Function{
+				Name:    "main.add",
+				Params:  []Param{{Name: "_", Type: "int"}},
+				Results: []Param{{Name: "~r0", Type: "int"}},
+				Inlined: 2,
+			},
+			want: `func main.add(int) int
+no code, inlined at 2 call sites`,
+		},
+	}
+	for _, test := range tests {
+		if got := test.f.String(); got != test.want {
+			t.Errorf("got\n%s\nwant\n%s", got, test.want)
+		}
+	}
+}
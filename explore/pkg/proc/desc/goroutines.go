@@ -0,0 +1,114 @@
+package desc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Location holds program location information.
+type Location struct {
+	PC       uint64 `json:"pc"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function,omitempty"`
+}
+
+func (l Location) String() string {
+	if l.File == "" {
+		return fmt.Sprintf("%#x", l.PC)
+	}
+	if l.Function == "" {
+		return fmt.Sprintf("%s:%d (%#x)", l.File, l.Line, l.PC)
+	}
+	return fmt.Sprintf("%s:%d %s (%#x)", l.File, l.Line, l.Function, l.PC)
+}
+
+// Goroutine describes a goroutine of the process.
+type Goroutine struct {
+	// ID is the goroutine id
+	ID int64 `json:"id"`
+	// Status is the name of the status of the goroutine, e.g. "waiting"
+	Status string `json:"status"`
+	// WaitReason is the reason why a waiting goroutine is blocked, e.g. "chan receive"
+	WaitReason string `json:"waitReason,omitempty"`
+	// WaitFor is for how long the goroutine has been blocked, zero if not known
+	WaitFor time.Duration `json:"waitFor,omitempty"`
+	// CurrentLoc is the location at which the goroutine was last parked
+	CurrentLoc Location `json:"currentLoc"`
+	// GoStatementLoc is the location of the 'go' statement that started the goroutine
+	GoStatementLoc Location `json:"goStatementLoc"`
+	// StartLoc is the location of the first function run by the goroutine
+	StartLoc Location `json:"startLoc"`
+
+	// Unreadable is set if the G struct of the goroutine could not be read
+	Unreadable string `json:"unreadable,omitempty"`
+}
+
+// String returns a representation of g on a single line.
+func (g *Goroutine) String() string {
+	if g.Unreadable != "" {
+		return fmt.Sprintf("Goroutine (unreadable %s)", g.Unreadable)
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Goroutine %d - Go: %s - Start: %s [%s", g.ID, g.GoStatementLoc, g.StartLoc.Function, g.Status)
+	if g.WaitReason != "" {
+		fmt.Fprintf(&buf, ": %s", g.WaitReason)
+	}
+	if g.WaitFor > 0 {
+		fmt.Fprintf(&buf, ", %v", g.WaitFor.Truncate(time.Second))
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// Goroutines is a list of goroutines.
+type Goroutines []*Goroutine
+
+// String returns a representation of gs with one goroutine per line,
+// followed by the number of goroutines.
+func (gs Goroutines) String() string {
+	var buf strings.Builder
+	for _, g := range gs {
+		buf.WriteString(g.String())
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(&buf, "[%d goroutines]", len(gs))
+	return buf.String()
+}
+
+// Stackframe describes one frame in a stack trace.
+type Stackframe struct {
+	Location
+	// Inlined is true if the frame is a call inlined in the next frame
+	Inlined bool `json:"inlined,omitempty"`
+	// Err is set if the stack could not be unwound past this frame
+	Err string `json:"err,omitempty"`
+}
+
+// Stacktrace is the list of frames of a goroutine stack, the innermost
+// frame first.
+type Stacktrace []Stackframe
+
+// String returns a representation of st with one frame every two lines,
+// the function followed by its file and line.
+func (st Stacktrace) String() string {
+	var buf strings.Builder
+	for i, frame := range st {
+		if frame.Err != "" {
+			fmt.Fprintf(&buf, "(truncated: %s)\n", frame.Err)
+			continue
+		}
+		fn := frame.Function
+		if fn == "" {
+			fn = "?"
+		}
+		inlined := ""
+		if frame.Inlined {
+			inlined = " (inlined)"
+		}
+		fmt.Fprintf(&buf, "%2d  %#016x in %s%s\n    at %s:%d\n", i, frame.PC, fn, inlined, frame.File, frame.Line)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,150 @@
+package desc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Formats of the memory read by the examine command.
+const (
+	MemoryHex  = "hex"
+	MemoryDec  = "dec"
+	MemoryChar = "char"
+	MemoryPtr  = "ptr"
+	MemoryStr  = "str"
+)
+
+// MemoryFormats are the formats of Memory.
+var MemoryFormats = []string{MemoryHex, MemoryDec, MemoryChar, MemoryPtr, MemoryStr}
+
+// memoryLineLen is the number of bytes printed on each line, except for
+// pointers which are printed one per line.
+const memoryLineLen = 16
+
+// Memory is a range of the memory of the process.
+type Memory struct {
+	// Addr is the address of the first byte of Data
+	Addr uint64 `json:"addr"`
+	// Format is how Data is printed, one of MemoryFormats
+	Format string `json:"format"`
+	// Size is the size in bytes of each value in Data
+	Size int    `json:"size"`
+	Data []byte `json:"data"`
+	// Symbols are the functions and package variables the values of Data
+	// point to, by index of the value, for the ptr format
+	Symbols map[int]string `json:"symbols,omitempty"`
+}
+
+func (m *Memory) String() string {
+	if m.Format == MemoryStr {
+		return fmt.Sprintf("%#x: %q", m.Addr, m.Data)
+	}
+
+	perLine := memoryLineLen / m.Size
+	if m.Format == MemoryPtr {
+		perLine = 1
+	}
+
+	var lines []string
+	var line strings.Builder
+	n := len(m.Data) / m.Size
+	for i := 0; i < n; i++ {
+		if i%perLine == 0 {
+			if i > 0 {
+				lines = append(lines, strings.TrimRight(line.String(), " "))
+				line.Reset()
+			}
+			fmt.Fprintf(&line, "%#x:", m.Addr+uint64(i*m.Size))
+		}
+		val := m.Value(i)
+		switch m.Format {
+		case MemoryDec:
+			fmt.Fprintf(&line, " %*d", decWidth[m.Size], val)
+		case MemoryChar:
+			q := strconv.Quote(string(m.Data[i : i+1]))
+			fmt.Fprintf(&line, " %-4s", q[1:len(q)-1])
+		default:
+			fmt.Fprintf(&line, " %#0*x", 2*m.Size, val)
+		}
+		if sym := m.Symbols[i]; sym != "" {
+			fmt.Fprintf(&line, " <%s>", sym)
+		}
+	}
+	lines = append(lines, strings.TrimRight(line.String(), " "))
+	return strings.Join(lines, "\n")
+}
+
+// decWidth is the number of digits of the largest value of each size.
+var decWidth = map[int]int{1: 3, 2: 5, 4: 10, 8: 20}
+
+// Value returns the i-th value of Data.
+func (m *Memory) Value(i int) uint64 {
+	b := m.Data[i*m.Size : (i+1)*m.Size]
+	switch m.Size {
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(b))
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(b))
+	case 8:
+		return binary.LittleEndian.Uint64(b)
+	}
+	return uint64(b[0])
+}
+
+// MemoryMatch is an address of the memory of the process holding the bytes
+// searched for.
+type MemoryMatch struct {
+	Addr uint64 `json:"addr"`
+	// Owner is the package variable, the heap object or the goroutine stack
+	// containing Addr, e.g. main.cfg+0x8
+	Owner string `json:"owner,omitempty"`
+}
+
+// MemoryMatches are the matches in a memory mapping of the process that
+// all are in the same kind of memory.
+type MemoryMatches struct {
+	// Kind is what the memory holds: heap, stack, data, bss, rodata or
+	// text, anon for other anonymous mappings and file for mapped files
+	Kind    string        `json:"kind"`
+	Start   uint64        `json:"start"`
+	End     uint64        `json:"end"`
+	Perms   string        `json:"perms"`
+	Path    string        `json:"path,omitempty"`
+	Matches []MemoryMatch `json:"matches"`
+}
+
+// MemorySearch is the result of a search of the memory of the process.
+type MemorySearch struct {
+	Mappings []MemoryMatches `json:"mappings"`
+	// Truncated is set if the search stopped at the maximum number of
+	// matches
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func (s *MemorySearch) String() string {
+	var buf strings.Builder
+	n := 0
+	for _, m := range s.Mappings {
+		fmt.Fprintf(&buf, "%s %#x-%#x %s", m.Kind, m.Start, m.End, m.Perms)
+		if m.Path != "" {
+			fmt.Fprintf(&buf, " %s", m.Path)
+		}
+		buf.WriteByte('\n')
+		for _, match := range m.Matches {
+			fmt.Fprintf(&buf, "\t%#x", match.Addr)
+			if match.Owner != "" {
+				fmt.Fprintf(&buf, "\t%s", match.Owner)
+			}
+			buf.WriteByte('\n')
+		}
+		n += len(m.Matches)
+	}
+	if s.Truncated {
+		fmt.Fprintf(&buf, "%d matches, stopped at the maximum number of matches", n)
+	} else {
+		fmt.Fprintf(&buf, "%d matches", n)
+	}
+	return buf.String()
+}
@@ -0,0 +1,22 @@
+package desc
+
+import "testing"
+
+func TestMemoryString(t *testing.T) {
+	data := []byte{'h', 'i', 0, 0xff, 0x10, 0, 0, 0, 0, 0, 0, 0, 0x20, 0, 0, 0}
+	for _, tc := range []struct {
+		m    Memory
+		want string
+	}{
+		{Memory{Addr: 0x1000, Format: MemoryHex, Size: 4, Data: data[:8]}, "0x1000: 0xff006968 0x00000010"},
+		{Memory{Addr: 0x1000, Format: MemoryDec, Size: 2, Data: data[4:8]}, "0x1000:    16     0"},
+		{Memory{Addr: 0x1000, Format: MemoryChar, Size: 1, Data: data[:4]}, `0x1000: h    i    \x00 \xff`},
+		{Memory{Addr: 0x1000, Format: MemoryStr, Size: 1, Data: data[:3]}, `0x1000: "hi\x00"`},
+		{Memory{Addr: 0x1000, Format: MemoryPtr, Size: 8, Data: data, Symbols: map[int]string{1: "main.cfg+0x8"}},
+			"0x1000: 0x00000010ff006968\n0x1008: 0x0000002000000000 <main.cfg+0x8>"},
+	} {
+		if got := tc.m.String(); got != tc.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tc.m.Format, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package desc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Reference is a pointer to the object looked up by a reverse pointer
+// lookup.
+type Reference struct {
+	// Path is the access path of the pointer from a package variable, e.g.
+	// main.registry.handlers["x"].next
+	Path string `json:"path"`
+	// Addr is the address of the pointer
+	Addr uint64 `json:"addr"`
+	// Ptr is the value of the pointer
+	Ptr uint64 `json:"ptr"`
+	// Holder is the object holding the pointer
+	Holder Object `json:"holder"`
+}
+
+// Object is an object of the memory of the process: a package variable, a
+// heap object or a value in static data.
+type Object struct {
+	Addr uint64 `json:"addr"`
+	Size uint64 `json:"size"`
+	// Type is the type of the object, empty if it is not known
+	Type string `json:"type,omitempty"`
+	// Name is the name of package variables
+	Name string `json:"name,omitempty"`
+	// Static is set for the values allocated statically by the linker,
+	// e.g. the Config of var cfg = &Config{...}
+	Static bool `json:"static,omitempty"`
+}
+
+func (o Object) String() string {
+	var buf strings.Builder
+	if o.Name != "" {
+		buf.WriteString(o.Name)
+	} else if o.Static {
+		fmt.Fprintf(&buf, "static object %#x", o.Addr)
+	} else {
+		fmt.Fprintf(&buf, "heap object %#x", o.Addr)
+	}
+	if o.Type != "" {
+		fmt.Fprintf(&buf, " %s", o.Type)
+	}
+	fmt.Fprintf(&buf, " (%d bytes)", o.Size)
+	return buf.String()
+}
+
+// References are the pointers to an object found by a reverse pointer
+// lookup.
+type References struct {
+	// Addr is the address looked up
+	Addr uint64 `json:"addr"`
+	// Target is the object containing Addr, nil if it is not known
+	Target *Object     `json:"target,omitempty"`
+	Refs   []Reference `json:"refs"`
+	// Objects is the number of objects reachable from the package
+	// variables that were searched
+	Objects int `json:"objects"`
+	// Truncated is set if not all the objects or the references were
+	// searched
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func (r *References) String() string {
+	var buf strings.Builder
+	if r.Target != nil {
+		fmt.Fprintf(&buf, "%#x: %s\n", r.Addr, r.Target)
+	} else {
+		fmt.Fprintf(&buf, "%#x\n", r.Addr)
+	}
+	for _, ref := range r.Refs {
+		fmt.Fprintf(&buf, "%s\n\tat %#x in %s", ref.Path, ref.Addr, ref.Holder)
+		if ref.Ptr != r.Addr {
+			fmt.Fprintf(&buf, ", points to %#x", ref.Ptr)
+		}
+		buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&buf, "%d references in %d objects reachable from package variables", len(r.Refs), r.Objects)
+	if r.Truncated {
+		buf.WriteString(", stopped at the maximum number of objects or references")
+	}
+	return buf.String()
+}
+
+// HeapType are the allocated heap objects of a type, or of a size class
+// when their type is not known.
+type HeapType struct {
+	// Type is the type of the objects, []T for arrays of T, empty if it is
+	// not known
+	Type string `json:"type,omitempty"`
+	// Size is the size of the objects of unknown type
+	Size uint64 `json:"size,omitempty"`
+	// NoScan is set for objects of unknown type without pointers
+	NoScan  bool   `json:"noscan,omitempty"`
+	Objects uint64 `json:"objects"`
+	Bytes   uint64 `json:"bytes"`
+}
+
+func (t HeapType) String() string {
+	if t.Type != "" {
+		return t.Type
+	}
+	if t.NoScan {
+		return fmt.Sprintf("<%d byte objects without pointers>", t.Size)
+	}
+	return fmt.Sprintf("<%d byte objects>", t.Size)
+}
+
+// HeapInventory are the allocated heap objects by type, the largest first,
+// including the garbage not swept yet.
+type HeapInventory struct {
+	Types []HeapType `json:"types"`
+	// Objects and Bytes are the totals of all the types, Types included
+	Objects uint64 `json:"objects"`
+	Bytes   uint64 `json:"bytes"`
+	// Others is the number of types left out of Types
+	Others int `json:"others,omitempty"`
+}
+
+func (h *HeapInventory) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%12s %10s  %s\n", "bytes", "objects", "type")
+	for _, t := range h.Types {
+		fmt.Fprintf(&buf, "%12d %10d  %s\n", t.Bytes, t.Objects, t)
+	}
+	fmt.Fprintf(&buf, "%d bytes in %d objects", h.Bytes, h.Objects)
+	if h.Others > 0 {
+		fmt.Fprintf(&buf, ", %d more types", h.Others)
+	}
+	buf.WriteString("\nthe allocated objects include the garbage not swept yet")
+	return buf.String()
+}
+
+// Retained is the memory retained by an object: the heap objects only
+// reachable through it, which would be freed if it were cleared.
+type Retained struct {
+	Object  Object `json:"object"`
+	Bytes   uint64 `json:"bytes"`
+	Objects uint64 `json:"objects"`
+}
+
+func (r Retained) String() string {
+	return fmt.Sprintf("%s retains %d bytes in %d objects", r.Object, r.Bytes, r.Objects)
+}
+
+// RetainedReport is the memory retained by the package variables, the
+// largest first.
+type RetainedReport struct {
+	Vars []Retained `json:"vars"`
+	// Objects and Bytes are the heap objects reachable from the package
+	// variables, including the ones retained by several of them
+	Objects uint64 `json:"objects"`
+	Bytes   uint64 `json:"bytes"`
+	// Others is the number of variables left out of Vars
+	Others int `json:"others,omitempty"`
+	// Truncated is set if not all the reachable objects were walked
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func (r *RetainedReport) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%12s %10s  %s\n", "retained", "objects", "variable")
+	for _, v := range r.Vars {
+		fmt.Fprintf(&buf, "%12d %10d  %s %s\n", v.Bytes, v.Objects, v.Object.Name, v.Object.Type)
+	}
+	fmt.Fprintf(&buf, "%d bytes in %d heap objects reachable from package variables", r.Bytes, r.Objects)
+	if r.Others > 0 {
+		fmt.Fprintf(&buf, ", %d more variables", r.Others)
+	}
+	if r.Truncated {
+		buf.WriteString(", stopped at the maximum number of objects")
+	}
+	return buf.String()
+}
+
+// Graph is the graph of the objects reachable from a variable.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+	// Truncated is set if objects were left out of the graph
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GraphNode is an object of a Graph, the first one is the variable.
+type GraphNode struct {
+	ID int `json:"id"`
+	Object
+	// Depth is the number of pointers followed from the variable
+	Depth int `json:"depth"`
+}
+
+// GraphEdge is a pointer from the object From to the object To.
+type GraphEdge struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	// Label is the access path of the pointer in From: a field, an index
+	// or a key, e.g. .handlers["x"]
+	Label string `json:"label"`
+}
+
+// DOT returns the graph in the Graphviz DOT language.
+func (g *Graph) DOT() string {
+	var buf strings.Builder
+	name := "objects"
+	if len(g.Nodes) > 0 && g.Nodes[0].Name != "" {
+		name = g.Nodes[0].Name
+	}
+	fmt.Fprintf(&buf, "digraph %s {\n", strconv.Quote(name))
+	buf.WriteString("\tnode [shape=box];\n")
+	if g.Truncated {
+		buf.WriteString("\t// truncated: objects were left out\n")
+	}
+	for _, n := range g.Nodes {
+		label := fmt.Sprintf("%#x (%d bytes)", n.Addr, n.Size)
+		if n.Type != "" {
+			label = n.Type + "\n" + label
+		}
+		if n.Name != "" {
+			label = n.Name + "\n" + label
+		}
+		fmt.Fprintf(&buf, "\tn%d [label=%s];\n", n.ID, strconv.Quote(label))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&buf, "\tn%d -> n%d [label=%s];\n", e.From, e.To, strconv.Quote(e.Label))
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
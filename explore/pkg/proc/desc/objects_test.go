@@ -0,0 +1,45 @@
+package desc
+
+import "testing"
+
+func TestHeapInventoryString(t *testing.T) {
+	inv := &HeapInventory{
+		Types: []HeapType{
+			{Type: "[]main.user", Objects: 1, Bytes: 2736128},
+			{Size: 16, NoScan: true, Objects: 10, Bytes: 160},
+			{Size: 48, Objects: 2, Bytes: 96},
+		},
+		Objects: 20,
+		Bytes:   2736500,
+		Others:  4,
+	}
+	want := `       bytes    objects  type
+     2736128          1  []main.user
+         160         10  <16 byte objects without pointers>
+          96          2  <48 byte objects>
+2736500 bytes in 20 objects, 4 more types
+the allocated objects include the garbage not swept yet`
+	if got := inv.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGraphDOT(t *testing.T) {
+	g := &Graph{
+		Nodes: []GraphNode{
+			{ID: 0, Object: Object{Name: "main.head", Addr: 0x1000, Size: 8, Type: "*main.node"}},
+			{ID: 1, Object: Object{Addr: 0xc000010000, Size: 16, Type: "main.node"}, Depth: 1},
+		},
+		Edges: []GraphEdge{{From: 0, To: 1}, {From: 1, To: 1, Label: ".next"}},
+	}
+	want := `digraph "main.head" {
+	node [shape=box];
+	n0 [label="main.head\n*main.node\n0x1000 (8 bytes)"];
+	n1 [label="main.node\n0xc000010000 (16 bytes)"];
+	n0 -> n1 [label=""];
+	n1 -> n1 [label=".next"];
+}`
+	if got := g.DOT(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package desc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypeInfo is a type of the process.
+type TypeInfo struct {
+	Name string `json:"name"`
+	// Package is the import path of the package of named types, empty for
+	// the predeclared and the unnamed types
+	Package string `json:"package,omitempty"`
+	Kind    string `json:"kind"`
+	Size    int64  `json:"size"`
+}
+
+// Types are the types of the process, sorted by name.
+type Types struct {
+	Types []TypeInfo `json:"types"`
+}
+
+func (t *Types) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%10s  %-10s  %s\n", "size", "kind", "type")
+	for _, ti := range t.Types {
+		fmt.Fprintf(&buf, "%10d  %-10s  %s\n", ti.Size, ti.Kind, ti.Name)
+	}
+	fmt.Fprintf(&buf, "%d types", len(t.Types))
+	return buf.String()
+}
+
+// TypeDecl is the declaration of a type, or of the type of an expression.
+type TypeDecl struct {
+	// Expr is the expression whose type is declared, empty for types
+	Expr string `json:"expr,omitempty"`
+	TypeInfo
+	// Decl is the declaration of named types, e.g. type main.ID int, or
+	// the type itself for unnamed types
+	Decl string `json:"decl"`
+	// Concrete is the dynamic type of interface values
+	Concrete string `json:"concrete,omitempty"`
+}
+
+func (d *TypeDecl) String() string {
+	var buf strings.Builder
+	if d.Expr != "" {
+		fmt.Fprintf(&buf, "%s: %s\n", d.Expr, d.Name)
+	}
+	if d.Concrete != "" {
+		fmt.Fprintf(&buf, "concrete type: %s\n", d.Concrete)
+	}
+	fmt.Fprintf(&buf, "%s\n", d.Decl)
+	fmt.Fprintf(&buf, "%s, %d bytes", d.Kind, d.Size)
+	return buf.String()
+}
+
+// FieldLayout is the position of a field in the memory of a struct.
+type FieldLayout struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	Align  int64  `json:"align"`
+	// Padding is the number of unused bytes after the field
+	Padding int64 `json:"padding,omitempty"`
+}
+
+// TypeLayout is the layout of the fields of a struct type in memory.
+type TypeLayout struct {
+	Name   string        `json:"name"`
+	Size   int64         `json:"size"`
+	Align  int64         `json:"align"`
+	Fields []FieldLayout `json:"fields"`
+	// Padding is the number of unused bytes before the first field, between
+	// the fields and after the last one
+	Padding int64 `json:"padding"`
+}
+
+func (l *TypeLayout) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%s: %d bytes, align %d, %d bytes of padding\n", l.Name, l.Size, l.Align, l.Padding)
+	fmt.Fprintf(&buf, "%8s %6s %6s  %s\n", "offset", "size", "align", "field")
+	for _, f := range l.Fields {
+		fmt.Fprintf(&buf, "%8d %6d %6d  %s %s\n", f.Offset, f.Size, f.Align, f.Name, f.Type)
+		if f.Padding > 0 {
+			fmt.Fprintf(&buf, "%8d %6d %6s  <padding>\n", f.Offset+f.Size, f.Padding, "")
+		}
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
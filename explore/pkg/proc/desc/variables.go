@@ -110,6 +110,11 @@ type Variable struct {
 	// The other length cap applied to this field is related to maximum recursion depth, when the maximum recursion depth is reached this field is left empty, contrary to the previous one this cap also applies to structs (otherwise structs will always have all their member fields returned)
 	Children []Variable `json:"children"`
 
+	// Index of the first element in Children for a page of a collection
+	Offset int64 `json:"offset,omitempty"`
+	// Position of the next page of a map, see proc.MapCursor
+	Cursor string `json:"cursor,omitempty"`
+
 	// Base address of arrays, Base address of the backing array for slices (0 for nil slices)
 	// Base address of the backing byte array for strings
 	// address of the struct backing chan and map variables
@@ -243,6 +248,12 @@ func (v *Variable) writeTo(buf io.Writer, flags prettyFlags, indent, fmtstr stri
 			} else {
 				fmt.Fprintf(buf, "*(*%s)(%#x)", v.typeStr(flags), v.Addr)
 			}
+		} else if !flags.includeType() && data.isBasic() {
+			// the dynamic type of interfaces in collections, where the type
+			// of the interface itself is left out
+			fmt.Fprintf(buf, "%s(", data.typeStr(flags))
+			data.writeTo(buf, flags.set(prettyTop, false), indent, fmtstr)
+			fmt.Fprint(buf, ")")
 		} else {
 			v.Children[0].writeTo(buf, flags.set(prettyTop, false).set(prettyIncludeType, !flags.includeType()), indent, fmtstr)
 		}
@@ -268,6 +279,17 @@ func (v *Variable) writeTo(buf io.Writer, flags prettyFlags, indent, fmtstr stri
 	}
 }
 
+// isBasic reports whether v is printed without its type, e.g. numbers and
+// strings, but not the missing value of nil interfaces.
+func (v *Variable) isBasic() bool {
+	switch v.Kind {
+	case reflect.Invalid, reflect.Slice, reflect.Array, reflect.Ptr, reflect.UnsafePointer, reflect.Chan,
+		reflect.Struct, reflect.Interface, reflect.Map, reflect.Func:
+		return false
+	}
+	return true
+}
+
 func (v *Variable) typeStr(flags prettyFlags) string {
 	if flags.shortenType() {
 		return ShortenType(v.Type)
@@ -288,6 +310,7 @@ func (v *Variable) writeMapTo(buf io.Writer, flags prettyFlags, indent, fmtstr s
 	nl := flags.newlines() && (len(v.Children) > 0)
 
 	fmt.Fprint(buf, "[")
+	v.writeOffsetTo(buf, nl, indent)
 
 	for i := 0; i < len(v.Children); i += 2 {
 		key := &v.Children[i]
@@ -305,14 +328,14 @@ func (v *Variable) writeMapTo(buf io.Writer, flags prettyFlags, indent, fmtstr s
 		}
 	}
 
-	if len(v.Children)/2 != int(v.Len) {
+	if int(v.Offset)+len(v.Children)/2 != int(v.Len) {
 		if len(v.Children) != 0 {
 			if nl {
 				fmt.Fprintf(buf, "\n%s%s", indent, indentString)
 			} else {
 				fmt.Fprint(buf, ",")
 			}
-			fmt.Fprintf(buf, "...+%d more", int(v.Len)-(len(v.Children)/2))
+			fmt.Fprintf(buf, "...+%d more", int(v.Len-v.Offset)-(len(v.Children)/2))
 		} else {
 			fmt.Fprint(buf, "...")
 		}
@@ -345,6 +368,7 @@ func (v *Variable) writeArrayTo(buf io.Writer, flags prettyFlags, indent, fmtstr
 func (v *Variable) writeSliceOrArrayTo(buf io.Writer, flags prettyFlags, indent, fmtstr string) {
 	nl := v.shouldNewlineArray(flags.newlines())
 	fmt.Fprint(buf, "[")
+	v.writeOffsetTo(buf, nl, indent)
 
 	for i := range v.Children {
 		if nl {
@@ -356,14 +380,14 @@ func (v *Variable) writeSliceOrArrayTo(buf io.Writer, flags prettyFlags, indent,
 		}
 	}
 
-	if len(v.Children) != int(v.Len) {
+	if int(v.Offset)+len(v.Children) != int(v.Len) {
 		if len(v.Children) != 0 {
 			if nl {
 				fmt.Fprintf(buf, "\n%s%s", indent, indentString)
 			} else {
 				fmt.Fprint(buf, ",")
 			}
-			fmt.Fprintf(buf, "...+%d more", int(v.Len)-len(v.Children))
+			fmt.Fprintf(buf, "...+%d more", int(v.Len-v.Offset)-len(v.Children))
 		} else {
 			fmt.Fprint(buf, "...")
 		}
@@ -376,6 +400,38 @@ func (v *Variable) writeSliceOrArrayTo(buf io.Writer, flags prettyFlags, indent,
 	fmt.Fprint(buf, "]")
 }
 
+// writeOffsetTo writes the number of elements before the first child of
+// a page of a collection.
+func (v *Variable) writeOffsetTo(buf io.Writer, nl bool, indent string) {
+	if v.Offset <= 0 {
+		return
+	}
+	if nl {
+		fmt.Fprintf(buf, "\n%s%s", indent, indentString)
+	}
+	fmt.Fprintf(buf, "...%d before,", v.Offset)
+}
+
+// NextPage returns the options of get reading the page of v following the
+// loaded children, empty if they reach the end of v.
+func (v *Variable) NextPage() string {
+	switch v.Kind {
+	case reflect.Map:
+		if v.Cursor != "" {
+			return "--cursor " + v.Cursor
+		}
+	case reflect.Slice, reflect.Array:
+		if next := v.Offset + int64(len(v.Children)); len(v.Children) > 0 && next < v.Len {
+			return fmt.Sprintf("--offset %d", next)
+		}
+	case reflect.String:
+		if next := v.Offset + int64(len(v.Value)); len(v.Value) > 0 && next < v.Len {
+			return fmt.Sprintf("--offset %d", next)
+		}
+	}
+	return ""
+}
+
 func (v *Variable) recursiveKind() (reflect.Kind, bool) {
 	hasptr := false
 	var kind reflect.Kind
@@ -547,8 +603,11 @@ func (v *Variable) writeBasicType(buf io.Writer, fmtstr string) {
 	case reflect.String:
 		if fmtstr == "" {
 			s := v.Value
-			if len(s) != int(v.Len) {
-				s = fmt.Sprintf("%s...+%d more", s, int(v.Len)-len(s))
+			if int(v.Offset)+len(s) != int(v.Len) {
+				s = fmt.Sprintf("%s...+%d more", s, int(v.Len-v.Offset)-len(s))
+			}
+			if v.Offset > 0 {
+				s = fmt.Sprintf("...%d before,%s", v.Offset, s)
 			}
 			fmt.Fprintf(buf, "%q", s)
 			return
@@ -567,3 +626,23 @@ func ExtractIntValue(s string) string {
 	}
 	return s[open+1 : len(s)-1]
 }
+
+// Variables is a list of the local variables or arguments of a frame.
+type Variables []*Variable
+
+// String returns a representation of vs with one "name = value" entry per
+// variable, the names of shadowed variables are enclosed in parentheses.
+func (vs Variables) String() string {
+	if len(vs) == 0 {
+		return "(no variables)"
+	}
+	var buf strings.Builder
+	for _, v := range vs {
+		name := v.Name
+		if v.Flags&VariableShadowed != 0 {
+			name = "(" + name + ")"
+		}
+		fmt.Fprintf(&buf, "%s = %s\n", name, v.MultilineString("", ""))
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
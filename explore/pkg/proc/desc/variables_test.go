@@ -0,0 +1,46 @@
+package desc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInterfacesInCollection(t *testing.T) {
+	iface := func(addr uint64, data Variable) Variable {
+		return Variable{Addr: addr, Type: "interface {}", Kind: reflect.Interface, Children: []Variable{data}}
+	}
+	v := &Variable{
+		Name: "anys", Type: "[]interface {}", Kind: reflect.Slice, Len: 3, Cap: 3,
+		Children: []Variable{
+			iface(0xc000010000, Variable{Type: "int", Kind: reflect.Int, Value: "1", Addr: 0x1000}),
+			iface(0xc000010010, Variable{Type: "void", Kind: reflect.Invalid}),
+			iface(0xc000010020, Variable{Type: "string", Kind: reflect.String, Value: "x", Len: 1, Addr: 0x2000}),
+		},
+	}
+	want := `[]interface {} len: 3, cap: 3, [int(1),nil,string("x")]`
+	if got := v.SinglelineString(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	elems := func(n int) []Variable { return make([]Variable, n) }
+	for _, test := range []struct {
+		v    *Variable
+		want string
+	}{
+		{&Variable{Kind: reflect.Slice, Len: 100, Children: elems(10)}, "--offset 10"},
+		{&Variable{Kind: reflect.Slice, Len: 100, Offset: 90, Children: elems(10)}, ""},
+		{&Variable{Kind: reflect.Array, Len: 100, Offset: 50, Children: elems(10)}, "--offset 60"},
+		{&Variable{Kind: reflect.Slice, Len: 100, Offset: 100}, ""},
+		{&Variable{Kind: reflect.String, Len: 36, Offset: 10, Value: "abcdef"}, "--offset 16"},
+		{&Variable{Kind: reflect.String, Len: 16, Offset: 10, Value: "abcdef"}, ""},
+		{&Variable{Kind: reflect.Map, Len: 100, Children: elems(20), Cursor: "10:0:3:2:0"}, "--cursor 10:0:3:2:0"},
+		{&Variable{Kind: reflect.Map, Len: 100, Children: elems(20)}, ""},
+		{&Variable{Kind: reflect.Struct, Len: 2, Children: elems(1)}, ""},
+	} {
+		if got := test.v.NextPage(); got != test.want {
+			t.Errorf("NextPage of %v at %d, %d children of %d = %q, want %q", test.v.Kind, test.v.Offset, len(test.v.Children), test.v.Len, got, test.want)
+		}
+	}
+}
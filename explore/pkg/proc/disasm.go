@@ -1,5 +1,11 @@
 package proc
 
+import (
+	"fmt"
+
+	"explore/pkg/dwarf/op"
+)
+
 // Location represents the location of a thread.
 // Holds information on the current instruction
 // address, the source file:line, and the function.
@@ -119,64 +125,57 @@ func checkPrologue(s []AsmInstruction, prologuePattern opcodeSeq) bool {
 	return true
 }
 
-// Disassemble disassembles target memory between startAddr and endAddr, marking
-// the current instruction being executed in goroutine g.
-// If currentGoroutine is set and thread is stopped at a CALL instruction Disassemble
-// will evaluate the argument of the CALL instruction using the thread's registers.
+// Disassemble disassembles target memory between startAddr and endAddr.
+// If regs is set and the current instruction is a CALL Disassemble will
+// evaluate the argument of the CALL instruction using regs.
 // Be aware that the Bytes field of each returned instruction is a slice of a larger array of size startAddr - endAddr.
-//func Disassemble(mem MemoryReadWriter, regs Registers, breakpoints *BreakpointMap, bi *BinaryInfo, startAddr, endAddr uint64) ([]AsmInstruction, error) {
-//	if startAddr > endAddr {
-//		return nil, fmt.Errorf("start address(%x) should be less than end address(%x)", startAddr, endAddr)
-//	}
-//	return disassemble(mem, regs, breakpoints, bi, startAddr, endAddr, false)
-//}
-//
-//func disassemble(memrw MemoryReadWriter, regs Registers, breakpoints *BreakpointMap, bi *BinaryInfo, startAddr, endAddr uint64, singleInstr bool) ([]AsmInstruction, error) {
-//	var dregs *op.DwarfRegisters
-//	if regs != nil {
-//		dregs = bi.Arch.RegistersToDwarfRegisters(0, regs)
-//	}
-//
-//	mem := make([]byte, int(endAddr-startAddr))
-//	_, err := memrw.ReadMemory(mem, startAddr)
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	r := make([]AsmInstruction, 0, len(mem)/bi.Arch.MaxInstructionLength())
-//	pc := startAddr
-//
-//	var curpc uint64
-//	if regs != nil {
-//		curpc = regs.PC()
-//	}
-//
-//	for len(mem) > 0 {
-//		bp, atbp := breakpoints.M[pc]
-//		if atbp {
-//			copy(mem, bp.OriginalData)
-//		}
-//
-//		file, line, fn := bi.PCToLine(pc)
-//
-//		var inst AsmInstruction
-//		inst.Loc = Location{PC: pc, File: file, Line: line, Fn: fn}
-//		inst.Breakpoint = atbp
-//		inst.AtPC = (regs != nil) && (curpc == pc)
-//
-//		bi.Arch.asmDecode(&inst, mem, dregs, memrw, bi)
-//
-//		r = append(r, inst)
-//
-//		pc += uint64(inst.Size)
-//		mem = mem[inst.Size:]
-//
-//		if singleInstr {
-//			break
-//		}
-//	}
-//	return r, nil
-//}
+func Disassemble(mem MemoryReadWriter, regs Registers, bi *BinaryInfo, startAddr, endAddr uint64) ([]AsmInstruction, error) {
+	if startAddr > endAddr {
+		return nil, fmt.Errorf("start address(%x) should be less than end address(%x)", startAddr, endAddr)
+	}
+	return disassemble(mem, regs, bi, startAddr, endAddr, false)
+}
+
+func disassemble(memrw MemoryReadWriter, regs Registers, bi *BinaryInfo, startAddr, endAddr uint64, singleInstr bool) ([]AsmInstruction, error) {
+	var dregs *op.DwarfRegisters
+	if regs != nil {
+		dregs = bi.Arch.RegistersToDwarfRegisters(0, regs)
+	}
+
+	mem := make([]byte, int(endAddr-startAddr))
+	_, err := memrw.ReadMemory(mem, startAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]AsmInstruction, 0, len(mem)/bi.Arch.MaxInstructionLength())
+	pc := startAddr
+
+	var curpc uint64
+	if regs != nil {
+		curpc = regs.PC()
+	}
+
+	for len(mem) > 0 {
+		file, line, fn := bi.PCToLine(pc)
+
+		var inst AsmInstruction
+		inst.Loc = Location{PC: pc, File: file, Line: line, Fn: fn}
+		inst.AtPC = (regs != nil) && (curpc == pc)
+
+		bi.Arch.asmDecode(&inst, mem, dregs, memrw, bi)
+
+		r = append(r, inst)
+
+		pc += uint64(inst.Size)
+		mem = mem[inst.Size:]
+
+		if singleInstr {
+			break
+		}
+	}
+	return r, nil
+}
 
 // Text will return the assembly instructions in human readable format according to
 // the flavour specified.
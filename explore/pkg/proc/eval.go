@@ -0,0 +1,1834 @@
+package proc
+
+import (
+	"debug/dwarf"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"runtime/debug"
+	"sort"
+
+	"explore/pkg/astutil"
+	"explore/pkg/dwarf/godwarf"
+	"explore/pkg/dwarf/op"
+	"explore/pkg/dwarf/reader"
+	"explore/pkg/goversion"
+	"explore/pkg/proc/evalop"
+)
+
+var errOperationOnSpecialFloat = errors.New("operations on non-finite floats not implemented")
+
+// errNoFrame is returned when an expression needs information that is
+// only available from a stack frame (locals, current goroutine, thread)
+// but the scope was created without one.
+var errNoFrame = errors.New("no frame selected, only package variables can be evaluated")
+
+// errFuncCallNotSupported is returned when an expression requires a
+// function call to be injected into the target process.
+var errFuncCallNotSupported = errors.New("function calls are not supported")
+
+// EvalScope is the scope for variable evaluation. Contains the current
+// location (PC), the registers used to resolve DWARF location expressions
+// and the memory of the target.
+type EvalScope struct {
+	Location
+	Regs    op.DwarfRegisters
+	Mem     MemoryReadWriter // Target's memory
+	BinInfo *BinaryInfo
+
+	// Alloc allocates size bytes of memory in the target, it is used to
+	// store the contents of string constants assigned to variables. If it
+	// is nil such assignments are refused.
+	Alloc func(size, align uint64) (uint64, error)
+
+	loadCfg *LoadConfig
+}
+
+// GlobalScope returns a scope that can be used to evaluate expressions
+// over the package variables of the target, without any frame or
+// goroutine information.
+func GlobalScope(bi *BinaryInfo, mem MemoryReadWriter) *EvalScope {
+	scope := &EvalScope{Mem: mem, BinInfo: bi}
+	if len(bi.Images) > 0 {
+		scope.Regs.StaticBase = bi.Images[0].StaticBase
+	}
+	return scope
+}
+
+// FrameToScope returns a scope for the first of frames, the registers of
+// the frame are used to resolve the location of its local variables.
+func FrameToScope(bi *BinaryInfo, mem MemoryReadWriter, frames ...Stackframe) *EvalScope {
+	scope := &EvalScope{Location: frames[0].Call, Regs: frames[0].Regs, Mem: mem, BinInfo: bi}
+	scope.PC = frames[0].lastpc
+	return scope
+}
+
+// Locals returns all local variables and arguments visible at the PC of
+// the scope, if wantedName is not empty only the variables with that name
+// are returned. Escaped variables are dereferenced and variables shadowed
+// by a later declaration with the same name are flagged VariableShadowed.
+func (scope *EvalScope) Locals(wantedName string) ([]*Variable, error) {
+	if scope.Fn == nil {
+		return nil, errNoFrame
+	}
+	image := scope.Fn.cu.image
+	if image.Stripped() {
+		return nil, errors.New("unable to find locals: no debug information present in binary")
+	}
+
+	dwarfTree, err := image.getDwarfTree(scope.Fn.offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// inlined calls are frames of their own
+	flags := reader.VariablesOnlyVisible | reader.VariablesSkipInlinedSubroutines
+	if goversion.ProducerAfterOrEqual(scope.BinInfo.Producer(), 1, 15) {
+		flags |= reader.VariablesTrustDeclLine
+	}
+	varEntries := reader.Variables(dwarfTree, scope.PC, scope.Line, flags)
+
+	var dictAddr uint64
+	for _, entry := range varEntries {
+		if name, _ := entry.Val(dwarf.AttrName).(string); name == goDictionaryName {
+			if dict, err := extractVarInfoFromEntry(scope.BinInfo, image, scope.Regs, scope.Mem, entry.Tree, 0); err == nil {
+				dict.loadValue(loadSingleValue)
+				if dict.Unreadable == nil && dict.Value != nil {
+					dictAddr, _ = constant.Uint64Val(dict.Value)
+				}
+			}
+			break
+		}
+	}
+
+	vars := make([]*Variable, 0, len(varEntries))
+	depths := make([]int, 0, len(varEntries))
+	for _, entry := range varEntries {
+		name, _ := entry.Val(dwarf.AttrName).(string)
+		if wantedName != "" {
+			if name != wantedName && name != "&"+wantedName {
+				continue
+			}
+		} else if name == goDictionaryName || name == goClosurePtr {
+			continue
+		}
+		val, err := extractVarInfoFromEntry(scope.BinInfo, image, scope.Regs, scope.Mem, entry.Tree, dictAddr)
+		if err != nil {
+			// skip variables that we can't parse yet
+			continue
+		}
+		vars = append(vars, val)
+		depths = append(depths, entry.Depth)
+	}
+
+	if len(vars) == 0 {
+		return vars, nil
+	}
+
+	sort.Stable(&variablesByDepthAndDeclLine{vars, depths})
+
+	lvn := map[string]*Variable{} // lvn[n] is the last variable we saw named n
+
+	for i, v := range vars {
+		if name := v.Name; len(name) > 1 && name[0] == '&' {
+			locationExpr := v.LocationExpr
+			declLine := v.DeclLine
+			flags := v.Flags
+			v = v.maybeDereference()
+			if v.Addr == 0 && v.Unreadable == nil {
+				v.Unreadable = errors.New("no address for escaped variable")
+			}
+			v.Name = name[1:]
+			v.Flags |= VariableEscaped | flags&(VariableArgument|VariableReturnArgument)
+			if locationExpr != nil {
+				locationExpr.isEscaped = true
+				v.LocationExpr = locationExpr
+			}
+			v.DeclLine = declLine
+			vars[i] = v
+		}
+		if otherv := lvn[v.Name]; otherv != nil {
+			otherv.Flags |= VariableShadowed
+		}
+		lvn[v.Name] = v
+	}
+	return vars, nil
+}
+
+// LocalVariables returns all local variables of the scope, arguments
+// excluded.
+func (scope *EvalScope) LocalVariables(cfg LoadConfig) ([]*Variable, error) {
+	vars, err := scope.Locals("")
+	if err != nil {
+		return nil, err
+	}
+	vars = filterVariables(vars, func(v *Variable) bool {
+		return (v.Flags & (VariableArgument | VariableReturnArgument)) == 0
+	})
+	cfg.MaxMapBuckets = maxMapBucketsFactor * cfg.MaxArrayValues
+	loadValues(vars, cfg)
+	return vars, nil
+}
+
+// FunctionArguments returns the arguments, including the named return
+// values, of the function of the scope.
+func (scope *EvalScope) FunctionArguments(cfg LoadConfig) ([]*Variable, error) {
+	vars, err := scope.Locals("")
+	if err != nil {
+		return nil, err
+	}
+	vars = filterVariables(vars, func(v *Variable) bool {
+		return (v.Flags & (VariableArgument | VariableReturnArgument)) != 0
+	})
+	cfg.MaxMapBuckets = maxMapBucketsFactor * cfg.MaxArrayValues
+	loadValues(vars, cfg)
+	return vars, nil
+}
+
+func filterVariables(vars []*Variable, pred func(v *Variable) bool) []*Variable {
+	r := make([]*Variable, 0, len(vars))
+	for i := range vars {
+		if pred(vars[i]) {
+			r = append(r, vars[i])
+		}
+	}
+	return r
+}
+
+// EvalExpression returns the value of the given expression.
+func (scope *EvalScope) EvalExpression(expr string, cfg LoadConfig) (*Variable, error) {
+	ops, err := evalop.Compile(scopeToEvalLookup{scope}, expr, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	stack := &evalStack{}
+
+	scope.loadCfg = &cfg
+	stack.eval(scope, ops)
+	ev, err := stack.result(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	if ev == nil {
+		return nil, fmt.Errorf("expression %q did not produce a value", expr)
+	}
+
+	ev.Name = expr
+	return ev, nil
+}
+
+// SetVariable sets the value of the named variable, both name and value
+// are Go expressions. Assigning to an index expression of a map inserts the
+// key if it isn't already in the map.
+func (scope *EvalScope) SetVariable(name, value string) error {
+	if t, err := parser.ParseExpr(name); err == nil {
+		if idx, ok := ast.Unparen(t).(*ast.IndexExpr); ok {
+			m, err := scope.EvalExpression(astutil.ExprToString(idx.X), loadSingleValue)
+			if err == nil && m.Kind == reflect.Map {
+				return scope.setMapIndex(m, idx.Index, value)
+			}
+		}
+	}
+
+	ops, err := evalop.CompileSet(scopeToEvalLookup{scope}, name, value, 0)
+	if err != nil {
+		return err
+	}
+
+	stack := &evalStack{}
+	stack.eval(scope, ops)
+	_, err = stack.result(nil)
+	return err
+}
+
+// DeleteMapKey removes a key from a map, like the delete builtin, both m
+// and key are Go expressions.
+func (scope *EvalScope) DeleteMapKey(m, key string) error {
+	mv, err := scope.EvalExpression(m, loadSingleValue)
+	if err != nil {
+		return err
+	}
+	kv, err := scope.EvalExpression(key, loadFullValue)
+	if err != nil {
+		return err
+	}
+	return scope.MapDelete(mv, kv)
+}
+
+func (scope *EvalScope) setMapIndex(m *Variable, key ast.Expr, value string) error {
+	kv, err := scope.EvalExpression(astutil.ExprToString(key), loadFullValue)
+	if err != nil {
+		return err
+	}
+	val, err := scope.EvalExpression(value, loadFullValue)
+	if err != nil {
+		return err
+	}
+	_, err = scope.MapAssign(m, kv, val)
+	return err
+}
+
+type scopeToEvalLookup struct {
+	*EvalScope
+}
+
+func (s scopeToEvalLookup) FindTypeExpr(expr ast.Expr) (godwarf.Type, error) {
+	return s.BinInfo.findTypeExpr(expr)
+}
+
+func (s scopeToEvalLookup) HasBuiltin(name string) bool {
+	return supportedBuiltins[name] != nil
+}
+
+func (s scopeToEvalLookup) PtrSize() int {
+	return s.BinInfo.Arch.PtrSize()
+}
+
+// evalStack stores the stack machine used to evaluate a program made of
+// evalop.Ops.
+type evalStack struct {
+	stack []*Variable // current stack of Variable values
+	ops   []evalop.Op // program being executed
+	opidx int         // program counter for the stack program
+	scope *EvalScope
+	err   error // error produced by the last executed instruction
+}
+
+func (s *evalStack) push(v *Variable) {
+	s.stack = append(s.stack, v)
+}
+
+func (s *evalStack) pop() *Variable {
+	v := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	return v
+}
+
+func (s *evalStack) peek() *Variable {
+	return s.stack[len(s.stack)-1]
+}
+
+func (s *evalStack) pushErr(v *Variable, err error) {
+	s.err = err
+	s.stack = append(s.stack, v)
+}
+
+func (s *evalStack) eval(scope *EvalScope, ops []evalop.Op) {
+	s.ops = ops
+	s.scope = scope
+
+	for s.opidx < len(s.ops) && s.err == nil {
+		s.executeOp()
+	}
+}
+
+func (s *evalStack) result(cfg *LoadConfig) (*Variable, error) {
+	var r *Variable
+	switch len(s.stack) {
+	case 0:
+		// ok
+	case 1:
+		r = s.peek()
+	default:
+		if s.err == nil {
+			s.err = fmt.Errorf("internal debugger error: wrong stack size at end %d", len(s.stack))
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if r != nil && cfg != nil {
+		r.loadValue(*cfg)
+	}
+	return r, nil
+}
+
+// executeOp executes the opcode at s.ops[s.opidx] and increments s.opidx.
+func (s *evalStack) executeOp() {
+	scope := s.scope
+	defer func() {
+		if ierr := recover(); ierr != nil {
+			s.err = fmt.Errorf("internal debugger error: %v (recovered)\n%s", ierr, string(debug.Stack()))
+		}
+	}()
+
+	switch op := s.ops[s.opidx].(type) {
+	case *evalop.PushCurg:
+		typ, err := scope.BinInfo.findType("runtime.g")
+		if err != nil {
+			s.err = fmt.Errorf("could not find runtime.g: %v", err)
+			return
+		}
+		gvar := newVariable("curg", fakeAddressUnresolv, typ, scope.BinInfo, scope.Mem)
+		gvar.loaded = true
+		gvar.Flags = VariableFakeAddress
+		gvar.Children = append(gvar.Children, *newConstant(constant.MakeInt64(0), scope.Mem))
+		gvar.Children[0].Name = "goid"
+		s.push(gvar)
+
+	case *evalop.PushFrameoff:
+		s.err = errNoFrame
+
+	case *evalop.PushThreadID:
+		s.err = errNoFrame
+
+	case *evalop.PushRangeParentOffset:
+		s.err = errNoFrame
+
+	case *evalop.PushConst:
+		s.push(newConstant(op.Value, scope.Mem))
+
+	case *evalop.PushLocal:
+		s.err = fmt.Errorf("could not find symbol value for %s: %v", op.Name, errNoFrame)
+
+	case *evalop.PushIdent:
+		scope.evalIdent(op, s)
+
+	case *evalop.PushPackageVarOrSelect:
+		scope.evalPackageVarOrSelect(op, s)
+
+	case *evalop.PushNil:
+		s.push(nilVariable)
+
+	case *evalop.PushLen:
+		v := s.peek()
+		s.push(newConstant(constant.MakeInt64(v.Len), scope.Mem))
+
+	case *evalop.Select:
+		scope.evalStructSelector(op, s)
+
+	case *evalop.TypeAssert:
+		scope.evalTypeAssert(op, s)
+
+	case *evalop.PointerDeref:
+		scope.evalPointerDeref(op, s)
+
+	case *evalop.Unary:
+		scope.evalUnary(op, s)
+
+	case *evalop.AddrOf:
+		scope.evalAddrOf(op, s)
+
+	case *evalop.TypeCast:
+		scope.evalTypeCast(op, s)
+
+	case *evalop.Reslice:
+		scope.evalReslice(op, s)
+
+	case *evalop.Index:
+		scope.evalIndex(op, s)
+
+	case *evalop.Jump:
+		scope.evalJump(op, s)
+
+	case *evalop.Binary:
+		scope.evalBinary(op, s)
+
+	case *evalop.BoolToConst:
+		x := s.pop()
+		if x.Kind != reflect.Bool {
+			s.err = errors.New("internal debugger error: expected boolean")
+			return
+		}
+		x.loadValue(loadFullValue)
+		if x.Unreadable != nil {
+			s.err = x.Unreadable
+			return
+		}
+		s.push(newConstant(x.Value, scope.Mem))
+
+	case *evalop.Pop:
+		s.pop()
+
+	case *evalop.Roll:
+		rolled := s.stack[len(s.stack)-op.N-1]
+		copy(s.stack[len(s.stack)-op.N-1:], s.stack[len(s.stack)-op.N:])
+		s.stack[len(s.stack)-1] = rolled
+
+	case *evalop.Dup:
+		s.push(s.peek())
+
+	case *evalop.BuiltinCall:
+		vars := make([]*Variable, len(op.Args))
+		for i := len(op.Args) - 1; i >= 0; i-- {
+			vars[i] = s.pop()
+		}
+		s.pushErr(supportedBuiltins[op.Name](vars, op.Args))
+
+	case *evalop.CallInjectionStart:
+		s.err = errFuncCallNotSupported
+
+	case *evalop.CallInjectionSetTarget:
+		s.err = errFuncCallNotSupported
+
+	case *evalop.CallInjectionCopyArg:
+		s.err = errFuncCallNotSupported
+
+	case *evalop.CallInjectionComplete:
+		s.err = errFuncCallNotSupported
+
+	case *evalop.CallInjectionComplete2:
+		s.err = errFuncCallNotSupported
+
+	case *evalop.CallInjectionStartSpecial:
+		if op.ComplainAboutStringAlloc {
+			s.err = errFuncCallNotAllowedStrAlloc
+			return
+		}
+		s.err = errFuncCallNotSupported
+
+	case *evalop.ConvertAllocToString:
+		s.err = errFuncCallNotSupported
+
+	case *evalop.SetValue:
+		lhv := s.pop()
+		rhv := s.pop()
+		s.err = scope.setValue(lhv, rhv, astutil.ExprToString(op.Rhe))
+
+	case *evalop.SetDebugPinner:
+		s.err = errFuncCallNotSupported
+
+	case *evalop.PushDebugPinner:
+		s.err = errFuncCallNotSupported
+
+	case *evalop.PushPinAddress:
+		s.err = errFuncCallNotSupported
+
+	case *evalop.PushBreakpointHitCount:
+		s.err = fmt.Errorf("%s is only available inside breakpoint conditions", evalop.BreakpointHitCountVarNameQualified)
+
+	case *evalop.PushRuntimeType:
+		typeAddr, _, found, err := dwarfToRuntimeType(scope.BinInfo, scope.Mem, op.Type)
+		if err != nil {
+			s.err = err
+			return
+		}
+		if !found {
+			s.err = fmt.Errorf("could not find runtime type for %s", op.Type.String())
+			return
+		}
+		s.push(newConstant(constant.MakeUint64(typeAddr), scope.Mem))
+
+	case *evalop.PushNewFakeVariable:
+		s.err = fmt.Errorf("can not create a new variable of type %s: %v", op.Type.String(), errFuncCallNotSupported)
+
+	default:
+		s.err = fmt.Errorf("internal debugger error: unknown eval opcode: %#v", op)
+	}
+
+	s.opidx++
+}
+
+// findGlobal looks up the package variable, function or constant
+// pkgName.varName.
+func (scope *EvalScope) findGlobal(pkgName, varName string) (*Variable, error) {
+	return findGlobal(scope.BinInfo, scope.Mem, pkgName, varName)
+}
+
+// defaultPackage is the package used to resolve unqualified identifiers:
+// the package of the current function if there is one, main otherwise.
+func (scope *EvalScope) defaultPackage() string {
+	if scope.Fn != nil {
+		return scope.Fn.PackageName()
+	}
+	return "main"
+}
+
+func isSymbolNotFound(err error) bool {
+	var notFound *errCouldNotFindSymbol
+	return errors.As(err, &notFound)
+}
+
+func (scope *EvalScope) evalIdent(op *evalop.PushIdent, s *evalStack) {
+	switch op.Name {
+	case "true", "false":
+		s.push(newConstant(constant.MakeBool(op.Name == "true"), scope.Mem))
+		return
+	case "nil":
+		s.push(nilVariable)
+		return
+	}
+
+	if scope.Fn != nil {
+		vars, err := scope.Locals(op.Name)
+		if err == nil && len(vars) > 0 {
+			// the innermost declaration is the visible one
+			v := vars[len(vars)-1]
+			v.Name = op.Name
+			s.push(v)
+			return
+		}
+	}
+
+	v, err := scope.findGlobal(scope.defaultPackage(), op.Name)
+	if err != nil && !isSymbolNotFound(err) {
+		s.err = err
+		return
+	}
+	if v != nil {
+		v.Name = op.Name
+		s.push(v)
+		return
+	}
+
+	s.err = fmt.Errorf("could not find symbol value for %s", op.Name)
+}
+
+func (scope *EvalScope) evalPackageVarOrSelect(op *evalop.PushPackageVarOrSelect, s *evalStack) {
+	v, err := scope.findGlobal(op.Name, op.Sel)
+	if err != nil && !isSymbolNotFound(err) {
+		s.err = err
+		return
+	}
+	if v != nil {
+		s.push(v)
+		return
+	}
+
+	if op.NameIsString {
+		s.err = fmt.Errorf("%q (type string) is not a struct", op.Name)
+		return
+	}
+
+	if len(scope.BinInfo.PackageMap[op.Name]) > 0 {
+		// Name is a package, Sel does not exist in it.
+		s.err = err
+		return
+	}
+
+	scope.evalIdent(&evalop.PushIdent{Name: op.Name}, s)
+	if s.err != nil {
+		return
+	}
+	s.pushErr(s.pop().structMember(op.Sel))
+}
+
+func (scope *EvalScope) evalStructSelector(op *evalop.Select, s *evalStack) {
+	xv := s.pop()
+	// Prevent abuse, attempting to call "nil.member" directly.
+	if xv.Addr == 0 && xv.Name == "nil" {
+		s.err = fmt.Errorf("%s (type %s) is not a struct", xv.Name, xv.TypeString())
+		return
+	}
+	// Prevent abuse, attempting to call "\"fake\".member" directly.
+	if xv.Addr == 0 && xv.Name == "" && xv.DwarfType == nil && xv.RealType == nil {
+		s.err = fmt.Errorf("%s (type %s) is not a struct", xv.Value, xv.TypeString())
+		return
+	}
+	// Special type conversions for CPU register variables (REGNAME.int8, etc)
+	if xv.Flags&VariableCPURegister != 0 && !xv.loaded {
+		s.pushErr(xv.registerVariableTypeConv(op.Name))
+		return
+	}
+
+	s.pushErr(xv.structMember(op.Name))
+}
+
+func (scope *EvalScope) evalTypeAssert(op *evalop.TypeAssert, s *evalStack) {
+	xv := s.pop()
+	if xv.Kind != reflect.Interface {
+		s.err = fmt.Errorf("expression %q not an interface", astutil.ExprToString(op.Node.X))
+		return
+	}
+	xv.loadInterface(0, false, loadFullValue)
+	if xv.Unreadable != nil {
+		s.err = xv.Unreadable
+		return
+	}
+	if xv.Children[0].Unreadable != nil {
+		s.err = xv.Children[0].Unreadable
+		return
+	}
+	if xv.Children[0].Addr == 0 {
+		s.err = fmt.Errorf("interface conversion: %s is nil, not %s", xv.DwarfType.String(), astutil.ExprToString(op.Node.Type))
+		return
+	}
+	typ := op.DwarfType
+	if typ != nil && xv.Children[0].DwarfType.Common().Name != typ.Common().Name {
+		s.err = fmt.Errorf("interface conversion: %s is %s, not %s", xv.DwarfType.Common().Name, xv.Children[0].TypeString(), typ.Common().Name)
+		return
+	}
+	// loadInterface will set OnlyAddr for the data member since here we are
+	// passing false to loadData, however returning the variable with OnlyAddr
+	// set here would be wrong since, once the expression evaluation
+	// terminates, the value of this variable will be loaded.
+	xv.Children[0].OnlyAddr = false
+	s.push(&xv.Children[0])
+}
+
+func (scope *EvalScope) evalPointerDeref(op *evalop.PointerDeref, s *evalStack) {
+	xv := s.pop()
+
+	if xv.Kind != reflect.Ptr {
+		s.err = fmt.Errorf("expression %q (%s) can not be dereferenced", astutil.ExprToString(op.Node.X), xv.TypeString())
+		return
+	}
+
+	if xv == nilVariable {
+		s.err = errors.New("nil can not be dereferenced")
+		return
+	}
+
+	if len(xv.Children) == 1 {
+		// this branch is here to support pointers constructed with typecasts from ints
+		xv.Children[0].OnlyAddr = false
+		s.push(&(xv.Children[0]))
+		return
+	}
+	xv.loadPtr()
+	if xv.Unreadable != nil {
+		s.err = xv.Unreadable
+		return
+	}
+	rv := &xv.Children[0]
+	if rv.Addr == 0 {
+		s.err = errors.New("nil pointer dereference")
+		return
+	}
+	s.push(rv)
+}
+
+func (scope *EvalScope) evalAddrOf(op *evalop.AddrOf, s *evalStack) {
+	xev := s.pop()
+	if xev.Addr == 0 || xev.DwarfType == nil {
+		s.err = fmt.Errorf("can not take address of %q", astutil.ExprToString(op.Node.X))
+		return
+	}
+
+	s.push(xev.pointerToVariable())
+}
+
+func (v *Variable) pointerToVariable() *Variable {
+	v.OnlyAddr = true
+
+	typename := "*" + v.DwarfType.Common().Name
+	rv := v.newVariable("", 0, &godwarf.PtrType{CommonType: godwarf.CommonType{ByteSize: int64(v.bi.Arch.PtrSize()), Name: typename}, Type: v.DwarfType}, v.mem)
+	rv.Children = []Variable{*v}
+	rv.loaded = true
+
+	return rv
+}
+
+func (scope *EvalScope) evalUnary(op *evalop.Unary, s *evalStack) {
+	xv := s.pop()
+
+	xv.loadValue(loadSingleValue)
+	if xv.Unreadable != nil {
+		s.err = xv.Unreadable
+		return
+	}
+	if xv.FloatSpecial != 0 {
+		s.err = errOperationOnSpecialFloat
+		return
+	}
+	if xv.Value == nil {
+		s.err = fmt.Errorf("operator %s can not be applied to %q", op.Node.Op.String(), astutil.ExprToString(op.Node.X))
+		return
+	}
+	rc, err := constantUnaryOp(op.Node.Op, xv.Value)
+	if err != nil {
+		s.err = err
+		return
+	}
+	if xv.DwarfType != nil {
+		r := xv.newVariable("", 0, xv.DwarfType, scope.Mem)
+		r.Value = rc
+		r.loaded = true
+		s.push(r)
+		return
+	}
+	s.push(newConstant(rc, xv.mem))
+}
+
+func (scope *EvalScope) evalTypeCast(op *evalop.TypeCast, s *evalStack) {
+	argv := s.pop()
+
+	typ := godwarf.ResolveTypedef(op.DwarfType)
+
+	converr := fmt.Errorf("can not convert %q to %s", astutil.ExprToString(op.Node.Args[0]), typ.String())
+
+	// compatible underlying types
+	if typeCastCompatibleTypes(argv.RealType, typ) {
+		if ptyp, isptr := typ.(*godwarf.PtrType); argv.Kind == reflect.Ptr && argv.loaded && len(argv.Children) > 0 && isptr {
+			cv := argv.Children[0]
+			argv.Children[0] = *newVariable(cv.Name, cv.Addr, ptyp.Type, cv.bi, cv.mem)
+			argv.Children[0].OnlyAddr = true
+		}
+		argv.RealType = typ
+		argv.DwarfType = op.DwarfType
+		s.push(argv)
+		return
+	}
+
+	v := newVariable("", 0, op.DwarfType, scope.BinInfo, scope.Mem)
+	v.loaded = true
+
+	switch ttyp := typ.(type) {
+	case *godwarf.PtrType:
+		switch argv.Kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			// ok
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			// ok
+		default:
+			s.err = converr
+			return
+		}
+
+		argv.loadValue(loadSingleValue)
+		if argv.Unreadable != nil {
+			s.err = argv.Unreadable
+			return
+		}
+
+		n, _ := constant.Uint64Val(argv.Value)
+
+		v.Children = []Variable{*(newVariable("", n, ttyp.Type, scope.BinInfo, scope.Mem))}
+		v.Children[0].OnlyAddr = true
+		s.push(v)
+		return
+
+	case *godwarf.UintType:
+		argv.loadValue(loadSingleValue)
+		if argv.Unreadable != nil {
+			s.err = argv.Unreadable
+			return
+		}
+		switch argv.Kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, _ := constant.Int64Val(argv.Value)
+			v.Value = constant.MakeUint64(convertInt(uint64(n), false, ttyp.Size()))
+			s.push(v)
+			return
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n, _ := constant.Uint64Val(argv.Value)
+			v.Value = constant.MakeUint64(convertInt(n, false, ttyp.Size()))
+			s.push(v)
+			return
+		case reflect.Float32, reflect.Float64:
+			x, _ := constant.Float64Val(argv.Value)
+			v.Value = constant.MakeUint64(uint64(x))
+			s.push(v)
+			return
+		case reflect.Ptr:
+			v.Value = constant.MakeUint64(argv.Children[0].Addr)
+			s.push(v)
+			return
+		}
+
+	case *godwarf.IntType:
+		argv.loadValue(loadSingleValue)
+		if argv.Unreadable != nil {
+			s.err = argv.Unreadable
+			return
+		}
+		switch argv.Kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, _ := constant.Int64Val(argv.Value)
+			v.Value = constant.MakeInt64(int64(convertInt(uint64(n), true, ttyp.Size())))
+			s.push(v)
+			return
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n, _ := constant.Uint64Val(argv.Value)
+			v.Value = constant.MakeInt64(int64(convertInt(n, true, ttyp.Size())))
+			s.push(v)
+			return
+		case reflect.Float32, reflect.Float64:
+			x, _ := constant.Float64Val(argv.Value)
+			v.Value = constant.MakeInt64(int64(x))
+			s.push(v)
+			return
+		}
+
+	case *godwarf.FloatType:
+		argv.loadValue(loadSingleValue)
+		if argv.Unreadable != nil {
+			s.err = argv.Unreadable
+			return
+		}
+		switch argv.Kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64:
+			v.Value = constant.ToFloat(argv.Value)
+			s.push(v)
+			return
+		}
+
+	case *godwarf.ComplexType:
+		argv.loadValue(loadSingleValue)
+		if argv.Unreadable != nil {
+			s.err = argv.Unreadable
+			return
+		}
+		switch argv.Kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+			v.Value = constant.ToComplex(argv.Value)
+			s.push(v)
+			return
+		}
+	}
+
+	cfg := loadFullValue
+	if scope.loadCfg != nil {
+		cfg = *scope.loadCfg
+	}
+
+	switch ttyp := typ.(type) {
+	case *godwarf.SliceType:
+		switch ttyp.ElemType.Common().ReflectKind {
+		case reflect.Uint8:
+			// string -> []uint8
+			if argv.Kind != reflect.String {
+				s.err = converr
+				return
+			}
+			cfg.MaxStringLen = cfg.MaxArrayValues
+			argv.loadValue(cfg)
+			if argv.Unreadable != nil {
+				s.err = argv.Unreadable
+				return
+			}
+			for i, ch := range []byte(constant.StringVal(argv.Value)) {
+				e := newVariable("", argv.Base+uint64(i), ttyp.ElemType, scope.BinInfo, argv.mem)
+				e.loaded = true
+				e.Value = constant.MakeInt64(int64(ch))
+				v.Children = append(v.Children, *e)
+			}
+			v.Len = argv.Len
+			v.Cap = v.Len
+			s.push(v)
+			return
+
+		case reflect.Int32:
+			// string -> []rune
+			if argv.Kind != reflect.String {
+				s.err = converr
+				return
+			}
+			argv.loadValue(cfg)
+			if argv.Unreadable != nil {
+				s.err = argv.Unreadable
+				return
+			}
+			for i, ch := range constant.StringVal(argv.Value) {
+				e := newVariable("", argv.Base+uint64(i), ttyp.ElemType, scope.BinInfo, argv.mem)
+				e.loaded = true
+				e.Value = constant.MakeInt64(int64(ch))
+				v.Children = append(v.Children, *e)
+			}
+			v.Len = int64(len(v.Children))
+			v.Cap = v.Len
+			s.push(v)
+			return
+		}
+
+	case *godwarf.StringType:
+		switch argv.Kind {
+		case reflect.String:
+			// string -> string
+			argv.DwarfType = v.DwarfType
+			argv.RealType = v.RealType
+			s.push(argv)
+			return
+
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			// integer -> string
+			argv.loadValue(cfg)
+			if argv.Unreadable != nil {
+				s.err = argv.Unreadable
+				return
+			}
+			b, _ := constant.Int64Val(argv.Value)
+			str := string(rune(b))
+			v.Value = constant.MakeString(str)
+			v.Len = int64(len(str))
+			s.push(v)
+			return
+
+		case reflect.Slice, reflect.Array:
+			var elem godwarf.Type
+			if argv.Kind == reflect.Slice {
+				elem = argv.RealType.(*godwarf.SliceType).ElemType
+			} else {
+				elem = argv.RealType.(*godwarf.ArrayType).Type
+			}
+			switch elemType := godwarf.ResolveTypedef(elem).(type) {
+			case *godwarf.UintType:
+				// []uint8 -> string
+				if elemType.Name != "uint8" && elemType.Name != "byte" {
+					s.err = converr
+					return
+				}
+				cfg.MaxArrayValues = cfg.MaxStringLen
+				argv.loadValue(cfg)
+				if argv.Unreadable != nil {
+					s.err = argv.Unreadable
+					return
+				}
+				bytes := make([]byte, len(argv.Children))
+				for i := range argv.Children {
+					n, _ := constant.Int64Val(argv.Children[i].Value)
+					bytes[i] = byte(n)
+				}
+				v.Value = constant.MakeString(string(bytes))
+				v.Len = int64(len(bytes))
+
+			case *godwarf.IntType:
+				// []rune -> string
+				if elemType.Name != "int32" && elemType.Name != "rune" {
+					s.err = converr
+					return
+				}
+				cfg.MaxArrayValues = cfg.MaxStringLen
+				argv.loadValue(cfg)
+				if argv.Unreadable != nil {
+					s.err = argv.Unreadable
+					return
+				}
+				runes := make([]rune, len(argv.Children))
+				for i := range argv.Children {
+					n, _ := constant.Int64Val(argv.Children[i].Value)
+					runes[i] = rune(n)
+				}
+				v.Value = constant.MakeString(string(runes))
+				v.Len = int64(len(constant.StringVal(v.Value)))
+
+			default:
+				s.err = converr
+				return
+			}
+			s.push(v)
+			return
+		}
+	}
+
+	s.err = converr
+}
+
+// typeCastCompatibleTypes returns true if typ1 and typ2 are compatible for
+// a type cast where only the type of the variable is changed.
+func typeCastCompatibleTypes(typ1, typ2 godwarf.Type) bool {
+	if typ1 == nil || typ2 == nil || typ1.Common().Size() != typ2.Common().Size() || typ1.Common().Align() != typ2.Common().Align() {
+		return false
+	}
+
+	if typ1.String() == typ2.String() {
+		return true
+	}
+
+	switch ttyp1 := typ1.(type) {
+	case *godwarf.PtrType:
+		if ttyp2, ok := typ2.(*godwarf.PtrType); ok {
+			_, isvoid1 := ttyp1.Type.(*godwarf.VoidType)
+			_, isvoid2 := ttyp2.Type.(*godwarf.VoidType)
+			if isvoid1 || isvoid2 {
+				return true
+			}
+			// pointer types are compatible if their element types are compatible
+			return typeCastCompatibleTypes(godwarf.ResolveTypedef(ttyp1.Type), godwarf.ResolveTypedef(ttyp2.Type))
+		}
+	case *godwarf.StringType:
+		if _, ok := typ2.(*godwarf.StringType); ok {
+			return true
+		}
+	case *godwarf.StructType:
+		if ttyp2, ok := typ2.(*godwarf.StructType); ok {
+			// struct types are compatible if they have the same fields
+			if len(ttyp1.Field) != len(ttyp2.Field) {
+				return false
+			}
+			for i := range ttyp1.Field {
+				if *ttyp1.Field[i] != *ttyp2.Field[i] {
+					return false
+				}
+			}
+			return true
+		}
+	case *godwarf.ComplexType:
+		if _, ok := typ2.(*godwarf.ComplexType); ok {
+			// size and alignment already checked above
+			return true
+		}
+	}
+
+	return false
+}
+
+func convertInt(n uint64, signed bool, size int64) uint64 {
+	bits := uint64(size) * 8
+	mask := uint64((1 << bits) - 1)
+	r := n & mask
+	if signed && (r>>(bits-1)) != 0 {
+		// sign extension
+		r |= ^uint64(0) &^ mask
+	}
+	return r
+}
+
+func (scope *EvalScope) evalReslice(op *evalop.Reslice, s *evalStack) {
+	low, err := s.pop().asInt()
+	if err != nil {
+		s.err = err
+		return
+	}
+	var high int64
+	if op.HasHigh {
+		high, err = s.pop().asInt()
+		if err != nil {
+			s.err = err
+			return
+		}
+	}
+	xev := s.pop()
+	if xev.Unreadable != nil {
+		s.err = xev.Unreadable
+		return
+	}
+	if !op.HasHigh {
+		high = xev.Len
+	}
+
+	switch xev.Kind {
+	case reflect.Slice, reflect.Array, reflect.String:
+		if xev.Base == 0 {
+			s.err = fmt.Errorf("can not slice %q", astutil.ExprToString(op.Node.X))
+			return
+		}
+		s.pushErr(xev.reslice(low, high, op.TrustLen))
+		return
+	case reflect.Map:
+		if op.Node.High != nil {
+			s.err = errors.New("second slice argument must be empty for maps")
+			return
+		}
+		xev.mapSkip += int(low)
+		xev.mapIterator(0) // reads map length
+		if int64(xev.mapSkip) >= xev.Len {
+			s.err = errors.New("map index out of bounds")
+			return
+		}
+		s.push(xev)
+		return
+	default:
+		s.err = fmt.Errorf("can not slice %q (type %s)", astutil.ExprToString(op.Node.X), xev.TypeString())
+		return
+	}
+}
+
+func (v *Variable) reslice(low int64, high int64, trustLen bool) (*Variable, error) {
+	wrong := false
+	if v.Kind == reflect.Slice {
+		wrong = low < 0 || low > v.Cap || high < 0 || high > v.Cap
+	} else {
+		wrong = low < 0 || low > v.Len || high < 0 || high > v.Len
+	}
+	if wrong || high-low < 0 {
+		return nil, errors.New("index out of bounds")
+	}
+
+	base := v.Base + uint64(low*v.stride)
+	len := high - low
+
+	typ := v.DwarfType
+	if _, isarr := v.DwarfType.(*godwarf.ArrayType); isarr {
+		typ = godwarf.FakeSliceType(v.fieldType)
+	}
+
+	r := v.newVariable("", 0, typ, v.mem)
+	r.Cap = len
+	r.Len = len
+	r.Base = base
+	r.stride = v.stride
+	r.fieldType = v.fieldType
+	r.Flags = v.Flags
+	if trustLen {
+		r.Flags |= variableTrustLen
+	}
+	r.reg = v.reg
+
+	return r, nil
+}
+
+func (scope *EvalScope) evalIndex(op *evalop.Index, s *evalStack) {
+	idxev := s.pop()
+	xev := s.pop()
+	if xev.Unreadable != nil {
+		s.err = xev.Unreadable
+		return
+	}
+
+	xev = xev.maybeDereference()
+
+	cantindex := fmt.Errorf("expression %q (%s) does not support indexing", astutil.ExprToString(op.Node.X), xev.TypeString())
+
+	switch xev.Kind {
+	case reflect.Ptr:
+		if xev == nilVariable {
+			s.err = cantindex
+			return
+		}
+		_, isarrptr := xev.RealType.(*godwarf.PtrType).Type.(*godwarf.ArrayType)
+		if !isarrptr {
+			s.err = cantindex
+			return
+		}
+		xev = xev.maybeDereference()
+		fallthrough
+
+	case reflect.Slice, reflect.Array, reflect.String:
+		if xev.Base == 0 {
+			s.err = fmt.Errorf("can not index %q", astutil.ExprToString(op.Node.X))
+			return
+		}
+		n, err := idxev.asInt()
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.pushErr(xev.sliceAccess(int(n)))
+		return
+
+	case reflect.Map:
+		idxev.loadValue(loadFullValue)
+		if idxev.Unreadable != nil {
+			s.err = idxev.Unreadable
+			return
+		}
+		s.pushErr(xev.mapAccess(idxev))
+		return
+
+	default:
+		s.err = cantindex
+		return
+	}
+}
+
+func (v *Variable) mapAccess(idx *Variable) (*Variable, error) {
+	it := v.mapIterator(0)
+	if it == nil {
+		return nil, fmt.Errorf("can not access unreadable map: %v", v.Unreadable)
+	}
+
+	found, err := v.seekMapKey(it, idx)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		// go would return zero for the map value type here, we do not have the ability to create zeroes
+		return nil, errors.New("key not found")
+	}
+	return it.value(), nil
+}
+
+func (scope *EvalScope) evalJump(op *evalop.Jump, s *evalStack) {
+	var x *Variable
+
+	switch op.When {
+	case evalop.JumpIfTrue, evalop.JumpIfFalse, evalop.JumpIfAllocStringChecksFail:
+		x = s.peek()
+		if op.Pop {
+			s.pop()
+		}
+	}
+
+	var v bool
+	switch op.When {
+	case evalop.JumpIfTrue:
+		v = true
+	case evalop.JumpIfFalse:
+		v = false
+	case evalop.JumpIfAllocStringChecksFail, evalop.JumpAlways, evalop.JumpIfPinningDone:
+		// Strings are never allocated in the target and nothing is ever
+		// pinned, the jump is always taken.
+		s.opidx = op.Target - 1
+		return
+	}
+
+	if x.Kind != reflect.Bool {
+		if op.Node != nil {
+			s.err = fmt.Errorf("expression %q should be boolean not %s", astutil.ExprToString(op.Node), x.Kind)
+		} else {
+			s.err = errors.New("internal debugger error: expected boolean")
+		}
+		return
+	}
+	x.loadValue(loadFullValue)
+	if x.Unreadable != nil {
+		s.err = x.Unreadable
+		return
+	}
+	if constant.BoolVal(x.Value) == v {
+		s.opidx = op.Target - 1
+	}
+}
+
+func (scope *EvalScope) evalBinary(binop *evalop.Binary, s *evalStack) {
+	node := binop.Node
+
+	yv := s.pop()
+	xv := s.pop()
+
+	if xv.Kind != reflect.String { // delay loading strings until we use them
+		xv.loadValue(loadFullValue)
+	}
+	if xv.Unreadable != nil {
+		s.err = xv.Unreadable
+		return
+	}
+	if yv.Kind != reflect.String { // delay loading strings until we use them
+		yv.loadValue(loadFullValue)
+	}
+	if yv.Unreadable != nil {
+		s.err = yv.Unreadable
+		return
+	}
+
+	if xv.FloatSpecial != 0 || yv.FloatSpecial != 0 {
+		s.err = errOperationOnSpecialFloat
+		return
+	}
+
+	typ, err := negotiateType(node.Op, xv, yv)
+	if err != nil {
+		s.err = err
+		return
+	}
+
+	op := node.Op
+	if typ != nil && (op == token.QUO) {
+		_, isint := typ.(*godwarf.IntType)
+		_, isuint := typ.(*godwarf.UintType)
+		if isint || isuint {
+			// forces integer division if the result type is integer
+			op = token.QUO_ASSIGN
+		}
+	}
+
+	switch op {
+	case token.EQL, token.LSS, token.GTR, token.NEQ, token.LEQ, token.GEQ:
+		v, err := compareOp(op, xv, yv)
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.push(newConstant(constant.MakeBool(v), xv.mem))
+
+	default:
+		if xv.Kind == reflect.String {
+			xv.loadValue(loadFullValueLongerStrings)
+		}
+		if yv.Kind == reflect.String {
+			yv.loadValue(loadFullValueLongerStrings)
+		}
+		if xv.Value == nil {
+			s.err = fmt.Errorf("operator %s can not be applied to %q", node.Op.String(), astutil.ExprToString(node.X))
+			return
+		}
+
+		if yv.Value == nil {
+			s.err = fmt.Errorf("operator %s can not be applied to %q", node.Op.String(), astutil.ExprToString(node.Y))
+			return
+		}
+
+		rc, err := constantBinaryOp(op, xv.Value, yv.Value)
+		if err != nil {
+			s.err = err
+			return
+		}
+
+		if typ == nil {
+			s.push(newConstant(rc, xv.mem))
+			return
+		}
+
+		r := xv.newVariable("", 0, typ, scope.Mem)
+		r.Value = rc
+		switch r.Kind {
+		case reflect.String:
+			r.Len = xv.Len + yv.Len
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, _ := constant.Int64Val(r.Value)
+			r.Value = constant.MakeInt64(int64(convertInt(uint64(n), true, typ.Size())))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n, _ := constant.Uint64Val(r.Value)
+			r.Value = constant.MakeUint64(convertInt(n, false, typ.Size()))
+		}
+		r.loaded = true
+		s.push(r)
+	}
+}
+
+// Returns the type of a binary operation or an error
+func negotiateType(op token.Token, xv, yv *Variable) (godwarf.Type, error) {
+	if xv == nilVariable {
+		return nil, negotiateTypeNil(op, yv)
+	}
+
+	if yv == nilVariable {
+		return nil, negotiateTypeNil(op, xv)
+	}
+
+	if op == token.SHR || op == token.SHL {
+		if xv.Value == nil || xv.Value.Kind() != constant.Int {
+			return nil, fmt.Errorf("shift of type %s", xv.Kind)
+		}
+
+		switch yv.Kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			// ok
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if constant.Sign(yv.Value) < 0 {
+				return nil, errors.New("shift count must not be negative")
+			}
+		default:
+			return nil, fmt.Errorf("shift count type %s, must be unsigned integer", yv.Kind.String())
+		}
+
+		return xv.DwarfType, nil
+	}
+
+	if xv.DwarfType == nil && yv.DwarfType == nil {
+		return nil, nil
+	}
+
+	if xv.DwarfType != nil && yv.DwarfType != nil {
+		if xv.DwarfType.String() != yv.DwarfType.String() {
+			return nil, fmt.Errorf("mismatched types %q and %q", xv.DwarfType.String(), yv.DwarfType.String())
+		}
+		return xv.DwarfType, nil
+	} else if xv.DwarfType != nil && yv.DwarfType == nil {
+		if err := yv.isType(xv.DwarfType, xv.Kind); err != nil {
+			return nil, err
+		}
+		return xv.DwarfType, nil
+	}
+
+	if err := xv.isType(yv.DwarfType, yv.Kind); err != nil {
+		return nil, err
+	}
+	return yv.DwarfType, nil
+}
+
+func negotiateTypeNil(op token.Token, v *Variable) error {
+	if op != token.EQL && op != token.NEQ {
+		return fmt.Errorf("operator %s can not be applied to \"nil\"", op.String())
+	}
+	switch v.Kind {
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Chan, reflect.Map, reflect.Interface, reflect.Slice, reflect.Func:
+		return nil
+	default:
+		return fmt.Errorf("can not compare %s to nil", v.Kind.String())
+	}
+}
+
+func compareOp(op token.Token, xv *Variable, yv *Variable) (bool, error) {
+	switch xv.Kind {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return constantCompare(op, xv.Value, yv.Value)
+	case reflect.String:
+		if xv.Len != yv.Len {
+			switch op {
+			case token.EQL:
+				return false, nil
+			case token.NEQ:
+				return true, nil
+			}
+		}
+		xv.loadValue(loadFullValueLongerStrings)
+		yv.loadValue(loadFullValueLongerStrings)
+		if int64(len(constant.StringVal(xv.Value))) != xv.Len || int64(len(constant.StringVal(yv.Value))) != yv.Len {
+			return false, errors.New("string too long for comparison")
+		}
+		return constantCompare(op, xv.Value, yv.Value)
+	}
+
+	if op != token.EQL && op != token.NEQ {
+		return false, fmt.Errorf("operator %s not defined on %s", op.String(), xv.Kind.String())
+	}
+
+	var eql bool
+	var err error
+
+	if xv == nilVariable {
+		switch op {
+		case token.EQL:
+			return yv.isNil(), nil
+		case token.NEQ:
+			return !yv.isNil(), nil
+		}
+	}
+
+	if yv == nilVariable {
+		switch op {
+		case token.EQL:
+			return xv.isNil(), nil
+		case token.NEQ:
+			return !xv.isNil(), nil
+		}
+	}
+
+	switch xv.Kind {
+	case reflect.Ptr, reflect.UnsafePointer:
+		eql = xv.Children[0].Addr == yv.Children[0].Addr
+	case reflect.Array:
+		if int64(len(xv.Children)) != xv.Len || int64(len(yv.Children)) != yv.Len {
+			return false, errors.New("array too long for comparison")
+		}
+		eql, err = equalChildren(xv, yv, true)
+	case reflect.Struct:
+		if len(xv.Children) != len(yv.Children) {
+			return false, nil
+		}
+		if int64(len(xv.Children)) != xv.Len || int64(len(yv.Children)) != yv.Len {
+			return false, errors.New("structure too deep for comparison")
+		}
+		eql, err = equalChildren(xv, yv, false)
+	case reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+		return false, fmt.Errorf("can not compare %s variables", xv.Kind.String())
+	case reflect.Interface:
+		if xv.Children[0].RealType.String() != yv.Children[0].RealType.String() {
+			eql = false
+		} else {
+			eql, err = compareOp(token.EQL, &xv.Children[0], &yv.Children[0])
+		}
+	default:
+		return false, fmt.Errorf("unimplemented comparison of %s variables", xv.Kind.String())
+	}
+
+	if op == token.NEQ {
+		return !eql, err
+	}
+	return eql, err
+}
+
+func (v *Variable) isNil() bool {
+	switch v.Kind {
+	case reflect.Ptr, reflect.UnsafePointer:
+		return v.Children[0].Addr == 0
+	case reflect.Interface:
+		return v.Children[0].Addr == 0 && v.Children[0].Kind == reflect.Invalid
+	case reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+		return v.Base == 0
+	}
+	return false
+}
+
+func equalChildren(xv, yv *Variable, shortcircuit bool) (bool, error) {
+	r := true
+	for i := range xv.Children {
+		eql, err := compareOp(token.EQL, &xv.Children[i], &yv.Children[i])
+		if err != nil {
+			return false, err
+		}
+		r = r && eql
+		if !r && shortcircuit {
+			return false, nil
+		}
+	}
+	return r, nil
+}
+
+func constantUnaryOp(op token.Token, y constant.Value) (r constant.Value, err error) {
+	defer func() {
+		if ierr := recover(); ierr != nil {
+			err = fmt.Errorf("%v", ierr)
+		}
+	}()
+	r = constant.UnaryOp(op, y, 0)
+	return
+}
+
+func constantBinaryOp(op token.Token, x, y constant.Value) (r constant.Value, err error) {
+	defer func() {
+		if ierr := recover(); ierr != nil {
+			err = fmt.Errorf("%v", ierr)
+		}
+	}()
+	switch op {
+	case token.SHL, token.SHR:
+		n, _ := constant.Uint64Val(y)
+		r = constant.Shift(x, op, uint(n))
+	default:
+		r = constant.BinaryOp(x, op, y)
+	}
+	return
+}
+
+func constantCompare(op token.Token, x, y constant.Value) (r bool, err error) {
+	defer func() {
+		if ierr := recover(); ierr != nil {
+			err = fmt.Errorf("%v", ierr)
+		}
+	}()
+	r = constant.Compare(x, op, y)
+	return
+}
+
+// setValue writes the value of srcv to dstv, see SetValue.
+func (scope *EvalScope) setValue(dstv, srcv *Variable, srcExpr string) error {
+	if dstv.Addr == 0 || dstv.Flags&VariableConstant != 0 {
+		return fmt.Errorf("can not assign to %q", dstv.Name)
+	}
+	srcv.loadValue(loadSingleValue)
+	if srcv.Kind == reflect.String && srcv.Flags&VariableConstant != 0 && srcv.Base == 0 && srcv.Len > 0 && scope.Alloc != nil {
+		if err := scope.allocString(srcv); err != nil {
+			return err
+		}
+	}
+	return SetValue(dstv, srcv, srcExpr)
+}
+
+// allocString stores the contents of the string constant v in memory
+// allocated in the target.
+func (scope *EvalScope) allocString(v *Variable) error {
+	s := constant.StringVal(v.Value)
+	addr, err := scope.Alloc(uint64(len(s)), 1)
+	if err != nil {
+		return err
+	}
+	if _, err := scope.Mem.WriteMemory(addr, []byte(s)); err != nil {
+		return err
+	}
+	v.Base = addr
+	return nil
+}
+
+var supportedBuiltins = map[string]func([]*Variable, []ast.Expr) (*Variable, error){
+	"cap":     capBuiltin,
+	"len":     lenBuiltin,
+	"complex": complexBuiltin,
+	"imag":    imagBuiltin,
+	"real":    realBuiltin,
+	"min":     minBuiltin,
+	"max":     maxBuiltin,
+}
+
+func capBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments to cap: %d", len(args))
+	}
+
+	arg := args[0]
+	invalidArgErr := fmt.Errorf("invalid argument %s (type %s) for cap", astutil.ExprToString(nodeargs[0]), arg.TypeString())
+
+	switch arg.Kind {
+	case reflect.Ptr:
+		arg = arg.maybeDereference()
+		if arg.Kind != reflect.Array {
+			return nil, invalidArgErr
+		}
+		fallthrough
+	case reflect.Array:
+		return newConstant(constant.MakeInt64(arg.Len), arg.mem), nil
+	case reflect.Slice:
+		return newConstant(constant.MakeInt64(arg.Cap), arg.mem), nil
+	case reflect.Chan:
+		arg.loadValue(loadFullValue)
+		if arg.Unreadable != nil {
+			return nil, arg.Unreadable
+		}
+		if arg.Base == 0 {
+			return newConstant(constant.MakeInt64(0), arg.mem), nil
+		}
+		return newConstant(arg.Children[1].Value, arg.mem), nil
+	default:
+		return nil, invalidArgErr
+	}
+}
+
+func lenBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments to len: %d", len(args))
+	}
+	arg := args[0]
+	invalidArgErr := fmt.Errorf("invalid argument %s (type %s) for len", astutil.ExprToString(nodeargs[0]), arg.TypeString())
+
+	switch arg.Kind {
+	case reflect.Ptr:
+		arg = arg.maybeDereference()
+		if arg.Kind != reflect.Array {
+			return nil, invalidArgErr
+		}
+		fallthrough
+	case reflect.Array, reflect.Slice, reflect.String:
+		if arg.Unreadable != nil {
+			return nil, arg.Unreadable
+		}
+		return newConstant(constant.MakeInt64(arg.Len), arg.mem), nil
+	case reflect.Chan:
+		arg.loadValue(loadFullValue)
+		if arg.Unreadable != nil {
+			return nil, arg.Unreadable
+		}
+		if arg.Base == 0 {
+			return newConstant(constant.MakeInt64(0), arg.mem), nil
+		}
+		return newConstant(arg.Children[0].Value, arg.mem), nil
+	case reflect.Map:
+		it := arg.mapIterator(0)
+		if arg.Unreadable != nil {
+			return nil, arg.Unreadable
+		}
+		if it == nil {
+			return newConstant(constant.MakeInt64(0), arg.mem), nil
+		}
+		return newConstant(constant.MakeInt64(arg.Len), arg.mem), nil
+	default:
+		return nil, invalidArgErr
+	}
+}
+
+func complexBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("wrong number of arguments to complex: %d", len(args))
+	}
+
+	realev := args[0]
+	imagev := args[1]
+
+	realev.loadValue(loadSingleValue)
+	imagev.loadValue(loadSingleValue)
+
+	if realev.Unreadable != nil {
+		return nil, realev.Unreadable
+	}
+
+	if imagev.Unreadable != nil {
+		return nil, imagev.Unreadable
+	}
+
+	if realev.Value == nil || ((realev.Value.Kind() != constant.Int) && (realev.Value.Kind() != constant.Float)) {
+		return nil, fmt.Errorf("invalid argument 1 %s (type %s) to complex", astutil.ExprToString(nodeargs[0]), realev.TypeString())
+	}
+
+	if imagev.Value == nil || ((imagev.Value.Kind() != constant.Int) && (imagev.Value.Kind() != constant.Float)) {
+		return nil, fmt.Errorf("invalid argument 2 %s (type %s) to complex", astutil.ExprToString(nodeargs[1]), imagev.TypeString())
+	}
+
+	// the size of the result is twice the size of the largest float argument
+	sz := int64(0)
+	if ftyp, ok := realev.RealType.(*godwarf.FloatType); ok {
+		sz = ftyp.Size()
+	}
+	if ftyp, ok := imagev.RealType.(*godwarf.FloatType); ok && ftyp.Size() > sz {
+		sz = ftyp.Size()
+	}
+	if sz == 0 {
+		sz = 8
+	}
+
+	typ := godwarf.FakeBasicType("complex", int(sz*2*8))
+
+	r := realev.newVariable("", 0, typ, nil)
+	r.Value = constant.BinaryOp(realev.Value, token.ADD, constant.MakeImag(imagev.Value))
+	r.loaded = true
+	return r, nil
+}
+
+func imagBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments to imag: %d", len(args))
+	}
+
+	arg := args[0]
+	arg.loadValue(loadSingleValue)
+
+	if arg.Unreadable != nil {
+		return nil, arg.Unreadable
+	}
+
+	if arg.Kind != reflect.Complex64 && arg.Kind != reflect.Complex128 {
+		return nil, fmt.Errorf("invalid argument %s (type %s) to imag", astutil.ExprToString(nodeargs[0]), arg.TypeString())
+	}
+
+	return newConstant(constant.Imag(arg.Value), arg.mem), nil
+}
+
+func realBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments to real: %d", len(args))
+	}
+
+	arg := args[0]
+	arg.loadValue(loadSingleValue)
+
+	if arg.Unreadable != nil {
+		return nil, arg.Unreadable
+	}
+
+	if arg.Value == nil || ((arg.Value.Kind() != constant.Int) && (arg.Value.Kind() != constant.Float) && (arg.Value.Kind() != constant.Complex)) {
+		return nil, fmt.Errorf("invalid argument %s (type %s) to real", astutil.ExprToString(nodeargs[0]), arg.TypeString())
+	}
+
+	return newConstant(constant.Real(arg.Value), arg.mem), nil
+}
+
+func minBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	return minmaxBuiltin("min", token.LSS, args, nodeargs)
+}
+
+func maxBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	return minmaxBuiltin("max", token.GTR, args, nodeargs)
+}
+
+func minmaxBuiltin(name string, op token.Token, args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	var best *Variable
+
+	for i := range args {
+		if args[i].Kind == reflect.String {
+			args[i].loadValue(loadFullValueLongerStrings)
+		} else {
+			args[i].loadValue(loadFullValue)
+		}
+
+		if args[i].Unreadable != nil {
+			return nil, fmt.Errorf("could not load %q: %v", astutil.ExprToString(nodeargs[i]), args[i].Unreadable)
+		}
+		if args[i].FloatSpecial == FloatIsNaN {
+			return nil, errors.New("NaN value")
+		}
+
+		switch args[i].Kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64, reflect.String:
+			// ok
+		default:
+			return nil, fmt.Errorf("%s(%s) not supported", name, args[i].TypeString())
+		}
+
+		if best == nil {
+			best = args[i]
+			continue
+		}
+
+		if _, err := negotiateType(op, args[i], best); err != nil {
+			return nil, err
+		}
+
+		v, err := compareOp(op, args[i], best)
+		if err != nil {
+			return nil, err
+		}
+
+		if v {
+			best = args[i]
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("not enough arguments to %s", name)
+	}
+	return best, nil
+}
@@ -0,0 +1,102 @@
+package proc
+
+import (
+	"go/constant"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestEvalExpression(t *testing.T) {
+	scope := fixtureScope(t)
+
+	for _, tc := range []struct {
+		expr string
+		want constant.Value
+	}{
+		// field access, through the pointer
+		{"main.cfg.Name", constant.MakeString("api")},
+		{"main.cfg.Port", constant.MakeUint64(8080)},
+		{"(*main.cfg).Port", constant.MakeUint64(8080)},
+		// index
+		{"main.cfg.Points[1].Y", constant.MakeInt64(4)},
+		{"main.cfg.Tags[2]", constant.MakeString("c")},
+		{"main.cfg.Name[1]", constant.MakeUint64('p')},
+		// slices
+		{"main.cfg.Points[1:][1].X", constant.MakeInt64(5)},
+		{"main.cfg.Name[1:]", constant.MakeString("pi")},
+		// string concatenation of loaded values
+		{"main.cfg.Name + main.cfg.Tags[0]", constant.MakeString("apia")},
+		// len and cap
+		{"len(main.cfg.Points)", constant.MakeInt64(3)},
+		{"cap(main.cfg.Points)", constant.MakeInt64(3)},
+		{"cap(main.numbers[10:20])", constant.MakeInt64(10)},
+		{"len(main.cfg.Tags)", constant.MakeInt64(3)},
+		{"len(main.cfg.Name)", constant.MakeInt64(3)},
+		{"len(main.cfg.Limits)", constant.MakeInt64(2)},
+		// casts
+		{"uint8(main.cfg.Port)", constant.MakeUint64(8080 & 0xff)},
+		{"int64(main.cfg.Points[2].X) * 2", constant.MakeInt64(10)},
+		// map index
+		{`main.cfg.Limits["api"]`, constant.MakeInt64(100)},
+		{`main.cfg.Limits[main.cfg.Tags[0] + "pi"]`, constant.MakeInt64(100)},
+		// comparisons
+		{"main.cfg.Port == 8080", constant.MakeBool(true)},
+		{`main.cfg.Name != "api"`, constant.MakeBool(false)},
+		{"main.cfg.Points[0].X < main.cfg.Points[1].X", constant.MakeBool(true)},
+		{"main.cfg.Points[0] == main.cfg.Points[1]", constant.MakeBool(false)},
+		{"main.cfg.Next == nil", constant.MakeBool(true)},
+		{"main.cfg.Next == nil && len(main.cfg.Tags) > 5", constant.MakeBool(false)},
+	} {
+		v, err := scope.EvalExpression(tc.expr, loadFullValue)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+		if v.Unreadable != nil {
+			t.Errorf("%s: unreadable: %v", tc.expr, v.Unreadable)
+			continue
+		}
+		if v.Value == nil || v.Value.Kind() != tc.want.Kind() || !constant.Compare(v.Value, token.EQL, tc.want) {
+			t.Errorf("%s = %v, want %v", tc.expr, v.Value, tc.want)
+		}
+	}
+}
+
+func TestEvalExpressionErrors(t *testing.T) {
+	scope := fixtureScope(t)
+
+	for _, tc := range []struct {
+		expr string
+		want string
+	}{
+		{"main.missing", "could not find symbol"},
+		{"missing", "could not find symbol value for missing"},
+		{"main.cfg.Points[5]", "out of bounds"},
+		{"main.cfg.Tags[1:7]", "out of bounds"},
+		{`main.cfg.Limits["db"]`, "not found"},
+		{"main.cfg.Port.X", "Port"},
+		// frame-only operations
+		{"runtime.frameoff", errNoFrame.Error()},
+		{"runtime.threadid", errNoFrame.Error()},
+		// function calls
+		{"runtime.Gosched()", errFuncCallNotSupported.Error()},
+		{"main.main()", errFuncCallNotSupported.Error()},
+	} {
+		v, err := scope.EvalExpression(tc.expr, loadFullValue)
+		if err == nil && v.Unreadable != nil {
+			err = v.Unreadable
+		}
+		switch {
+		case err == nil:
+			t.Errorf("%s = %v, want an error", tc.expr, v.Value)
+		case strings.Contains(err.Error(), "internal debugger error"):
+			t.Errorf("%s: %v", tc.expr, err)
+		case !strings.Contains(err.Error(), tc.want):
+			t.Errorf("%s: error %q, want %q", tc.expr, err, tc.want)
+		}
+	}
+	if _, err := scope.Locals(""); err != errNoFrame {
+		t.Errorf("Locals without a frame: %v, want %v", err, errNoFrame)
+	}
+}
@@ -0,0 +1,99 @@
+package proc
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// fixture is the running testdata/fixture process, started by the first
+// test that needs it and stopped by TestMain.
+var fixture struct {
+	once sync.Once
+	err  error
+	dir  string
+	cmd  *exec.Cmd
+	bi   *BinaryInfo
+	mem  *processMemory
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if fixture.cmd != nil {
+		fixture.cmd.Process.Kill()
+		fixture.cmd.Wait()
+	}
+	if fixture.dir != "" {
+		os.RemoveAll(fixture.dir)
+	}
+	os.Exit(code)
+}
+
+// processMemory is the memory of a process, read through /proc/<pid>/mem.
+type processMemory struct {
+	f *os.File
+}
+
+func (m *processMemory) ReadMemory(data []byte, addr uint64) (int, error) {
+	return m.f.ReadAt(data, int64(addr))
+}
+
+func (m *processMemory) WriteMemory(addr uint64, data []byte) (int, error) {
+	return m.f.WriteAt(data, int64(addr))
+}
+
+// fixtureScope returns a scope over the package variables of the fixture
+// process, skipping the test if it can not be built or read.
+func fixtureScope(t *testing.T) *EvalScope {
+	t.Helper()
+	if runtime.GOOS != "linux" || (runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64") {
+		t.Skipf("not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	fixture.once.Do(func() { fixture.err = startFixture() })
+	if fixture.err != nil {
+		t.Skipf("fixture: %v", fixture.err)
+	}
+	return GlobalScope(fixture.bi, fixture.mem)
+}
+
+func startFixture() error {
+	dir, err := os.MkdirTemp("", "proc-fixture")
+	if err != nil {
+		return err
+	}
+	fixture.dir = dir
+	exe := filepath.Join(dir, "fixture")
+	out, err := exec.Command("go", "build", "-o", exe, "./testdata/fixture").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v\n%s", err, out)
+	}
+
+	cmd := exec.Command(exe)
+	if _, err := cmd.StdinPipe(); err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	fixture.cmd = cmd
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "ready\n" {
+		return fmt.Errorf("fixture did not start: %q, %v", line, err)
+	}
+
+	f, err := os.OpenFile(fmt.Sprintf("/proc/%d/mem", cmd.Process.Pid), os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	fixture.mem = &processMemory{f}
+	fixture.bi = NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+	return fixture.bi.LoadBinaryInfo(exe, 0, nil)
+}
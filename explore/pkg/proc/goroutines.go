@@ -0,0 +1,222 @@
+package proc
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"go/constant"
+	"strings"
+	"time"
+
+	"explore/pkg/dwarf/godwarf"
+)
+
+var gStatusNames = [...]string{
+	Gidle:           "idle",
+	Grunnable:       "runnable",
+	Grunning:        "running",
+	Gsyscall:        "syscall",
+	Gwaiting:        "waiting",
+	GmoribundUnused: "moribund",
+	Gdead:           "dead",
+	Genqueue:        "enqueue",
+	Gcopystack:      "copystack",
+	Gpreempted:      "preempted",
+	Gleaked:         "leaked",
+	Gdeadextra:      "dead",
+}
+
+// Goroutines returns the live goroutines of the process, read from
+// runtime.allgs. Goroutines whose G struct can not be read are returned with
+// Unreadable set.
+func Goroutines(bi *BinaryInfo, mem MemoryReadWriter) ([]*G, error) {
+	allgs, err := findGlobal(bi, mem, "runtime", "allgs")
+	if err != nil {
+		return nil, err
+	}
+	t, ok := allgs.RealType.(*godwarf.SliceType)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %s for runtime.allgs", allgs.RealType)
+	}
+	allgs.loadSliceInfo(t)
+	if allgs.Unreadable != nil {
+		return nil, allgs.Unreadable
+	}
+
+	reasons := waitReasonStrings(bi, mem)
+
+	gs := make([]*G, 0, allgs.Len)
+	for i := int64(0); i < allgs.Len; i++ {
+		gvar := newVariable("", allgs.Base+uint64(i*allgs.stride), allgs.fieldType, bi, mem)
+		g, err := gvar.parseG()
+		if err != nil {
+			gs = append(gs, &G{Unreadable: err})
+			continue
+		}
+		if s := g.Status &^ Gscan; s == Gdead || s == Gdeadextra {
+			continue
+		}
+		g.waitReasons = reasons
+		gs = append(gs, g)
+	}
+
+	return gs, nil
+}
+
+// FindGoroutine returns the goroutine of the process with the given id.
+func FindGoroutine(bi *BinaryInfo, mem MemoryReadWriter, gid int64) (*G, error) {
+	gs, err := Goroutines(bi, mem)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gs {
+		if g.Unreadable == nil && g.ID == gid {
+			return g, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown goroutine %d", gid)
+}
+
+// waitReasonStrings returns the names of the wait reasons of the runtime of
+// the process, or nil if runtime.waitReasonStrings can not be read.
+func waitReasonStrings(bi *BinaryInfo, mem MemoryReadWriter) []string {
+	v, err := findGlobal(bi, mem, "runtime", "waitReasonStrings")
+	if err != nil {
+		return nil
+	}
+	v.loadValue(LoadConfig{MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 256})
+	if v.Unreadable != nil {
+		return nil
+	}
+
+	r := make([]string, len(v.Children))
+	for i := range v.Children {
+		if v.Children[i].Value != nil {
+			r[i] = constant.StringVal(v.Children[i].Value)
+		}
+	}
+	return r
+}
+
+// StatusString returns the name of the status of g, e.g. "waiting".
+func (g *G) StatusString() string {
+	s := g.Status &^ Gscan
+	if s < uint64(len(gStatusNames)) {
+		return gStatusNames[s]
+	}
+	return fmt.Sprintf("status(%d)", g.Status)
+}
+
+// WaitReasonString returns the reason why g is blocked, e.g. "chan receive",
+// or an empty string if g is not waiting.
+func (g *G) WaitReasonString() string {
+	if s := g.Status &^ Gscan; s != Gwaiting && s != Gleaked {
+		return ""
+	}
+	if g.WaitReason >= 0 && g.WaitReason < int64(len(g.waitReasons)) {
+		return g.waitReasons[g.WaitReason]
+	}
+	return fmt.Sprintf("waitReason(%d)", g.WaitReason)
+}
+
+// WaitFor returns for how long g has been blocked, now is the current value
+// of the runtime clock (nanotime) of the process. The runtime records the
+// time at which a goroutine blocked only during garbage collections, zero is
+// returned if it is not known.
+func (g *G) WaitFor(now int64) time.Duration {
+	if s := g.Status &^ Gscan; s != Gwaiting && s != Gsyscall && s != Gleaked {
+		return 0
+	}
+	if g.WaitSince == 0 || now < g.WaitSince {
+		return 0
+	}
+	return time.Duration(now - g.WaitSince)
+}
+
+// Go returns the location of the 'go' statement that started g.
+func (g *G) Go() Location {
+	pc := g.GoPC
+	if fn := g.variable.bi.PCToFunc(pc); fn != nil {
+		// GoPC is the return address of the call to newproc, back up to
+		// the call instruction.
+		if pc > fn.Entry {
+			pc--
+		}
+	}
+	f, l, fn := g.variable.bi.PCToLine(pc)
+	return Location{PC: g.GoPC, File: f, Line: l, Fn: fn}
+}
+
+// StartLoc returns the location of the first function run by g, for
+// goroutines started by the wrappers generated for go statements this is
+// the function called by the wrapper.
+func (g *G) StartLoc() Location {
+	fn := dwrapUnwrap(g.variable.bi, g.variable.mem, g.variable.bi.PCToFunc(g.StartPC))
+	if fn == nil {
+		return Location{PC: g.StartPC}
+	}
+	if fn.Entry == 0 {
+		// inlined in the wrapper of the go statement
+		return Location{PC: g.StartPC, Fn: fn}
+	}
+	f, l := g.variable.bi.EntryLineForFunc(fn)
+	return Location{PC: fn.Entry, File: f, Line: l, Fn: fn}
+}
+
+// dwrapUnwrap returns the function called by fn if fn is a wrapper generated
+// by the compiler for a go or defer statement, fn otherwise.
+func dwrapUnwrap(bi *BinaryInfo, mem MemoryReadWriter, fn *Function) *Function {
+	if fn == nil {
+		return nil
+	}
+	if !goWrapper(fn.Name) && !fn.trampoline {
+		return fn
+	}
+	// the function may have been inlined in the wrapper
+	if tree, err := fn.cu.image.getDwarfTree(fn.offset); err == nil {
+		for _, child := range tree.Children {
+			if child.Tag != dwarf.TagInlinedSubroutine {
+				continue
+			}
+			if name, ok := child.Val(dwarf.AttrName).(string); ok {
+				if inlfn := bi.lookupOneFunc(name); inlfn != nil {
+					return inlfn
+				}
+			}
+		}
+	}
+	text, err := disassemble(mem, nil, bi, fn.Entry, fn.End, false)
+	if err != nil {
+		return fn
+	}
+	for _, instr := range text {
+		if !instr.IsCall() || instr.DestLoc == nil || instr.DestLoc.Fn == nil {
+			continue
+		}
+		// the calls to the runtime are the ones of the wrapper itself, e.g.
+		// to morestack, unless it wraps a function of the runtime, e.g.
+		// runtime.gcenable.gowrap1 calling runtime.bgsweep
+		callee := instr.DestLoc.Fn
+		if !callee.privateRuntime() || (fn.privateRuntime() && !strings.HasPrefix(callee.Name, "runtime.morestack")) {
+			return callee
+		}
+	}
+	return fn
+}
+
+// goWrapper reports whether the function named name is a wrapper generated
+// by the compiler for a go or defer statement: f·dwrap·1 before go1.21, and
+// f.gowrap1 or f.deferwrap1 since.
+func goWrapper(name string) bool {
+	if strings.Contains(name, "·dwrap·") {
+		return true
+	}
+	for _, suffix := range []string{".gowrap", ".deferwrap"} {
+		if i := strings.LastIndex(name, suffix); i >= 0 {
+			n := name[i+len(suffix):]
+			if n != "" && strings.Trim(n, "0123456789") == "" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package proc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGoWrapper(t *testing.T) {
+	for name, want := range map[string]bool{
+		"main.main·dwrap·1":             true,
+		"runtime.gcenable.gowrap1":      true,
+		"main.(*Server).Serve.gowrap12": true,
+		"main.main.deferwrap1":          true,
+		"main.main.func1":               false,
+		"main.gowrap":                   false,
+		"main.gowrapper":                false,
+		"main.main":                     false,
+	} {
+		if got := goWrapper(name); got != want {
+			t.Errorf("goWrapper(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestGStatusString(t *testing.T) {
+	for _, test := range []struct {
+		status uint64
+		want   string
+	}{
+		{Grunnable, "runnable"},
+		{Gwaiting, "waiting"},
+		{Gscan | Grunning, "running"},
+		{Gleaked, "leaked"},
+		{Gdeadextra, "dead"},
+		{42, "status(42)"},
+		{Gscan | 42, "status(4138)"},
+	} {
+		g := &G{Status: test.status}
+		if got := g.StatusString(); got != test.want {
+			t.Errorf("StatusString of %#x = %q, want %q", test.status, got, test.want)
+		}
+	}
+}
+
+func TestGWaitReasonString(t *testing.T) {
+	reasons := []string{"", "GC assist marking", "chan receive"}
+	for _, test := range []struct {
+		status  uint64
+		reason  int64
+		reasons []string
+		want    string
+	}{
+		{Gwaiting, 2, reasons, "chan receive"},
+		{Gscan | Gwaiting, 1, reasons, "GC assist marking"},
+		{Gleaked, 2, reasons, "chan receive"},
+		// only waiting goroutines have a wait reason
+		{Grunnable, 2, reasons, ""},
+		{Gsyscall, 2, reasons, ""},
+		// out of the names of the runtime, or without them
+		{Gwaiting, 3, reasons, "waitReason(3)"},
+		{Gwaiting, -1, reasons, "waitReason(-1)"},
+		{Gwaiting, 2, nil, "waitReason(2)"},
+	} {
+		g := &G{Status: test.status, WaitReason: test.reason, waitReasons: test.reasons}
+		if got := g.WaitReasonString(); got != test.want {
+			t.Errorf("WaitReasonString of status %#x, reason %d = %q, want %q", test.status, test.reason, got, test.want)
+		}
+	}
+}
+
+func TestGWaitFor(t *testing.T) {
+	for _, test := range []struct {
+		status uint64
+		since  int64
+		now    int64
+		want   time.Duration
+	}{
+		{Gwaiting, 100, 350, 250},
+		{Gscan | Gwaiting, 100, 350, 250},
+		{Gsyscall, 100, 100 + int64(time.Second), time.Second},
+		{Gleaked, 100, 350, 250},
+		// not blocked
+		{Grunning, 100, 350, 0},
+		{Grunnable, 100, 350, 0},
+		// not recorded, or recorded after now
+		{Gwaiting, 0, 350, 0},
+		{Gwaiting, 400, 350, 0},
+	} {
+		g := &G{Status: test.status, WaitSince: test.since}
+		if got := g.WaitFor(test.now); got != test.want {
+			t.Errorf("WaitFor(%d) of status %#x since %d = %v, want %v", test.now, test.status, test.since, got, test.want)
+		}
+	}
+}
+
+func TestGoroutines(t *testing.T) {
+	scope := fixtureScope(t)
+	gs, err := Goroutines(scope.BinInfo, scope.Mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var worker *G
+	for _, g := range gs {
+		if g.Unreadable != nil {
+			t.Errorf("unreadable goroutine: %v", g.Unreadable)
+			continue
+		}
+		if start := g.StartLoc(); start.Fn != nil && start.Fn.Name == "main.worker" {
+			worker = g
+		}
+	}
+	if worker == nil {
+		t.Fatal("the goroutine started by go worker(ch) not found")
+	}
+	if s, r := worker.StatusString(), worker.WaitReasonString(); s != "waiting" || r != "chan receive" {
+		t.Errorf("worker is %s (%s), want waiting (chan receive)", s, r)
+	}
+	if fn := worker.Go().Fn; fn == nil || fn.Name != "main.main" {
+		t.Errorf("worker started by %v, want main.main", fn)
+	}
+}
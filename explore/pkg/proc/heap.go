@@ -0,0 +1,469 @@
+package proc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go/constant"
+	"sort"
+
+	"explore/pkg/dwarf/godwarf"
+)
+
+// HeapSpan is a span of the Go heap: a run of pages holding objects of a
+// single size, a large object or, for manually managed spans, goroutine
+// stacks.
+type HeapSpan struct {
+	// Base is the address of the first object of the span
+	Base uint64
+	// Limit is the end of the objects of the span
+	Limit uint64
+	// ElemSize is the size of the objects of the span
+	ElemSize uint64
+	// Manual is set for spans that do not hold heap objects, e.g. stacks
+	Manual bool
+
+	nelems    uint64
+	freeindex uint64
+	allocBits uint64
+	spanclass uint64
+	largeType uint64 // type of the object of large spans, 0 if not known
+}
+
+// Heap finds the spans and the objects of the Go heap of a process, from
+// the arenas of runtime.mheap_.
+type Heap struct {
+	bi  *BinaryInfo
+	mem MemoryReadWriter
+
+	arenas          uint64 // address of mheap_.arenas
+	arenasL1        uint64 // length of mheap_.arenas
+	arenaBaseOffset uint64
+	heapArenaBytes  uint64
+	pageSize        uint64
+	pagesPerArena   uint64
+	arenaL1Shift    uint64
+	arenaL2Bits     uint64
+	spanInUse       uint64
+	spanManual      uint64
+	mallocHeader    uint64 // objects larger than this start with their type
+
+	spansOff   uint64 // offset of heapArena.spans
+	spanType   *godwarf.StructType
+	spanFields map[string]*godwarf.StructField
+
+	spans map[uint64]*HeapSpan // by address of the mspan
+}
+
+var errHeapLayout = errors.New("unsupported layout of the Go heap")
+
+// tflagExtraStar is set in the tflag of runtime types whose name starts
+// with a * that is not part of it.
+const tflagExtraStar = 1 << 1
+
+// NewHeap reads the layout of the heap of the process.
+func NewHeap(bi *BinaryInfo, mem MemoryReadWriter) (*Heap, error) {
+	h := &Heap{bi: bi, mem: mem, spans: make(map[uint64]*HeapSpan)}
+
+	for _, c := range []struct {
+		name string
+		dst  *uint64
+	}{
+		{"arenaBaseOffsetUintptr", &h.arenaBaseOffset},
+		{"heapArenaBytes", &h.heapArenaBytes},
+		{"pageSize", &h.pageSize},
+		{"pagesPerArena", &h.pagesPerArena},
+		{"arenaL1Shift", &h.arenaL1Shift},
+		{"arenaL2Bits", &h.arenaL2Bits},
+		{"mSpanInUse", &h.spanInUse},
+		{"mSpanManual", &h.spanManual},
+	} {
+		v, err := findGlobal(bi, mem, "runtime", c.name)
+		if err != nil {
+			return nil, err
+		}
+		if v.Value == nil {
+			return nil, errHeapLayout
+		}
+		n, ok := constant.Uint64Val(v.Value)
+		if !ok {
+			i, _ := constant.Int64Val(v.Value)
+			n = uint64(i)
+		}
+		*c.dst = n
+	}
+	if h.heapArenaBytes == 0 || h.pageSize == 0 || h.pagesPerArena == 0 {
+		return nil, errHeapLayout
+	}
+
+	mheap, err := findGlobal(bi, mem, "runtime", "mheap_")
+	if err != nil {
+		return nil, err
+	}
+	arenas, err := mheap.structMember("arenas")
+	if err != nil {
+		return nil, err
+	}
+	h.arenas = arenas.Addr
+
+	// arenas is a [L1]*[L2]*heapArena
+	l1, ok := arenas.RealType.(*godwarf.ArrayType)
+	if !ok {
+		return nil, errHeapLayout
+	}
+	arena, ok := heapDeref(l1.Type).(*godwarf.ArrayType)
+	if !ok {
+		return nil, errHeapLayout
+	}
+	h.arenasL1 = uint64(l1.Count)
+	heapArena, ok := heapDeref(arena.Type).(*godwarf.StructType)
+	if !ok {
+		return nil, errHeapLayout
+	}
+	for _, f := range heapArena.Field {
+		if f.Name != "spans" {
+			continue
+		}
+		h.spansOff = uint64(f.ByteOffset)
+		spans, ok := godwarf.ResolveTypedef(f.Type).(*godwarf.ArrayType)
+		if !ok {
+			return nil, errHeapLayout
+		}
+		h.spanType, _ = heapDeref(spans.Type).(*godwarf.StructType)
+	}
+	if h.spanType == nil {
+		return nil, errHeapLayout
+	}
+
+	h.spanFields = make(map[string]*godwarf.StructField)
+	for _, f := range h.spanType.Field {
+		h.spanFields[f.Name] = f
+	}
+	for _, name := range []string{"startAddr", "npages", "nelems", "freeindex", "allocBits", "state", "elemsize", "limit", "spanclass"} {
+		if h.spanFields[name] == nil {
+			return nil, fmt.Errorf("%w: mspan.%s not found", errHeapLayout, name)
+		}
+	}
+
+	// the types of heap objects are only known with allocation headers
+	if h.spanFields["largeType"] != nil {
+		h.mallocHeader = uint64(bi.Arch.PtrSize()) * 8 * 8
+		if v, err := findGlobal(bi, mem, "runtime", "minSizeForMallocHeader"); err == nil && v.Value != nil {
+			h.mallocHeader, _ = constant.Uint64Val(constant.ToInt(v.Value))
+		}
+	}
+	return h, nil
+}
+
+// heapDeref returns the type pointed to by the pointer type typ.
+func heapDeref(typ godwarf.Type) godwarf.Type {
+	ptr, ok := godwarf.ResolveTypedef(typ).(*godwarf.PtrType)
+	if !ok {
+		return nil
+	}
+	return godwarf.ResolveTypedef(ptr.Type)
+}
+
+// SpanOf returns the span in use containing addr, nil if addr is not in
+// the Go heap.
+func (h *Heap) SpanOf(addr uint64) *HeapSpan {
+	ha := h.arenaOf(addr)
+	if ha == 0 {
+		return nil
+	}
+	ptrSize := uint64(h.bi.Arch.PtrSize())
+	page := (addr / h.pageSize) % h.pagesPerArena
+	spanAddr, err := readUintRaw(h.mem, ha+h.spansOff+page*ptrSize, int64(ptrSize))
+	if err != nil || spanAddr == 0 {
+		return nil
+	}
+
+	s := h.readSpan(spanAddr)
+	if s == nil || addr < s.Base || addr >= s.Limit {
+		return nil
+	}
+	return s
+}
+
+// Contains reports whether addr is in an arena of the Go heap, the memory
+// of the arena not in use by any span included.
+func (h *Heap) Contains(addr uint64) bool {
+	return h.arenaOf(addr) != 0
+}
+
+// arenaOf returns the address of the heapArena of addr, 0 if addr is not
+// in the Go heap.
+func (h *Heap) arenaOf(addr uint64) uint64 {
+	ptrSize := int64(h.bi.Arch.PtrSize())
+	ri := (addr - h.arenaBaseOffset) / h.heapArenaBytes
+	l1 := ri >> h.arenaL1Shift
+	l2 := ri & (1<<h.arenaL2Bits - 1)
+	if l1 >= h.arenasL1 {
+		return 0
+	}
+
+	arena, err := readUintRaw(h.mem, h.arenas+l1*uint64(ptrSize), ptrSize)
+	if err != nil || arena == 0 {
+		return 0
+	}
+	ha, err := readUintRaw(h.mem, arena+l2*uint64(ptrSize), ptrSize)
+	if err != nil {
+		return 0
+	}
+	return ha
+}
+
+// readSpan reads the mspan at addr, nil if it is not in use.
+func (h *Heap) readSpan(addr uint64) *HeapSpan {
+	if s, ok := h.spans[addr]; ok {
+		return s
+	}
+
+	buf := make([]byte, h.spanType.ByteSize)
+	if _, err := h.mem.ReadMemory(buf, addr); err != nil {
+		h.spans[addr] = nil
+		return nil
+	}
+	field := func(name string) uint64 {
+		f := h.spanFields[name]
+		b := buf[f.ByteOffset : f.ByteOffset+f.Type.Size()]
+		switch len(b) {
+		case 1:
+			return uint64(b[0])
+		case 2:
+			return uint64(binary.LittleEndian.Uint16(b))
+		case 4:
+			return uint64(binary.LittleEndian.Uint32(b))
+		}
+		return binary.LittleEndian.Uint64(b)
+	}
+
+	var s *HeapSpan
+	if state := field("state"); state == h.spanInUse || state == h.spanManual {
+		s = &HeapSpan{
+			Base:      field("startAddr"),
+			Limit:     field("limit"),
+			ElemSize:  field("elemsize"),
+			Manual:    state == h.spanManual,
+			nelems:    field("nelems"),
+			freeindex: field("freeindex"),
+			allocBits: field("allocBits"),
+			spanclass: field("spanclass"),
+		}
+		if h.spanFields["largeType"] != nil {
+			s.largeType = field("largeType")
+		}
+		if s.Manual || s.Limit == 0 {
+			// the limit of manual spans is not maintained
+			s.Limit = s.Base + field("npages")*h.pageSize
+		}
+	}
+	h.spans[addr] = s
+	return s
+}
+
+// ObjectOf returns the address and the size of the allocated heap object
+// containing addr.
+func (h *Heap) ObjectOf(addr uint64) (base, size uint64, ok bool) {
+	s := h.SpanOf(addr)
+	if s == nil || s.Manual || s.ElemSize == 0 {
+		return 0, 0, false
+	}
+	idx := (addr - s.Base) / s.ElemSize
+	if idx >= s.nelems || !s.allocated(h.mem, idx) {
+		return 0, 0, false
+	}
+	return s.Base + idx*s.ElemSize, s.ElemSize, true
+}
+
+// NoScan reports whether the objects of s hold no pointers.
+func (s *HeapSpan) NoScan() bool {
+	return s.spanclass&1 != 0
+}
+
+// allocated reports whether the object with index idx of s is allocated:
+// objects before freeindex are, the others are if their bit is set in the
+// allocation bitmap of the last garbage collection.
+func (s *HeapSpan) allocated(mem MemoryReader, idx uint64) bool {
+	if idx < s.freeindex {
+		return true
+	}
+	if s.allocBits == 0 {
+		return false
+	}
+	var b [1]byte
+	if _, err := mem.ReadMemory(b[:], s.allocBits+idx/8); err != nil {
+		return false
+	}
+	return b[0]&(1<<(idx%8)) != 0
+}
+
+// HeapObjects are the allocated objects of a type, or of a size class when
+// their type is not known.
+type HeapObjects struct {
+	// Type is the type of the objects, []T for arrays of T, empty if it is
+	// not known
+	Type string
+	// Size is the size of the objects of a size class of unknown type
+	Size uint64
+	// NoScan is set for objects without pointers
+	NoScan  bool
+	Objects uint64
+	Bytes   uint64
+}
+
+// Inventory counts the allocated objects of the heap, which includes the
+// garbage not swept yet, by type. The type of an object is known if it
+// is large or starts with an allocation header, which small objects
+// without pointers and of at most 512 bytes don't.
+func (h *Heap) Inventory() ([]HeapObjects, error) {
+	mheap, err := findGlobal(h.bi, h.mem, "runtime", "mheap_")
+	if err != nil {
+		return nil, err
+	}
+	allspans, err := mheap.structMember("allspans")
+	if err != nil {
+		return nil, err
+	}
+	allspans.loadValue(LoadConfig{})
+	if allspans.Unreadable != nil {
+		return nil, allspans.Unreadable
+	}
+
+	ptrSize := uint64(h.bi.Arch.PtrSize())
+	spans := make([]byte, uint64(allspans.Len)*ptrSize)
+	if _, err := h.mem.ReadMemory(spans, allspans.Base); err != nil {
+		return nil, err
+	}
+
+	types := newHeapTypes(h.bi, h.mem)
+	byKey := make(map[HeapObjects]*HeapObjects)
+	count := func(key HeapObjects, size uint64) {
+		o := byKey[key]
+		if o == nil {
+			o = &key
+			byKey[key] = o
+		}
+		o.Objects++
+		o.Bytes += size
+	}
+
+	for i := uint64(0); i < uint64(allspans.Len); i++ {
+		s := h.readSpan(binary.LittleEndian.Uint64(spans[i*ptrSize:]))
+		if s == nil || s.Manual || s.ElemSize == 0 {
+			continue
+		}
+		bits := make([]byte, (s.nelems+7)/8)
+		if s.allocBits != 0 {
+			h.mem.ReadMemory(bits, s.allocBits)
+		}
+		large := s.spanclass>>1 == 0
+		for idx := uint64(0); idx < s.nelems; idx++ {
+			if idx >= s.freeindex && bits[idx/8]&(1<<(idx%8)) == 0 {
+				continue
+			}
+			var key HeapObjects
+			switch {
+			case s.NoScan():
+			case large && s.largeType != 0:
+				key = HeapObjects{Type: types.name(s.largeType, s.ElemSize)}
+			case !large && h.mallocHeader != 0 && s.ElemSize > h.mallocHeader:
+				typ, err := readUintRaw(h.mem, s.Base+idx*s.ElemSize, int64(ptrSize))
+				if err == nil && typ != 0 {
+					key = HeapObjects{Type: types.name(typ, s.ElemSize-ptrSize)}
+				}
+			}
+			if key.Type == "" {
+				key = HeapObjects{Size: s.ElemSize, NoScan: s.NoScan()}
+			}
+			count(key, s.ElemSize)
+		}
+	}
+
+	r := make([]HeapObjects, 0, len(byKey))
+	for _, o := range byKey {
+		r = append(r, *o)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Bytes != r[j].Bytes {
+			return r[i].Bytes > r[j].Bytes
+		}
+		return r[i].Type < r[j].Type
+	})
+	return r, nil
+}
+
+// heapTypes names the runtime types of heap objects.
+type heapTypes struct {
+	bi    *BinaryInfo
+	mem   MemoryReadWriter
+	mds   []ModuleData
+	rtyp  godwarf.Type
+	names map[uint64]heapType // by address of the runtime type
+}
+
+type heapType struct {
+	name string
+	size int64
+}
+
+func newHeapTypes(bi *BinaryInfo, mem MemoryReadWriter) *heapTypes {
+	t := &heapTypes{bi: bi, mem: mem, names: make(map[uint64]heapType)}
+	t.mds, _ = LoadModuleData(bi, mem)
+	t.rtyp, _ = bi.findType(bi.runtimeTypeTypename())
+	return t
+}
+
+// name returns the name of the type at addr for an object of size bytes,
+// []T for arrays of T and empty if the type is not known.
+func (t *heapTypes) name(addr, size uint64) string {
+	ht, ok := t.names[addr]
+	if !ok {
+		if t.rtyp != nil && t.mds != nil {
+			_type := newVariable("", addr, t.rtyp, t.bi, t.mem)
+			if typ, _, err := RuntimeTypeToDIE(_type, 0, t.mds); err == nil {
+				ht = heapType{name: typeName(typ), size: typ.Size()}
+			} else {
+				ht = t.runtimeName(_type)
+			}
+		}
+		t.names[addr] = ht
+	}
+	if ht.name != "" && ht.size > 0 && size >= 2*uint64(ht.size) {
+		return "[]" + ht.name
+	}
+	return ht.name
+}
+
+// runtimeName reads the name and the size of the runtime type _type, for
+// the types without debug information.
+func (t *heapTypes) runtimeName(_type *Variable) heapType {
+	md := findModuleDataForType(t.mds, _type.Addr)
+	str, tflag, size := _type.loadFieldNamed("Str"), _type.loadFieldNamed("TFlag"), _type.loadFieldNamed("Size_")
+	if md == nil || str == nil || str.Value == nil || tflag == nil || tflag.Value == nil || size == nil || size.Value == nil {
+		return heapType{}
+	}
+	off, _ := constant.Int64Val(str.Value)
+	flags, _ := constant.Uint64Val(tflag.Value)
+	n, _ := constant.Int64Val(constant.ToInt(size.Value))
+
+	// a name is a byte of flags, the varint length of the name and the
+	// name
+	var buf [1 + binary.MaxVarintLen64]byte
+	addr := md.types + uint64(off)
+	if _, err := t.mem.ReadMemory(buf[:], addr); err != nil {
+		return heapType{}
+	}
+	l, k := binary.Uvarint(buf[1:])
+	if k <= 0 || l > 1<<12 {
+		return heapType{}
+	}
+	name := make([]byte, l)
+	if _, err := t.mem.ReadMemory(name, addr+1+uint64(k)); err != nil {
+		return heapType{}
+	}
+	if flags&tflagExtraStar != 0 && len(name) > 0 && name[0] == '*' {
+		name = name[1:]
+	}
+	return heapType{name: string(name), size: n}
+}
@@ -0,0 +1,159 @@
+package proc
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"explore/pkg/dwarf/godwarf"
+)
+
+// testHeap returns a heap of a single arena of 4 pages at 0x10000: a span
+// of 16 objects of 256 bytes on the first page, none on the second one, a
+// stack on the third one and a free span on the last one.
+func testHeap() *Heap {
+	u64 := &godwarf.UintType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{ByteSize: 8, Name: "uintptr"}}}
+	u8 := &godwarf.UintType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{ByteSize: 1, Name: "uint8"}}}
+	span := &godwarf.StructType{CommonType: godwarf.CommonType{ByteSize: 64, Name: "runtime.mspan"}, StructName: "runtime.mspan", Kind: "struct"}
+	for i, name := range []string{"startAddr", "npages", "nelems", "freeindex", "allocBits", "elemsize", "limit"} {
+		span.Field = append(span.Field, &godwarf.StructField{Name: name, Type: u64, ByteOffset: int64(i * 8)})
+	}
+	span.Field = append(span.Field,
+		&godwarf.StructField{Name: "spanclass", Type: u8, ByteOffset: 56},
+		&godwarf.StructField{Name: "state", Type: u8, ByteOffset: 57})
+
+	mem := &bufMemory{base: 0x1000, buf: make([]byte, 0x1000)}
+	put := func(addr, v uint64) { binary.LittleEndian.PutUint64(mem.buf[addr-mem.base:], v) }
+	putSpan := func(addr, start, npages, nelems, freeindex, allocBits, elemsize, limit uint64, spanclass, state byte) {
+		for i, v := range []uint64{start, npages, nelems, freeindex, allocBits, elemsize, limit} {
+			put(addr+uint64(i*8), v)
+		}
+		mem.buf[addr+56-mem.base] = spanclass
+		mem.buf[addr+57-mem.base] = state
+	}
+	// arenas[0] -> [2]*heapArena at 0x1100, whose first one is at 0x1200
+	put(0x1000, 0x1100)
+	put(0x1100, 0x1200)
+	// the spans of the pages of the arena
+	put(0x1200, 0x1400)
+	put(0x1210, 0x1500)
+	put(0x1218, 0x1600)
+	putSpan(0x1400, 0x10000, 1, 16, 4, 0x1800, 0x100, 0x11000, 5<<1, 1)
+	putSpan(0x1500, 0x12000, 1, 0, 0, 0, 0, 0, 0, 2)
+	putSpan(0x1600, 0x13000, 1, 16, 0, 0, 0x100, 0x14000, 5<<1, 0)
+	// objects 6 and 9 were allocated after freeindex
+	mem.buf[0x1800-mem.base] = 1 << 6
+	mem.buf[0x1801-mem.base] = 1 << (9 - 8)
+
+	h := &Heap{
+		bi:              NewBinaryInfo("linux", "amd64"),
+		mem:             mem,
+		arenas:          0x1000,
+		arenasL1:        1,
+		arenaBaseOffset: 0x10000,
+		heapArenaBytes:  0x4000,
+		pageSize:        0x1000,
+		pagesPerArena:   4,
+		arenaL1Shift:    1,
+		arenaL2Bits:     1,
+		spanInUse:       1,
+		spanManual:      2,
+		spanType:        span,
+		spanFields:      make(map[string]*godwarf.StructField),
+		spans:           make(map[uint64]*HeapSpan),
+	}
+	for _, f := range span.Field {
+		h.spanFields[f.Name] = f
+	}
+	return h
+}
+
+func TestHeapSpanOf(t *testing.T) {
+	h := testHeap()
+
+	s := h.SpanOf(0x10050)
+	if s == nil || s.Base != 0x10000 || s.Limit != 0x11000 || s.ElemSize != 0x100 || s.Manual || s.NoScan() {
+		t.Errorf("span of 0x10050: %+v", s)
+	}
+	if s := h.SpanOf(0x12010); s == nil || !s.Manual || s.Limit != 0x13000 {
+		t.Errorf("span of the stack: %+v", s)
+	}
+	for _, addr := range []uint64{0x11000, 0x13000, 0x14000, 0x8000} {
+		if s := h.SpanOf(addr); s != nil {
+			t.Errorf("span of %#x: %+v, want none", addr, s)
+		}
+	}
+
+	if !h.Contains(0x11000) {
+		t.Error("a page without span of the arena is not in the heap")
+	}
+	if h.Contains(0x14000) || h.Contains(0x8000) {
+		t.Error("an address out of the arena is in the heap")
+	}
+}
+
+func TestHeapObjectOf(t *testing.T) {
+	h := testHeap()
+	for _, test := range []struct {
+		addr uint64
+		base uint64
+		ok   bool
+	}{
+		{0x10000, 0x10000, true},
+		{0x10150, 0x10100, true},
+		{0x103ff, 0x10300, true},
+		// after freeindex, allocated if their bit is set
+		{0x10400, 0, false},
+		{0x10620, 0x10600, true},
+		{0x10900, 0x10900, true},
+		{0x10a00, 0, false},
+		{0x10ff8, 0, false},
+		// stacks, free spans and pages without span hold no objects
+		{0x12010, 0, false},
+		{0x13000, 0, false},
+		{0x11000, 0, false},
+	} {
+		base, size, ok := h.ObjectOf(test.addr)
+		if ok != test.ok || base != test.base || (ok && size != 0x100) {
+			t.Errorf("ObjectOf(%#x) = %#x, %d, %v, want %#x, %v", test.addr, base, size, ok, test.base, test.ok)
+		}
+	}
+}
+
+func TestHeapInventory(t *testing.T) {
+	scope := fixtureScope(t)
+	h, err := NewHeap(scope.BinInfo, scope.Mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points, err := scope.EvalExpression("main.points", loadFullValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the backing array starts with its type
+	if base, size, ok := h.ObjectOf(points.Base); !ok || base+8 != points.Base || size < 100*8 {
+		t.Errorf("ObjectOf(%#x) = %#x, %d, %v", points.Base, base, size, ok)
+	}
+	// package variables initialized by the linker are not in the heap
+	cfg, err := scope.EvalExpression("main.cfg", loadFullValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := h.ObjectOf(cfg.Children[0].Addr); ok {
+		t.Errorf("main.cfg at %#x in the heap", cfg.Children[0].Addr)
+	}
+
+	objs, err := h.Inventory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, o := range objs {
+		if o.Type == "[]*main.Point" {
+			found = o.Objects >= 1 && o.Bytes >= 100*8
+		}
+	}
+	if !found {
+		t.Errorf("the backing array of main.points not found in %+v", objs)
+	}
+}
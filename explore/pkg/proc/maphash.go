@@ -0,0 +1,392 @@
+package proc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"reflect"
+)
+
+// Software implementation of the hash functions used by the runtime for map
+// keys, derived from $GOROOT/src/internal/runtime/maps/memhash_amd64.s,
+// $GOROOT/src/internal/runtime/maps/runtime_hash64.go and
+// $GOROOT/src/runtime/alg.go.
+// The hash of a key is needed to know where a new key goes in a map, it
+// depends on per-process seeds that are read from the target.
+
+const (
+	mapHashAESKeySchedSize = 128
+
+	// +rtype go1.24 internal/runtime/maps.m5
+	mapHashM5 = 0x1d8e4e27c47d124f
+
+	// c0 and c1 of runtime/alg.go, for 64bit architectures
+	mapHashC0 = 33054211828000289
+	mapHashC1 = 23344194077549503
+)
+
+var errMapHashNaN = errors.New("can not insert NaN keys")
+
+// mapHasher computes the hash of map keys as the runtime of the target does.
+type mapHasher struct {
+	useAES      bool
+	aeskeysched [mapHashAESKeySchedSize]byte
+	hashkey     [4]uint64
+}
+
+// mapHashSeedVars lists the variables that hold the per-process hash seeds,
+// in the packages they are defined in for the different versions of Go.
+var mapHashSeedVars = []struct{ pkg, useAES, keysched, hashkey string }{
+	{"internal/runtime/maps", "UseAeshash", "aeskeysched", "hashkey"}, // Go 1.27 and later
+	{"runtime", "useAeshash", "aeskeysched", "hashkey"},
+}
+
+// newMapHasher reads the hash seeds of the target.
+func newMapHasher(bi *BinaryInfo, mem MemoryReadWriter) (*mapHasher, error) {
+	if bi.Arch.Name != "amd64" || bi.Arch.PtrSize() != 8 {
+		return nil, fmt.Errorf("hashing map keys is not supported on %s", bi.Arch.Name)
+	}
+
+	for _, vars := range mapHashSeedVars {
+		useAES, err := findGlobal(bi, mem, vars.pkg, vars.useAES)
+		if err != nil {
+			continue
+		}
+		useAES.loadValue(loadSingleValue)
+		if useAES.Unreadable != nil {
+			return nil, useAES.Unreadable
+		}
+
+		h := &mapHasher{}
+		h.useAES = useAES.Value != nil && useAES.Value.String() == "true"
+		if h.useAES {
+			keysched, err := findGlobal(bi, mem, vars.pkg, vars.keysched)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := mem.ReadMemory(h.aeskeysched[:], keysched.Addr); err != nil {
+				return nil, err
+			}
+			return h, nil
+		}
+
+		hashkey, err := findGlobal(bi, mem, vars.pkg, vars.hashkey)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, len(h.hashkey)*8)
+		if _, err := mem.ReadMemory(buf, hashkey.Addr); err != nil {
+			return nil, err
+		}
+		for i := range h.hashkey {
+			h.hashkey[i] = binary.LittleEndian.Uint64(buf[i*8:])
+		}
+		return h, nil
+	}
+
+	return nil, errors.New("could not find the map hash seeds of the target")
+}
+
+// hash returns the hash of a key of the given kind, whose in-memory
+// representation is data (the contents for strings), for a map with the
+// given seed.
+func (h *mapHasher) hash(kind reflect.Kind, data []byte, seed uint64) (uint64, error) {
+	switch kind {
+	case reflect.String:
+		return h.memhash(data, seed), nil
+	case reflect.Float32, reflect.Float64:
+		return h.fhash(data, seed)
+	case reflect.Complex64, reflect.Complex128:
+		seed, err := h.fhash(data[:len(data)/2], seed)
+		if err != nil {
+			return 0, err
+		}
+		return h.fhash(data[len(data)/2:], seed)
+	}
+
+	switch len(data) {
+	case 4:
+		return h.memhash32(binary.LittleEndian.Uint32(data), seed), nil
+	case 8:
+		return h.memhash64(binary.LittleEndian.Uint64(data), seed), nil
+	}
+	return h.memhash(data, seed), nil
+}
+
+// fhash is f32hash and f64hash.
+func (h *mapHasher) fhash(data []byte, seed uint64) (uint64, error) {
+	var f float64
+	if len(data) == 4 {
+		f = float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	} else {
+		f = math.Float64frombits(binary.LittleEndian.Uint64(data))
+	}
+	switch {
+	case f == 0:
+		return mapHashC1 * (mapHashC0 ^ seed), nil
+	case f != f:
+		// the runtime hashes NaNs to a random value
+		return 0, errMapHashNaN
+	}
+	return h.memhash(data, seed), nil
+}
+
+func (h *mapHasher) memhash32(k uint32, seed uint64) uint64 {
+	if !h.useAES {
+		a := uint64(k)
+		return mapHashMix(mapHashM5^4, mapHashMix(a^h.hashkey[1], a^seed^h.hashkey[0]))
+	}
+	var x aesBlock
+	binary.LittleEndian.PutUint64(x[:], seed)
+	binary.LittleEndian.PutUint32(x[8:], k)
+	return h.scramble64(x)
+}
+
+func (h *mapHasher) memhash64(k uint64, seed uint64) uint64 {
+	if !h.useAES {
+		return mapHashMix(mapHashM5^8, mapHashMix(k^h.hashkey[1], k^seed^h.hashkey[0]))
+	}
+	var x aesBlock
+	binary.LittleEndian.PutUint64(x[:], seed)
+	binary.LittleEndian.PutUint64(x[8:], k)
+	return h.scramble64(x)
+}
+
+// scramble64 is the tail of memHash32AES and memHash64AES.
+func (h *mapHasher) scramble64(x aesBlock) uint64 {
+	x = aesenc(x, h.keysched(0))
+	x = aesenc(x, h.keysched(1))
+	x = aesenc(x, h.keysched(2))
+	return x.lo()
+}
+
+func (h *mapHasher) keysched(i int) aesBlock {
+	var k aesBlock
+	copy(k[:], h.aeskeysched[i*16:])
+	return k
+}
+
+func (h *mapHasher) memhash(p []byte, seed uint64) uint64 {
+	if !h.useAES {
+		return h.memhashFallback(p, seed)
+	}
+
+	s := len(p)
+
+	// 64 bits of per-table hash seed and 16 bits of length, repeated 4 times.
+	var seed0 aesBlock
+	binary.LittleEndian.PutUint64(seed0[:], seed)
+	for i := 8; i < 16; i += 2 {
+		binary.LittleEndian.PutUint16(seed0[i:], uint16(s))
+	}
+	x0 := seed0.xor(h.keysched(0))
+	x0 = aesenc(x0, x0)
+
+	// seeds returns x0 followed by n-1 more starting seeds.
+	seeds := func(n int) []aesBlock {
+		r := []aesBlock{x0}
+		for i := 1; i < n; i++ {
+			x := seed0.xor(h.keysched(i))
+			r = append(r, aesenc(x, x))
+		}
+		return r
+	}
+
+	// scramble xors each block with its seed and scrambles it three times.
+	scramble := func(blocks, seeds []aesBlock) {
+		for i := range blocks {
+			blocks[i] = blocks[i].xor(seeds[i])
+		}
+		for range 3 {
+			for i := range blocks {
+				blocks[i] = aesenc(blocks[i], blocks[i])
+			}
+		}
+	}
+
+	block := func(off int) aesBlock {
+		var b aesBlock
+		copy(b[:], p[off:])
+		return b
+	}
+
+	// combine xors the second half of blocks into the first one until there
+	// is only one left.
+	combine := func(blocks []aesBlock) uint64 {
+		for n := len(blocks) / 2; n > 0; n /= 2 {
+			for i := 0; i < n; i++ {
+				blocks[i] = blocks[i].xor(blocks[i+n])
+			}
+		}
+		return blocks[0].lo()
+	}
+
+	switch {
+	case s == 0:
+		return aesenc(x0, x0).lo()
+	case s <= 16:
+		// shorter data is zero padded, as the masks do
+		blocks := []aesBlock{block(0)}
+		scramble(blocks, seeds(1))
+		return blocks[0].lo()
+	case s <= 32:
+		blocks := []aesBlock{block(0), block(s - 16)}
+		scramble(blocks, seeds(2))
+		return combine(blocks)
+	case s <= 64:
+		blocks := []aesBlock{block(0), block(16), block(s - 32), block(s - 16)}
+		scramble(blocks, seeds(4))
+		return combine(blocks)
+	case s <= 128:
+		blocks := []aesBlock{block(0), block(16), block(32), block(48), block(s - 64), block(s - 48), block(s - 32), block(s - 16)}
+		scramble(blocks, seeds(8))
+		return combine(blocks)
+	}
+
+	// start with the last (possibly overlapping) 128 bytes
+	blocks := make([]aesBlock, 8)
+	for i := range blocks {
+		blocks[i] = block(s - 128 + 16*i)
+	}
+	sd := seeds(8)
+	for i := range blocks {
+		blocks[i] = blocks[i].xor(sd[i])
+	}
+	for off, n := 0, (s-1)>>7; n > 0; off, n = off+128, n-1 {
+		for i := range blocks {
+			blocks[i] = aesenc(blocks[i], blocks[i])
+		}
+		for i := range blocks {
+			blocks[i] = aesenc(blocks[i], block(off+16*i))
+		}
+	}
+	for range 3 {
+		for i := range blocks {
+			blocks[i] = aesenc(blocks[i], blocks[i])
+		}
+	}
+	return combine(blocks)
+}
+
+func (h *mapHasher) memhashFallback(p []byte, seed uint64) uint64 {
+	r4 := func(off int) uint64 { return uint64(binary.LittleEndian.Uint32(p[off:])) }
+	r8 := func(off int) uint64 { return binary.LittleEndian.Uint64(p[off:]) }
+
+	var a, b uint64
+	s := len(p)
+	seed ^= h.hashkey[0]
+	switch {
+	case s == 0:
+		return seed
+	case s < 4:
+		a = uint64(p[0])
+		a |= uint64(p[s>>1]) << 8
+		a |= uint64(p[s-1]) << 16
+	case s == 4:
+		a = r4(0)
+		b = a
+	case s < 8:
+		a = r4(0)
+		b = r4(s - 4)
+	case s == 8:
+		a = r8(0)
+		b = a
+	case s <= 16:
+		a = r8(0)
+		b = r8(s - 8)
+	default:
+		l, off := s, 0
+		if l > 48 {
+			seed1 := seed
+			seed2 := seed
+			for ; l > 48; l -= 48 {
+				seed = mapHashMix(r8(off)^h.hashkey[1], r8(off+8)^seed)
+				seed1 = mapHashMix(r8(off+16)^h.hashkey[2], r8(off+24)^seed1)
+				seed2 = mapHashMix(r8(off+32)^h.hashkey[3], r8(off+40)^seed2)
+				off += 48
+			}
+			seed ^= seed1 ^ seed2
+		}
+		for ; l > 16; l -= 16 {
+			seed = mapHashMix(r8(off)^h.hashkey[1], r8(off+8)^seed)
+			off += 16
+		}
+		a = r8(off + l - 16)
+		b = r8(off + l - 8)
+	}
+
+	return mapHashMix(mapHashM5^uint64(s), mapHashMix(a^h.hashkey[1], b^seed))
+}
+
+func mapHashMix(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return hi ^ lo
+}
+
+// aesBlock is the content of a XMM register.
+type aesBlock [16]byte
+
+func (x aesBlock) xor(y aesBlock) aesBlock {
+	for i := range x {
+		x[i] ^= y[i]
+	}
+	return x
+}
+
+func (x aesBlock) lo() uint64 {
+	return binary.LittleEndian.Uint64(x[:])
+}
+
+// aesenc is the AESENC instruction: one round of AES encryption of state
+// with the round key rk.
+func aesenc(state, rk aesBlock) aesBlock {
+	var t aesBlock
+	// ShiftRows and SubBytes, byte r+4c is row r of column c.
+	for c := 0; c < 4; c++ {
+		for r := 0; r < 4; r++ {
+			t[r+4*c] = aesSbox[state[r+4*((c+r)%4)]]
+		}
+	}
+	// MixColumns
+	var out aesBlock
+	for c := 0; c < 4; c++ {
+		a0, a1, a2, a3 := t[4*c], t[4*c+1], t[4*c+2], t[4*c+3]
+		out[4*c] = aesMul2(a0) ^ aesMul2(a1) ^ a1 ^ a2 ^ a3
+		out[4*c+1] = a0 ^ aesMul2(a1) ^ aesMul2(a2) ^ a2 ^ a3
+		out[4*c+2] = a0 ^ a1 ^ aesMul2(a2) ^ aesMul2(a3) ^ a3
+		out[4*c+3] = aesMul2(a0) ^ a0 ^ a1 ^ a2 ^ aesMul2(a3)
+	}
+	return out.xor(rk)
+}
+
+// aesMul2 multiplies b by x in GF(2^8).
+func aesMul2(b byte) byte {
+	r := b << 1
+	if b&0x80 != 0 {
+		r ^= 0x1b
+	}
+	return r
+}
+
+var aesSbox = func() (sbox [256]byte) {
+	// multiplicative inverse followed by the affine transformation
+	p, q := byte(1), byte(1)
+	for {
+		// p = p * 3, q = q / 3
+		p = p ^ aesMul2(p)
+		q ^= q << 1
+		q ^= q << 2
+		q ^= q << 4
+		if q&0x80 != 0 {
+			q ^= 0x09
+		}
+		sbox[p] = q ^ bits.RotateLeft8(q, 1) ^ bits.RotateLeft8(q, 2) ^ bits.RotateLeft8(q, 3) ^ bits.RotateLeft8(q, 4) ^ 0x63
+		if p == 1 {
+			break
+		}
+	}
+	sbox[0] = 0x63
+	return sbox
+}()
@@ -0,0 +1,75 @@
+package proc
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestAesenc(t *testing.T) {
+	// AESENC example of the Intel AES-NI white paper, registers are written
+	// most significant byte first.
+	xmm := func(s string) aesBlock {
+		b, _ := hex.DecodeString(s)
+		slices.Reverse(b)
+		return aesBlock(b)
+	}
+	got := aesenc(xmm("7b5b54657374566563746f725d53475d"), xmm("48692853686179295b477565726f6e5d"))
+	if want := xmm("a8311c2f9fdba3c58b104b58ded7e595"); got != want {
+		t.Errorf("aesenc = %x, want %x", got, want)
+	}
+}
+
+func TestMapHash(t *testing.T) {
+	// Hashes computed by the runtime of go1.27, with and without AES
+	// instructions (GODEBUG=cpu.aes=off), of the strings made of the bytes
+	// 0, 1, 2, ... and of k as a 64 and 32 bit integer.
+	const seed = 0x0123456789abcdef
+	const k = 0xfeedfacecafebeef
+
+	aes := &mapHasher{useAES: true}
+	ks, _ := hex.DecodeString("b0399d12ab704abf857b724a59db00bb6aa3586e22359727ec61352dfc68fcffca0c96da4b77387519c53afcb03ca878820cdab6d718c847c0c7a1be64d23520b8c127b5c70a01b44be7ad8d9893d6ae8ba11ce978ea3fa095a204738415f74fff9bcf13b0f1f82bc72d1122bf82eea2d77085ee69947058df015bc5b7f5dc2f")
+	copy(aes.aeskeysched[:], ks)
+	fallback := &mapHasher{hashkey: [4]uint64{0xd4d9567773a207f2, 0x6aa51478c22783c, 0x4c142b9207c9bb81, 0x27277d7bc01fbd2d}}
+
+	tests := []struct {
+		kind          reflect.Kind
+		len           int
+		aes, fallback uint64
+	}{
+		{reflect.String, 0, 0x35b8ed1238a044bc, 0xd5fa1310fa09ca1d},
+		{reflect.String, 3, 0x427080961a98c0bf, 0x4de7ee378674e771},
+		{reflect.String, 16, 0x0f5ac11305d09a60, 0x71c1fb2023524dda},
+		{reflect.String, 17, 0x00e37a4185e5e64b, 0x2fb11f92eb0b12b5},
+		{reflect.String, 33, 0xa3327b9532c1d401, 0x206a7238afa24a45},
+		{reflect.String, 65, 0x9eb15a083c676eda, 0xb0c3da41f9dade8d},
+		{reflect.String, 200, 0x71e773fe4de95a58, 0x5c93189c4be29603},
+		{reflect.Uint, 8, 0xec6b88ee629e27c5, 0xeebec39f15eeaa2d},
+		{reflect.Uint, 4, 0x1cecf140ced441f4, 0x3993ad6f114bdce5},
+	}
+	for _, tc := range tests {
+		data := make([]byte, tc.len)
+		if tc.kind == reflect.String {
+			for i := range data {
+				data[i] = byte(i)
+			}
+		} else {
+			buf := binary.LittleEndian.AppendUint64(nil, k)
+			copy(data, buf)
+		}
+		for _, h := range []struct {
+			hasher *mapHasher
+			want   uint64
+		}{{aes, tc.aes}, {fallback, tc.fallback}} {
+			got, err := h.hasher.hash(tc.kind, data, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != h.want {
+				t.Errorf("hash(%v, %d bytes, aes=%v) = %#x, want %#x", tc.kind, tc.len, h.hasher.useAES, got, h.want)
+			}
+		}
+	}
+}
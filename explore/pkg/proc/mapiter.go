@@ -13,8 +13,14 @@ type mapIterator interface {
 	next() bool
 	key() *Variable
 	value() *Variable
+	// cursor returns the position after the current entry
+	cursor() *MapCursor
+	// seek moves the iterator to a position returned by cursor
+	seek(c *MapCursor) error
 }
 
+var errMapCursor = errors.New("map cursor out of range, the map might have grown")
+
 func (v *Variable) mapIterator(maxNumBuckets uint64) mapIterator {
 	mt := v.RealType.(*godwarf.MapType)
 	sv := v.clone()
@@ -33,8 +39,8 @@ func (v *Variable) mapIterator(maxNumBuckets uint64) mapIterator {
 		return isptr
 	}
 
-	it := &mapIteratorClassic{v: v, bidx: 0, b: nil, idx: 0, maxNumBuckets: maxNumBuckets, keyTypeIsPtr: isptr(mt.KeyType), elemTypeIsPtr: isptr(mt.ElemType)}
-	itswiss := &mapIteratorSwiss{v: v, maxNumGroups: maxNumBuckets, keyTypeIsPtr: isptr(mt.KeyType), elemTypeIsPtr: isptr(mt.ElemType)}
+	it := &mapIteratorClassic{v: v, hdr: sv, bidx: 0, b: nil, idx: 0, maxNumBuckets: maxNumBuckets, keyTypeIsPtr: isptr(mt.KeyType), elemTypeIsPtr: isptr(mt.ElemType)}
+	itswiss := &mapIteratorSwiss{v: v, hdr: sv, maxNumGroups: maxNumBuckets, keyTypeIsPtr: isptr(mt.KeyType), elemTypeIsPtr: isptr(mt.ElemType)}
 
 	if sv.Addr == 0 {
 		it.numbuckets = 0
@@ -100,6 +106,7 @@ func (v *Variable) mapIterator(maxNumBuckets uint64) mapIterator {
 
 type mapIteratorClassic struct {
 	v          *Variable
+	hdr        *Variable // the hmap struct
 	numbuckets uint64
 	oldmask    uint64
 	buckets    *Variable
@@ -177,6 +184,11 @@ func (it *mapIteratorClassic) nextBucket() bool {
 		it.bidx++
 	}
 
+	return it.loadBucket()
+}
+
+// loadBucket reads the tophashes, keys, values and overflow fields of it.b.
+func (it *mapIteratorClassic) loadBucket() bool {
 	if it.b.Addr <= 0 {
 		return false
 	}
@@ -285,6 +297,41 @@ func (it *mapIteratorClassic) value() *Variable {
 	return v
 }
 
+func (it *mapIteratorClassic) cursor() *MapCursor {
+	c := &MapCursor{Bucket: it.bidx, Slot: it.idx}
+	if it.b != nil {
+		c.Addr = it.b.Addr
+	}
+	return c
+}
+
+func (it *mapIteratorClassic) seek(c *MapCursor) error {
+	if c.Table != 0 || c.Bucket > it.numbuckets {
+		return errMapCursor
+	}
+	it.bidx = c.Bucket
+	it.b = nil
+	it.overflow = nil
+	if c.Addr == 0 {
+		return nil
+	}
+	// the bucket can be an overflow bucket or an old bucket, all of them
+	// have the same type
+	it.b = it.buckets.clone()
+	it.b.Addr = c.Addr
+	if !it.loadBucket() {
+		if it.v.Unreadable == nil {
+			it.v.Unreadable = errMapCursor
+		}
+		return it.v.Unreadable
+	}
+	if c.Slot > it.tophashes.Len {
+		return errMapCursor
+	}
+	it.idx = c.Slot
+	return nil
+}
+
 func (it *mapIteratorClassic) mapEvacuated(b *Variable) bool {
 	if b.Addr == 0 {
 		return true
@@ -308,11 +355,14 @@ func (it *mapIteratorClassic) mapEvacuated(b *Variable) bool {
 // Swisstable Maps ///////////////////////////////////////////////////////////////
 
 const (
-	swissTableCtrlEmpty = 0b10000000 // +rtype go1.24 internal/runtime/maps.ctrlEmpty
+	swissTableCtrlEmpty   = 0b10000000 // +rtype go1.24 internal/runtime/maps.ctrlEmpty
+	swissTableCtrlDeleted = 0b11111110 // +rtype go1.24 internal/runtime/maps.ctrlDeleted
 )
 
 type mapIteratorSwiss struct {
 	v            *Variable
+	hdr          *Variable // the Map struct
+	small        bool      // the map has a single group and no tables
 	dirPtr       *Variable
 	dirLen       int64
 	maxNumGroups uint64 // Maximum number of groups we will visit
@@ -336,13 +386,15 @@ type mapIteratorSwiss struct {
 }
 
 type swissTable struct {
+	v      *Variable
 	index  int64
 	groups *Variable
 }
 
 type swissGroup struct {
-	slots *Variable
-	ctrls []byte
+	slots    *Variable
+	ctrls    []byte
+	ctrlAddr uint64
 }
 
 var errSwissTableCouldNotLoad = errors.New("could not load one of the tables")
@@ -438,6 +490,11 @@ func (it *mapIteratorSwiss) loadTypes() {
 		it.dirPtr.RealType = it.dirPtr.DwarfType
 		it.dirPtr = it.dirPtr.maybeDereference()
 		it.dirLen = 1
+		if it.dirPtr.Addr == 0 {
+			// the group is allocated on the first insertion
+			it.dirLen = 0
+		}
+		it.small = true
 		it.tab = &swissTable{groups: it.dirPtr} // so that we don't try to load this later on
 		return
 	}
@@ -542,7 +599,7 @@ func (it *mapIteratorSwiss) loadCurrentTable() {
 
 	tab = tab.maybeDereference()
 
-	r := &swissTable{}
+	r := &swissTable{v: tab}
 
 	field, _ := tab.toField(it.tableFieldIndex)
 	r.index, err = field.asInt()
@@ -580,6 +637,7 @@ func (it *mapIteratorSwiss) loadCurrentGroup() {
 	g.slots, _ = group.toField(it.groupFieldSlots)
 	ctrl, _ := group.toField(it.groupFieldCtrl)
 	g.ctrls = make([]byte, ctrl.DwarfType.Size())
+	g.ctrlAddr = ctrl.Addr
 	_, err = ctrl.mem.ReadMemory(g.ctrls, ctrl.Addr)
 	if err != nil {
 		it.v.Unreadable = err
@@ -596,6 +654,27 @@ func (it *mapIteratorSwiss) value() *Variable {
 	return it.curValue
 }
 
+func (it *mapIteratorSwiss) cursor() *MapCursor {
+	return &MapCursor{Table: it.dirIdx, Bucket: it.groupIdx, Slot: int64(it.slotIdx)}
+}
+
+func (it *mapIteratorSwiss) seek(c *MapCursor) error {
+	if c.Addr != 0 || c.Table > it.dirLen {
+		return errMapCursor
+	}
+	it.dirIdx = c.Table
+	it.groupIdx = c.Bucket
+	it.slotIdx = uint32(c.Slot)
+	it.group = nil
+	if !it.small {
+		it.tab = nil
+	}
+	if it.tab != nil && it.groupIdx > uint64(it.tab.groups.Len) {
+		return errMapCursor
+	}
+	return nil
+}
+
 func (it *mapIteratorSwiss) slotIsEmptyOrDeleted(k uint32) bool {
 	//TODO: check that this hasn't changed after it's merged and the TODO is deleted
 	return it.group.ctrls[k]&swissTableCtrlEmpty == swissTableCtrlEmpty
@@ -0,0 +1,614 @@
+package proc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go/constant"
+	"go/token"
+	"math"
+	"reflect"
+
+	"explore/pkg/dwarf/godwarf"
+)
+
+// Insertion and deletion of map entries, derived from
+// $GOROOT/src/internal/runtime/maps/map.go and table.go for swiss tables
+// and from $GOROOT/src/runtime/map.go of Go 1.23 for classic maps.
+// The target keeps running while its maps are modified: the writing flag of
+// the map is checked first but a concurrent write by the target can still
+// corrupt the map. Insertions that would make the runtime grow the map, or
+// allocate an overflow bucket, are refused.
+
+const (
+	hashWriting          = 4  // +rtype hashWriting
+	classicBucketCnt     = 8  // +rtype bucketCnt
+	classicLoadFactorNum = 13 // +rtype loadFactorNum
+	classicLoadFactorDen = 2  // +rtype loadFactorDen
+)
+
+var errMapNil = errors.New("assignment to entry in nil map")
+var errMapWriting = errors.New("map is being written by the target")
+var errMapGrowing = errors.New("map is being grown by the target")
+var errMapNeedsGrow = errors.New("inserting a new key would make the map grow, which is not supported")
+var errMapNotAllocated = errors.New("map storage has not been allocated yet, inserting into it is not supported")
+var errMapIndirect = errors.New("inserting into maps that store keys or elements indirectly is not supported")
+
+// mapWriter is implemented by the map iterators that can modify the map
+// they iterate over.
+type mapWriter interface {
+	mapIterator
+	// seed returns the hash seed of the map.
+	seed() (uint64, error)
+	// insert adds an entry for a key with the given hash, the key must not
+	// be in the map already. write is called to fill the key and element of
+	// the new entry before the entry is made visible.
+	insert(hash uint64, write func(key, elem *Variable) error) error
+	// remove deletes the entry the iterator is positioned on.
+	remove() error
+}
+
+// MapAssign assigns val to m[key], inserting key in the map if it isn't
+// there yet. If val is nil the element of an existing key is left unchanged
+// and the element of a new key is zeroed. The element of key is returned.
+func (scope *EvalScope) MapAssign(m, key, val *Variable) (*Variable, error) {
+	it, kv, err := scope.mapWriteIterator(m, key)
+	if err != nil {
+		return nil, err
+	}
+	if m.Base == 0 {
+		return nil, errMapNil
+	}
+
+	found, err := m.seekMapKey(it, key)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		elem := it.value()
+		if val != nil {
+			if err := scope.setValue(elem, val, ""); err != nil {
+				return nil, err
+			}
+		}
+		return elem, nil
+	}
+
+	data, err := mapKeyData(kv, key)
+	if err != nil {
+		return nil, err
+	}
+	hasher, err := newMapHasher(scope.BinInfo, scope.Mem)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := it.seed()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hasher.hash(kv.Kind, data, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	var elem *Variable
+	err = it.insert(hash, func(k, e *Variable) error {
+		if err := scope.setValue(k, key, ""); err != nil {
+			return err
+		}
+		if err := e.writeZero(); err != nil {
+			return err
+		}
+		elem = e
+		if val != nil {
+			return scope.setValue(e, val, "")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return elem, nil
+}
+
+// MapDelete removes key from m. As with the delete builtin it is not an
+// error if key is not in the map.
+func (scope *EvalScope) MapDelete(m, key *Variable) error {
+	it, _, err := scope.mapWriteIterator(m, key)
+	if err != nil {
+		return err
+	}
+
+	found, err := m.seekMapKey(it, key)
+	if err != nil || !found {
+		return err
+	}
+	return it.remove()
+}
+
+// mapWriteIterator returns an iterator that can modify m and a variable of
+// the key type of m, key must be assignable to it.
+func (scope *EvalScope) mapWriteIterator(m, key *Variable) (mapWriter, *Variable, error) {
+	if m.Kind != reflect.Map {
+		return nil, nil, fmt.Errorf("%s (type %s) is not a map", m.Name, m.TypeString())
+	}
+	mt := m.RealType.(*godwarf.MapType)
+	kv := newVariable("", 0, mt.KeyType, scope.BinInfo, scope.Mem)
+	if err := key.isType(kv.RealType, kv.Kind); err != nil {
+		return nil, nil, err
+	}
+
+	it := m.mapIterator(0)
+	if it == nil {
+		return nil, nil, fmt.Errorf("can not access unreadable map: %v", m.Unreadable)
+	}
+	return it.(mapWriter), kv, nil
+}
+
+// mapKeyData returns the in-memory representation of key converted to the
+// type of kv, or its contents for strings, which is what the runtime hashes.
+func mapKeyData(kv, key *Variable) ([]byte, error) {
+	switch kv.Kind {
+	case reflect.Int, reflect.Uint, reflect.Bool, reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.Ptr, reflect.UnsafePointer, reflect.Chan:
+		// hashed as memory, except for floats
+	case reflect.String:
+		if key.Value != nil && int64(len(constant.StringVal(key.Value))) == key.Len {
+			return []byte(constant.StringVal(key.Value)), nil
+		}
+		buf := make([]byte, key.Len)
+		_, err := key.mem.ReadMemory(buf, key.Base)
+		return buf, err
+	default:
+		return nil, fmt.Errorf("inserting keys of type %s is not supported", kv.TypeString())
+	}
+
+	buf := make([]byte, kv.RealType.Size())
+	if key == nilVariable {
+		return buf, nil
+	}
+	if key.Addr != 0 && key.Flags&VariableConstant == 0 {
+		_, err := key.mem.ReadMemory(buf, key.Addr)
+		return buf, err
+	}
+
+	putFloat := func(buf []byte, f float64) {
+		if len(buf) == 4 {
+			binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(f)))
+		} else {
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(f))
+		}
+	}
+
+	switch kv.Kind {
+	case reflect.Int:
+		n, _ := constant.Int64Val(key.Value)
+		putUint(buf, uint64(n))
+	case reflect.Uint:
+		n, _ := constant.Uint64Val(key.Value)
+		putUint(buf, n)
+	case reflect.Bool:
+		if constant.BoolVal(key.Value) {
+			buf[0] = 1
+		}
+	case reflect.Float32, reflect.Float64:
+		f, _ := constant.Float64Val(key.Value)
+		putFloat(buf, f)
+	case reflect.Complex64, reflect.Complex128:
+		real, _ := constant.Float64Val(constant.Real(key.Value))
+		imag, _ := constant.Float64Val(constant.Imag(key.Value))
+		putFloat(buf[:len(buf)/2], real)
+		putFloat(buf[len(buf)/2:], imag)
+	default:
+		return nil, fmt.Errorf("can not use %s as a key of type %s", key.Value, kv.TypeString())
+	}
+	return buf, nil
+}
+
+func putUint(buf []byte, n uint64) {
+	for i := range buf {
+		buf[i] = byte(n >> (8 * i))
+	}
+}
+
+// seekMapKey advances it to the entry of map v with key idx, found is false
+// if there is no such entry.
+func (v *Variable) seekMapKey(it mapIterator, idx *Variable) (found bool, err error) {
+	lcfg := loadFullValue
+	if idx.Kind == reflect.String && int64(len(constant.StringVal(idx.Value))) == idx.Len && idx.Len > int64(lcfg.MaxStringLen) {
+		// If the index is a string load as much of the keys to at least match the length of the index.
+		lcfg.MaxStringLen = int(idx.Len)
+	}
+
+	first := true
+	for it.next() {
+		key := it.key()
+		key.loadValue(lcfg)
+		if key.Unreadable != nil {
+			return false, fmt.Errorf("can not access unreadable map: %v", key.Unreadable)
+		}
+		if first {
+			first = false
+			if err := idx.isType(key.RealType, key.Kind); err != nil {
+				return false, err
+			}
+		}
+		eql, err := compareOp(token.EQL, key, idx)
+		if err != nil {
+			return false, err
+		}
+		if eql {
+			return true, nil
+		}
+	}
+	if v.Unreadable != nil {
+		return false, v.Unreadable
+	}
+	return false, nil
+}
+
+// mapField reads the unsigned integer field name of the struct v.
+func mapField(v *Variable, name string) (*Variable, uint64, error) {
+	f, err := v.structMember(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	n, err := f.asUint()
+	return f, n, err
+}
+
+// addMapField adds delta to the unsigned integer field name of the struct v.
+func addMapField(v *Variable, name string, delta int64) error {
+	f, n, err := mapField(v, name)
+	if err != nil {
+		return err
+	}
+	return f.writeUint(n+uint64(delta), f.RealType.Size())
+}
+
+// storedIndirectly returns true if the type stored in the map for a key, or
+// an element, is a pointer to it because it is too big.
+func storedIndirectly(stored godwarf.Type, isptr bool) bool {
+	_, storedptr := godwarf.ResolveTypedef(stored).(*godwarf.PtrType)
+	return storedptr && !isptr
+}
+
+// Classic Maps ///////////////////////////////////////////////////////////////
+
+func (it *mapIteratorClassic) seed() (uint64, error) {
+	_, seed, err := mapField(it.hdr, "hash0")
+	return seed, err
+}
+
+func (it *mapIteratorClassic) checkWritable() error {
+	_, flags, err := mapField(it.hdr, "flags")
+	if err != nil {
+		return err
+	}
+	if flags&hashWriting != 0 {
+		return errMapWriting
+	}
+	if it.oldbuckets.Addr != 0 {
+		return errMapGrowing
+	}
+	return nil
+}
+
+// slot returns the key and the element of the entry at index i of the
+// current bucket, as they are stored in the bucket.
+func (it *mapIteratorClassic) slot(i int64) (key, elem *Variable, err error) {
+	key, err = it.keys.sliceAccess(int(i))
+	if err != nil {
+		return nil, nil, err
+	}
+	if it.values.fieldType.Size() <= 0 {
+		return key, it.v.newVariable("", it.values.Addr, it.values.fieldType, it.v.mem), nil
+	}
+	elem, err = it.values.sliceAccess(int(i))
+	return key, elem, err
+}
+
+func (it *mapIteratorClassic) insert(hash uint64, write func(key, elem *Variable) error) error {
+	if err := it.checkWritable(); err != nil {
+		return err
+	}
+	if it.buckets.Addr == 0 {
+		return errMapNotAllocated
+	}
+	if storedIndirectly(it.keys.fieldType, it.keyTypeIsPtr) || storedIndirectly(it.values.fieldType, it.elemTypeIsPtr) {
+		return errMapIndirect
+	}
+
+	_, b, err := mapField(it.hdr, "B")
+	if err != nil {
+		return err
+	}
+	_, noverflow, err := mapField(it.hdr, "noverflow")
+	if err != nil {
+		return err
+	}
+	// overLoadFactor and tooManyOverflowBuckets, the runtime would start
+	// growing the map.
+	count := uint64(it.v.Len) + 1
+	if count > classicBucketCnt && count > classicLoadFactorNum*((uint64(1)<<b)/classicLoadFactorDen) {
+		return errMapNeedsGrow
+	}
+	if noverflow >= uint64(1)<<min(b, 15) {
+		return errMapNeedsGrow
+	}
+
+	top := hash >> (it.v.bi.Arch.PtrSize()*8 - 8)
+	if top < it.hashMinTopHash {
+		top += it.hashMinTopHash
+	}
+
+	it.bidx = hash & (it.numbuckets - 1)
+	it.b, it.overflow = nil, nil
+	for it.nextBucket() {
+		for i := int64(0); i < it.tophashes.Len; i++ {
+			tophash, _ := it.tophashes.sliceAccess(int(i))
+			h, err := tophash.asUint()
+			if err != nil {
+				return err
+			}
+			if h > it.hashTophashEmptyOne {
+				continue
+			}
+
+			key, elem, err := it.slot(i)
+			if err != nil {
+				return err
+			}
+			if err := write(key, elem); err != nil {
+				return err
+			}
+			if err := tophash.writeUint(top, 1); err != nil {
+				return err
+			}
+			return addMapField(it.hdr, "count", 1)
+		}
+		if it.overflow == nil || it.overflow.Addr == 0 {
+			break
+		}
+	}
+	if it.v.Unreadable != nil {
+		return it.v.Unreadable
+	}
+	// the bucket is full, the runtime would allocate an overflow bucket
+	return errMapNeedsGrow
+}
+
+func (it *mapIteratorClassic) remove() error {
+	if err := it.checkWritable(); err != nil {
+		return err
+	}
+
+	i := it.idx - 1
+	key, elem, err := it.slot(i)
+	if err != nil {
+		return err
+	}
+	if err := key.writeZero(); err != nil {
+		return err
+	}
+	if err := elem.writeZero(); err != nil {
+		return err
+	}
+	tophash, err := it.tophashes.sliceAccess(int(i))
+	if err != nil {
+		return err
+	}
+	if err := tophash.writeUint(it.hashTophashEmptyOne, 1); err != nil {
+		return err
+	}
+	return addMapField(it.hdr, "count", -1)
+}
+
+// Swisstable Maps ///////////////////////////////////////////////////////////////
+
+func (it *mapIteratorSwiss) seed() (uint64, error) {
+	_, seed, err := mapField(it.hdr, "seed")
+	return seed, err
+}
+
+func (it *mapIteratorSwiss) checkWritable() error {
+	_, writing, err := mapField(it.hdr, "writing")
+	if err != nil {
+		return err
+	}
+	if writing != 0 {
+		return errMapWriting
+	}
+	return nil
+}
+
+// slot returns the key and the element of slot i of group g, as they are
+// stored in the group.
+func (it *mapIteratorSwiss) slot(g *swissGroup, i int) (key, elem *Variable, err error) {
+	slot, err := g.slots.sliceAccess(i)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key, err = slot.toField(it.slotFieldKey); err != nil {
+		return nil, nil, err
+	}
+	elem, err = slot.toField(it.slotFieldElem)
+	return key, elem, err
+}
+
+func (it *mapIteratorSwiss) setCtrl(g *swissGroup, i int, ctrl byte) error {
+	_, err := it.v.mem.WriteMemory(g.ctrlAddr+uint64(i), []byte{ctrl})
+	return err
+}
+
+func (it *mapIteratorSwiss) insert(hash uint64, write func(key, elem *Variable) error) error {
+	if err := it.checkWritable(); err != nil {
+		return err
+	}
+	if it.dirPtr.Addr == 0 {
+		return errMapNotAllocated
+	}
+	if storedIndirectly(it.slotFieldKey.Type, it.keyTypeIsPtr) || storedIndirectly(it.slotFieldElem.Type, it.elemTypeIsPtr) {
+		return errMapIndirect
+	}
+
+	h1, h2 := hash>>7, byte(hash&0x7f)
+
+	if it.small {
+		it.tab = &swissTable{groups: it.dirPtr}
+		it.groupIdx, it.group = 0, nil
+		it.loadCurrentGroup()
+		if it.group == nil {
+			return it.v.Unreadable
+		}
+		// small maps have no tombstones
+		for i, ctrl := range it.group.ctrls {
+			if ctrl&swissTableCtrlEmpty != swissTableCtrlEmpty {
+				continue
+			}
+			key, elem, err := it.slot(it.group, i)
+			if err != nil {
+				return err
+			}
+			if err := write(key, elem); err != nil {
+				return err
+			}
+			if err := it.setCtrl(it.group, i, h2); err != nil {
+				return err
+			}
+			return addMapField(it.hdr, "used", 1)
+		}
+		// the runtime would grow the map to a table
+		return errMapNeedsGrow
+	}
+
+	it.dirIdx = 0
+	if it.dirLen > 1 {
+		_, shift, err := mapField(it.hdr, "globalShift")
+		if err != nil {
+			return err
+		}
+		it.dirIdx = int64(hash >> (shift & 63))
+	}
+	it.tab = nil
+	it.loadCurrentTable()
+	if it.tab == nil {
+		return it.v.Unreadable
+	}
+
+	// probe for the first empty slot, remembering the first deleted one
+	// which is preferred.
+	var group, deletedGroup *swissGroup
+	slotIdx, deletedSlotIdx := -1, -1
+	mask := uint64(it.tab.groups.Len - 1)
+	offset := h1 & mask
+	for index := uint64(0); index <= mask && group == nil; index++ {
+		it.groupIdx, it.group = offset, nil
+		it.loadCurrentGroup()
+		if it.group == nil {
+			return it.v.Unreadable
+		}
+		offset = (offset + index + 1) & mask
+
+		for i, ctrl := range it.group.ctrls {
+			if ctrl&swissTableCtrlEmpty != swissTableCtrlEmpty {
+				continue
+			}
+			if ctrl == swissTableCtrlDeleted {
+				if deletedGroup == nil {
+					deletedGroup, deletedSlotIdx = it.group, i
+				}
+				break
+			}
+			group, slotIdx = it.group, i
+			break
+		}
+	}
+
+	_, growthLeft, err := mapField(it.tab.v, "growthLeft")
+	if err != nil {
+		return err
+	}
+	if deletedGroup != nil {
+		group, slotIdx = deletedGroup, deletedSlotIdx
+	} else if group == nil || growthLeft == 0 {
+		// the runtime would prune tombstones or rehash the table
+		return errMapNeedsGrow
+	}
+
+	key, elem, err := it.slot(group, slotIdx)
+	if err != nil {
+		return err
+	}
+	if err := write(key, elem); err != nil {
+		return err
+	}
+	if err := it.setCtrl(group, slotIdx, h2); err != nil {
+		return err
+	}
+	if deletedGroup == nil {
+		if err := addMapField(it.tab.v, "growthLeft", -1); err != nil {
+			return err
+		}
+	}
+	if err := addMapField(it.tab.v, "used", 1); err != nil {
+		return err
+	}
+	return addMapField(it.hdr, "used", 1)
+}
+
+func (it *mapIteratorSwiss) remove() error {
+	if err := it.checkWritable(); err != nil {
+		return err
+	}
+
+	i := int(it.slotIdx - 1)
+	key, elem, err := it.slot(it.group, i)
+	if err != nil {
+		return err
+	}
+	if err := key.writeZero(); err != nil {
+		return err
+	}
+	if err := elem.writeZero(); err != nil {
+		return err
+	}
+
+	if it.small {
+		// there is a single group, the slot can be reused immediately
+		if err := it.setCtrl(it.group, i, swissTableCtrlEmpty); err != nil {
+			return err
+		}
+		return addMapField(it.hdr, "used", -1)
+	}
+
+	// only a full group can be in the middle of a probe sequence, if the
+	// group isn't full the slot can be emptied, otherwise it becomes a
+	// tombstone.
+	hasEmpty := false
+	for _, ctrl := range it.group.ctrls {
+		if ctrl == swissTableCtrlEmpty {
+			hasEmpty = true
+		}
+	}
+	if hasEmpty {
+		if err := it.setCtrl(it.group, i, swissTableCtrlEmpty); err != nil {
+			return err
+		}
+		if err := addMapField(it.tab.v, "growthLeft", 1); err != nil {
+			return err
+		}
+	} else {
+		if err := it.setCtrl(it.group, i, swissTableCtrlDeleted); err != nil {
+			return err
+		}
+		if tombstonePossible, err := it.hdr.structMember("tombstonePossible"); err == nil {
+			if err := tombstonePossible.writeBool(true); err != nil {
+				return err
+			}
+		}
+	}
+	if err := addMapField(it.tab.v, "used", -1); err != nil {
+		return err
+	}
+	return addMapField(it.hdr, "used", -1)
+}
@@ -1,5 +1,13 @@
 package proc
 
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"explore/pkg/dwarf/op"
+)
+
 // MemoryReader is like io.ReaderAt, but the offset is a uint64 so that it
 // can address all of 64-bit memory.
 // Redundant with memoryReadWriter but more easily suited to working with
@@ -16,3 +24,106 @@ type MemoryReadWriter interface {
 	MemoryReader
 	WriteMemory(addr uint64, data []byte) (written int, err error)
 }
+
+// compositeMemory represents a chunk of memory that is stored in CPU
+// registers or non-contiguously.
+//
+// When optimizations are enabled the compiler will store some variables
+// into registers and sometimes it will also store structs non-contiguously
+// with some fields stored into CPU registers and other fields stored in
+// memory.
+type compositeMemory struct {
+	base    uint64 // base address for this composite memory
+	realmem MemoryReadWriter
+	arch    *Arch
+	regs    op.DwarfRegisters
+	pieces  []op.Piece
+	data    []byte
+}
+
+func newCompositeMemory(mem MemoryReadWriter, arch *Arch, regs op.DwarfRegisters, pieces []op.Piece, size int64) (*compositeMemory, error) {
+	cmem := &compositeMemory{realmem: mem, arch: arch, regs: regs, pieces: pieces, data: []byte{}}
+	for i := range pieces {
+		piece := &pieces[i]
+		switch piece.Kind {
+		case op.RegPiece:
+			reg := regs.Bytes(piece.Val)
+			if piece.Size == 0 && i == len(pieces)-1 {
+				piece.Size = len(reg)
+			}
+			if piece.Size > len(reg) {
+				if regs.FloatLoadError != nil {
+					return nil, fmt.Errorf("could not read %d bytes from register %d (size: %d), also error loading floating point registers: %v", piece.Size, piece.Val, len(reg), regs.FloatLoadError)
+				}
+				return nil, fmt.Errorf("could not read %d bytes from register %d (size: %d)", piece.Size, piece.Val, len(reg))
+			}
+			cmem.data = append(cmem.data, reg[:piece.Size]...)
+		case op.AddrPiece:
+			buf := make([]byte, piece.Size)
+			mem.ReadMemory(buf, piece.Val)
+			cmem.data = append(cmem.data, buf...)
+		case op.ImmPiece:
+			buf := piece.Bytes
+			if buf == nil {
+				sz := 8
+				if piece.Size > sz {
+					sz = piece.Size
+				}
+				if piece.Size == 0 && i == len(pieces)-1 {
+					piece.Size = arch.PtrSize() // DWARF doesn't say what this should be
+				}
+				buf = make([]byte, sz)
+				binary.LittleEndian.PutUint64(buf, piece.Val)
+			}
+			cmem.data = append(cmem.data, buf[:piece.Size]...)
+		default:
+			panic("unsupported piece kind")
+		}
+	}
+	paddingBytes := int(size) - len(cmem.data)
+	if paddingBytes > 0 && paddingBytes < arch.ptrSize {
+		padding := make([]byte, paddingBytes)
+		cmem.data = append(cmem.data, padding...)
+	}
+	return cmem, nil
+}
+
+func (mem *compositeMemory) ReadMemory(data []byte, addr uint64) (int, error) {
+	addr -= mem.base
+	if addr >= uint64(len(mem.data)) || addr+uint64(len(data)) > uint64(len(mem.data)) {
+		return 0, errors.New("read out of bounds")
+	}
+	copy(data, mem.data[addr:addr+uint64(len(data))])
+	return len(data), nil
+}
+
+// WriteMemory writes the pieces stored in memory, the registers of a
+// process that is not stopped can not be changed.
+func (mem *compositeMemory) WriteMemory(addr uint64, data []byte) (int, error) {
+	addr -= mem.base
+	if addr >= uint64(len(mem.data)) || addr+uint64(len(data)) > uint64(len(mem.data)) {
+		return 0, errors.New("write out of bounds")
+	}
+
+	curAddr := uint64(0)
+	donesz := 0
+	for _, piece := range mem.pieces {
+		if curAddr < (addr+uint64(len(data))) && addr < (curAddr+uint64(piece.Size)) {
+			// changed memory interval overlaps current piece
+			pieceMem := mem.data[curAddr : curAddr+uint64(piece.Size)]
+			start := max(addr, curAddr)
+			end := min(addr+uint64(len(data)), curAddr+uint64(piece.Size))
+			if piece.Kind != op.AddrPiece {
+				return donesz, errors.New("can not write a variable stored in a register")
+			}
+			pieceData := data[start-addr : end-addr]
+			if _, err := mem.realmem.WriteMemory(piece.Val+(start-curAddr), pieceData); err != nil {
+				return donesz, err
+			}
+			copy(pieceMem[start-curAddr:], pieceData)
+			donesz += len(pieceData)
+		}
+		curAddr += uint64(piece.Size)
+	}
+	return donesz, nil
+}
@@ -0,0 +1,59 @@
+package proc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"explore/pkg/dwarf/op"
+)
+
+type bufMemory struct {
+	base uint64
+	buf  []byte
+}
+
+func (m *bufMemory) ReadMemory(data []byte, addr uint64) (int, error) {
+	return copy(data, m.buf[addr-m.base:]), nil
+}
+
+func (m *bufMemory) WriteMemory(addr uint64, data []byte) (int, error) {
+	return copy(m.buf[addr-m.base:], data), nil
+}
+
+func TestCompositeMemory(t *testing.T) {
+	// a 16 byte struct with the first field in register 0 and the second
+	// one in memory.
+	mem := &bufMemory{base: 0x1000, buf: []byte{1, 2, 3, 4, 5, 6, 7, 8}}
+	regs := op.NewDwarfRegisters(0, nil, binary.LittleEndian, 16, 7, 6, 0)
+	regs.AddReg(0, op.DwarfRegisterFromUint64(0xcafe))
+	pieces := []op.Piece{{Size: 8, Kind: op.RegPiece, Val: 0}, {Size: 8, Kind: op.AddrPiece, Val: 0x1000}}
+
+	cmem, err := newCompositeMemory(mem, AMD64Arch("linux"), *regs, pieces, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmem.base = fakeAddressUnresolv
+
+	buf := make([]byte, 16)
+	if _, err := cmem.ReadMemory(buf, fakeAddressUnresolv); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0xfe, 0xca, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8}
+	if !bytes.Equal(buf, want) {
+		t.Errorf("read %x, want %x", buf, want)
+	}
+
+	if _, err := cmem.WriteMemory(fakeAddressUnresolv+10, []byte{0xff, 0xff}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{1, 2, 0xff, 0xff, 5, 6, 7, 8}; !bytes.Equal(mem.buf, want) {
+		t.Errorf("memory %x after write, want %x", mem.buf, want)
+	}
+	if _, err := cmem.WriteMemory(fakeAddressUnresolv, []byte{0}); err == nil {
+		t.Error("writing a register piece did not fail")
+	}
+	if _, err := cmem.ReadMemory(buf, fakeAddressUnresolv+1); err == nil {
+		t.Error("out of bounds read did not fail")
+	}
+}
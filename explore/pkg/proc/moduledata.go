@@ -24,13 +24,18 @@ func (e *errCouldNotFindSymbol) Error() string {
 type ModuleData struct {
 	text, etext   uint64
 	types, etypes uint64
-	typemapVar    *Variable
+	// noptrdata and enoptrbss bound the package variables and the values
+	// the linker allocates statically, from .noptrdata to .noptrbss
+	noptrdata, enoptrbss uint64
+	typemapVar           *Variable
 }
 
 func LoadModuleData(bi *BinaryInfo, mem MemoryReadWriter) ([]ModuleData, error) {
 	// +rtype -var firstmoduledata moduledata
 	// +rtype -field moduledata.text uintptr
 	// +rtype -field moduledata.types uintptr
+	// +rtype -field moduledata.noptrdata uintptr
+	// +rtype -field moduledata.enoptrbss uintptr
 
 	var md *Variable
 	md, err := findGlobal(bi, mem, "runtime", "firstmoduledata")
@@ -42,16 +47,18 @@ func LoadModuleData(bi *BinaryInfo, mem MemoryReadWriter) ([]ModuleData, error)
 
 	for md.Addr != 0 {
 		const (
-			typesField   = "types"
-			etypesField  = "etypes"
-			textField    = "text"
-			etextField   = "etext"
-			nextField    = "next"
-			typemapField = "typemap"
+			typesField     = "types"
+			etypesField    = "etypes"
+			textField      = "text"
+			etextField     = "etext"
+			nextField      = "next"
+			typemapField   = "typemap"
+			noptrdataField = "noptrdata"
+			enoptrbssField = "enoptrbss"
 		)
 		vars := map[string]*Variable{}
 
-		for _, fieldName := range []string{typesField, etypesField, textField, etextField, nextField, typemapField} {
+		for _, fieldName := range []string{typesField, etypesField, textField, etextField, nextField, typemapField, noptrdataField, enoptrbssField} {
 			var err error
 			vars[fieldName], err = md.structMember(fieldName)
 			if err != nil {
@@ -73,6 +80,7 @@ func LoadModuleData(bi *BinaryInfo, mem MemoryReadWriter) ([]ModuleData, error)
 		r = append(r, ModuleData{
 			types: touint(typesField), etypes: touint(etypesField),
 			text: touint(textField), etext: touint(etextField),
+			noptrdata: touint(noptrdataField), enoptrbss: touint(enoptrbssField),
 			typemapVar: vars[typemapField],
 		})
 		if err != nil {
@@ -88,6 +96,18 @@ func LoadModuleData(bi *BinaryInfo, mem MemoryReadWriter) ([]ModuleData, error)
 	return r, nil
 }
 
+// inStaticData reports whether addr is in the statically allocated memory of a
+// module: its package variables and the composite literals the linker
+// allocates, e.g. the Config of var cfg = &Config{...}.
+func inStaticData(mds []ModuleData, addr uint64) bool {
+	for i := range mds {
+		if addr >= mds[i].noptrdata && addr < mds[i].enoptrbss {
+			return true
+		}
+	}
+	return false
+}
+
 func findModuleDataForType(mds []ModuleData, typeAddr uint64) *ModuleData {
 	for i := range mds {
 		if typeAddr >= mds[i].types && typeAddr < mds[i].etypes {
@@ -187,6 +207,15 @@ func extractVarInfoFromEntry(bi *BinaryInfo, image *Image, regs op.DwarfRegister
 	}
 
 	addr, pieces, descr, err := bi.Location(entry, dwarf.AttrLocation, regs.PC(), regs, mem)
+	if pieces != nil {
+		var cmem *compositeMemory
+		cmem, err = newCompositeMemory(mem, bi.Arch, regs, pieces, t.Common().ByteSize)
+		if cmem != nil {
+			cmem.base = fakeAddressUnresolv
+			addr = int64(cmem.base)
+			mem = cmem
+		}
+	}
 
 	v := newVariable(n, uint64(addr), t, bi, mem)
 	if pieces != nil {
@@ -194,6 +223,13 @@ func extractVarInfoFromEntry(bi *BinaryInfo, image *Image, regs op.DwarfRegister
 	}
 	v.LocationExpr = descr
 	v.DeclLine, _ = entry.Val(dwarf.AttrDeclLine).(int64)
+	if entry.Tag == dwarf.TagFormalParameter {
+		if isret, _ := entry.Val(dwarf.AttrVarParam).(bool); isret {
+			v.Flags |= VariableReturnArgument
+		} else {
+			v.Flags |= VariableArgument
+		}
+	}
 	if err != nil {
 		v.Unreadable = err
 	}
@@ -0,0 +1,730 @@
+package proc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"go/constant"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"explore/pkg/dwarf/godwarf"
+)
+
+// maxGraphRead is the largest number of bytes of an object read at once
+// while walking an object graph, larger arrays are read in pieces.
+const maxGraphRead = 1 << 20
+
+// GraphObject is an object of an ObjectGraph: a root, a heap object, a
+// value in the static data of the modules or, when the layout of the heap
+// is not known, the memory a pointer points to.
+type GraphObject struct {
+	Addr uint64
+	// Size is the size of the heap object, or of the type of the root
+	Size uint64
+	// Type is the name of the type of the object, empty if it is only
+	// reached by untyped pointers. Backing arrays of slices are [N]T
+	Type string
+	// Name is the name of the roots
+	Name string
+	// Depth is the number of pointers followed from the nearest root
+	Depth int
+	// Parent is the object the object was first reached from, -1 for roots
+	Parent int
+	// Label is the access path from Parent to the object, e.g. .next
+	Label string
+	// Edges are the pointers held by the object
+	Edges []GraphEdge
+	// Static is set for the objects in the static data of modules, which
+	// are not roots, e.g. the Config of var cfg = &Config{...}
+	Static bool
+
+	root bool
+}
+
+// GraphEdge is a pointer held by an object of an ObjectGraph.
+type GraphEdge struct {
+	// To is the index of the object pointed to, -1 if it is not an object
+	// of the graph, e.g. a string literal
+	To int
+	// Label is the access path of the pointer in the object holding it,
+	// e.g. .handlers["x"]
+	Label string
+	// Addr is the address of the pointer, 0 for the pointers to the memory
+	// of maps holding their entries
+	Addr uint64
+	// Ptr is the value of the pointer
+	Ptr uint64
+}
+
+// ObjectGraph is the graph of the objects reachable from a set of roots,
+// e.g. package variables, following the pointers typed by DWARF: pointers,
+// slices, strings, maps, channels and the dynamic type of interfaces.
+// Buffers of channels, closures and the pointers hidden in unsafe.Pointer
+// and uintptr values are not followed further than the object they point
+// to.
+type ObjectGraph struct {
+	Objects []GraphObject
+	// MaxObjects is the number of objects after which no new object is
+	// added, 0 for no limit
+	MaxObjects int
+	// Truncated is set if objects were left out because of MaxObjects
+	Truncated bool
+
+	bi   *BinaryInfo
+	mem  MemoryReadWriter
+	heap *Heap // nil if the layout of the heap is not known
+
+	mds    []ModuleData
+	mdsErr error
+	ifaces map[uint64]ifaceType // by address of the type or of the itab
+
+	roots   []int // sorted by address
+	statics []int // objects in the static data of modules, sorted by address
+	objects map[uint64]int
+	views   map[graphView]bool
+	maps    map[int]bool // objects of the maps iterated
+	queue   []graphView
+}
+
+// graphView is a value of type typ at addr in the object obj, n is the
+// number of elements of the backing arrays of slices. For the entries of
+// maps, obj is the map and addr is the address of the map value.
+type graphView struct {
+	obj     int
+	addr    uint64
+	typ     godwarf.Type
+	n       int64
+	entries bool
+}
+
+type ifaceType struct {
+	typ    godwarf.Type
+	direct bool
+	err    error
+}
+
+// NewObjectGraph returns an empty graph of the objects of the process. The
+// objects are the heap objects of heap, if it isn't nil.
+func NewObjectGraph(bi *BinaryInfo, mem MemoryReadWriter, heap *Heap) *ObjectGraph {
+	return &ObjectGraph{
+		bi:      bi,
+		mem:     mem,
+		heap:    heap,
+		ifaces:  make(map[uint64]ifaceType),
+		objects: make(map[uint64]int),
+		views:   make(map[graphView]bool),
+		maps:    make(map[int]bool),
+	}
+}
+
+// AddRoot adds the value of type typ at addr named name to the roots of the
+// graph and returns its index. Roots must be added before Walk.
+func (g *ObjectGraph) AddRoot(name string, addr uint64, typ godwarf.Type) int {
+	i := len(g.Objects)
+	g.Objects = append(g.Objects, GraphObject{Addr: addr, Size: uint64(typ.Size()), Type: typeName(typ), Name: name, Parent: -1, root: true})
+	g.roots = append(g.roots, i)
+	g.enqueue(graphView{obj: i, addr: addr, typ: typ})
+	return i
+}
+
+// Walk adds the objects reachable from the roots, breadth first so that
+// the parents of objects are on a shortest path from a root. The objects
+// deeper than maxDepth are added but their pointers are not followed, a
+// negative maxDepth follows all of them.
+func (g *ObjectGraph) Walk(maxDepth int) {
+	sort.Slice(g.roots, func(a, b int) bool { return g.Objects[g.roots[a]].Addr < g.Objects[g.roots[b]].Addr })
+	for len(g.queue) > 0 {
+		v := g.queue[0]
+		g.queue = g.queue[1:]
+		if maxDepth >= 0 && g.Objects[v.obj].Depth > maxDepth {
+			continue
+		}
+		g.visit(v)
+	}
+}
+
+// Path returns the access path of the object i from its root, e.g.
+// main.registry.handlers["x"].next.
+func (g *ObjectGraph) Path(i int) string {
+	path := ""
+	for ; i >= 0 && !g.Objects[i].root; i = g.Objects[i].Parent {
+		path = g.Objects[i].Label + path
+	}
+	if i < 0 {
+		return path
+	}
+	return g.Objects[i].Name + path
+}
+
+// ObjectOf returns the index of the object containing addr, -1 if it is
+// not an object of the graph. Without the layout of the heap, only the
+// first byte of objects other than roots is found.
+func (g *ObjectGraph) ObjectOf(addr uint64) int {
+	if i := g.rootOf(addr); i >= 0 {
+		return i
+	}
+	if g.heap != nil {
+		if base, _, ok := g.heap.ObjectOf(addr); ok {
+			if i, ok := g.objects[base]; ok {
+				return i
+			}
+			return -1
+		}
+		return g.staticOf(addr)
+	}
+	if i, ok := g.objects[addr]; ok {
+		return i
+	}
+	return -1
+}
+
+func (g *ObjectGraph) rootOf(addr uint64) int {
+	i := sort.Search(len(g.roots), func(i int) bool { return g.Objects[g.roots[i]].Addr > addr }) - 1
+	if i >= 0 {
+		r := &g.Objects[g.roots[i]]
+		if addr < r.Addr+max(r.Size, 1) {
+			return g.roots[i]
+		}
+	}
+	return -1
+}
+
+// staticOf returns the index of the object in static data containing addr,
+// -1 if there is none.
+func (g *ObjectGraph) staticOf(addr uint64) int {
+	i := sort.Search(len(g.statics), func(i int) bool { return g.Objects[g.statics[i]].Addr > addr }) - 1
+	if i >= 0 {
+		o := &g.Objects[g.statics[i]]
+		if addr < o.Addr+max(o.Size, 1) {
+			return g.statics[i]
+		}
+	}
+	return -1
+}
+
+// static reports whether addr is in the static data of a module, where the
+// linker allocates the values of composite literals of package variables.
+func (g *ObjectGraph) static(addr uint64) bool {
+	mds, err := g.moduleData()
+	return err == nil && inStaticData(mds, addr)
+}
+
+func (g *ObjectGraph) moduleData() ([]ModuleData, error) {
+	if g.mds == nil && g.mdsErr == nil {
+		g.mds, g.mdsErr = LoadModuleData(g.bi, g.mem)
+	}
+	return g.mds, g.mdsErr
+}
+
+func (g *ObjectGraph) enqueue(v graphView) {
+	if g.views[v] {
+		return
+	}
+	g.views[v] = true
+	g.queue = append(g.queue, v)
+}
+
+// object returns the index of the object containing addr, adding it if it
+// is not in the graph yet. typeName is the type of the value at addr.
+func (g *ObjectGraph) object(addr uint64, size uint64, typeName string, from int, label string) int {
+	if i := g.rootOf(addr); i >= 0 {
+		return i
+	}
+	base, static := addr, false
+	if g.heap != nil {
+		if hbase, hsize, ok := g.heap.ObjectOf(addr); ok {
+			base, size = hbase, hsize
+		} else {
+			// a value in static data, as large as its type
+			if i := g.staticOf(addr); i >= 0 {
+				return i
+			}
+			if size == 0 || !g.static(addr) {
+				return -1
+			}
+			static = true
+		}
+	}
+	if i, ok := g.objects[base]; ok {
+		if g.Objects[i].Type == "" && base == addr {
+			g.Objects[i].Type = typeName
+		}
+		return i
+	}
+	if g.MaxObjects > 0 && len(g.Objects) >= g.MaxObjects {
+		g.Truncated = true
+		return -1
+	}
+
+	i := len(g.Objects)
+	o := GraphObject{Addr: base, Size: size, Depth: g.Objects[from].Depth + 1, Parent: from, Label: label, Static: static}
+	if base == addr {
+		o.Type = typeName
+	}
+	g.Objects = append(g.Objects, o)
+	g.objects[base] = i
+	if static {
+		j := sort.Search(len(g.statics), func(j int) bool { return g.Objects[g.statics[j]].Addr > base })
+		g.statics = append(g.statics, 0)
+		copy(g.statics[j+1:], g.statics[j:])
+		g.statics[j] = i
+	}
+	return i
+}
+
+// follow records the pointer ptr at addr in the object from and queues the
+// value of type typ it points to, nil if the type is not known.
+func (g *ObjectGraph) follow(from int, label string, addr, ptr uint64, typ godwarf.Type) {
+	if ptr == 0 {
+		return
+	}
+	to := -1
+	if g.heap == nil || g.heap.Contains(ptr) || g.rootOf(ptr) >= 0 || (typ != nil && g.static(ptr)) {
+		size, name := uint64(0), ""
+		if typ != nil {
+			size, name = uint64(typ.Size()), typeName(typ)
+		}
+		if typ != nil || g.heap != nil {
+			to = g.object(ptr, size, name, from, label)
+		}
+	}
+	g.Objects[from].Edges = append(g.Objects[from].Edges, GraphEdge{To: to, Label: label, Addr: addr, Ptr: ptr})
+	if to >= 0 && typ != nil {
+		g.enqueue(graphView{obj: to, addr: ptr, typ: typ})
+	}
+}
+
+// visit reads the view v and follows the pointers in it.
+func (g *ObjectGraph) visit(v graphView) {
+	if v.n > 0 {
+		// backing array of a slice
+		size := uint64(v.typ.Size())
+		if size == 0 {
+			return
+		}
+		per := max(maxGraphRead/size, 1)
+		for i := int64(0); i < v.n; i += int64(per) {
+			n := min(int64(per), v.n-i)
+			addr := v.addr + uint64(i)*size
+			buf := make([]byte, uint64(n)*size)
+			if _, err := g.mem.ReadMemory(buf, addr); err != nil {
+				return
+			}
+			for j := int64(0); j < n; j++ {
+				g.scan(v.obj, buf[uint64(j)*size:uint64(j+1)*size], addr+uint64(j)*size, v.typ, fmt.Sprintf("[%d]", i+j))
+			}
+		}
+		return
+	}
+
+	if v.entries {
+		g.scanMap(v.obj, v.addr, v.typ, "")
+		return
+	}
+	size := v.typ.Size()
+	if size <= 0 || size > maxGraphRead*64 {
+		return
+	}
+	buf := make([]byte, size)
+	if _, err := g.mem.ReadMemory(buf, v.addr); err != nil {
+		return
+	}
+	g.scan(v.obj, buf, v.addr, v.typ, "")
+}
+
+// scan follows the pointers in the value of type typ at addr, buf is its
+// memory.
+func (g *ObjectGraph) scan(obj int, buf []byte, addr uint64, typ godwarf.Type, label string) {
+	ptrSize := g.bi.Arch.PtrSize()
+	word := func(off int) uint64 {
+		if off+ptrSize > len(buf) {
+			return 0
+		}
+		if ptrSize == 4 {
+			return uint64(binary.LittleEndian.Uint32(buf[off:]))
+		}
+		return binary.LittleEndian.Uint64(buf[off:])
+	}
+
+	switch t := godwarf.ResolveTypedef(typ).(type) {
+	case *godwarf.PtrType:
+		elem := t.Type
+		if _, isvoid := godwarf.ResolveTypedef(elem).(*godwarf.VoidType); isvoid {
+			// unsafe.Pointer
+			elem = nil
+		}
+		g.follow(obj, label, addr, word(0), elem)
+
+	case *godwarf.FuncType:
+		g.follow(obj, label, addr, word(0), nil)
+
+	case *godwarf.ChanType:
+		if ptr, ok := godwarf.ResolveTypedef(t.TypedefType.Type).(*godwarf.PtrType); ok {
+			g.follow(obj, label, addr, word(0), ptr.Type)
+		}
+
+	case *godwarf.StringType:
+		if n := word(ptrSize); n > 0 {
+			g.follow(obj, label, addr, word(0), nil)
+			if to := g.lastEdge(obj); to >= 0 && g.Objects[to].Type == "" && g.Objects[to].Addr == word(0) {
+				g.Objects[to].Type = "string"
+			}
+		}
+
+	case *godwarf.SliceType:
+		base, n := word(0), int64(word(ptrSize))
+		if base == 0 {
+			return
+		}
+		g.follow(obj, label, addr, base, nil)
+		to := g.lastEdge(obj)
+		if to < 0 || n <= 0 {
+			return
+		}
+		if o := &g.Objects[to]; o.Type == "" && o.Addr == base {
+			o.Type = fmt.Sprintf("[%d]%s", int64(word(2*ptrSize)), typeName(t.ElemType))
+		}
+		g.enqueue(graphView{obj: to, addr: base, typ: t.ElemType, n: n})
+
+	case *godwarf.MapType:
+		hdr := word(0)
+		g.follow(obj, label, addr, hdr, nil)
+		if to := g.lastEdge(obj); to >= 0 && !g.maps[to] {
+			if o := &g.Objects[to]; o.Type == "" && o.Addr == hdr {
+				o.Type = typeName(typ)
+			}
+			g.maps[to] = true
+			g.enqueue(graphView{obj: to, addr: addr, typ: typ, entries: true})
+		}
+
+	case *godwarf.InterfaceType:
+		it := g.ifaceType(addr, t, word(0))
+		if it.typ == nil {
+			return
+		}
+		label += ".(" + typeName(it.typ) + ")"
+		if it.direct {
+			g.scan(obj, buf[ptrSize:], addr+uint64(ptrSize), it.typ, label)
+		} else {
+			g.follow(obj, label, addr+uint64(ptrSize), word(ptrSize), it.typ)
+		}
+
+	case *godwarf.StructType:
+		for _, f := range t.Field {
+			off, size := f.ByteOffset, f.Type.Size()
+			if off+size > int64(len(buf)) || !hasPointers(f.Type) {
+				continue
+			}
+			g.scan(obj, buf[off:off+size], addr+uint64(off), f.Type, label+"."+f.Name)
+		}
+
+	case *godwarf.ArrayType:
+		size := t.Type.Size()
+		if size <= 0 || !hasPointers(t.Type) {
+			return
+		}
+		for i := int64(0); i < t.Count && (i+1)*size <= int64(len(buf)); i++ {
+			g.scan(obj, buf[i*size:(i+1)*size], addr+uint64(i*size), t.Type, label+"["+strconv.FormatInt(i, 10)+"]")
+		}
+	}
+}
+
+// lastEdge returns the object pointed to by the last edge of obj.
+func (g *ObjectGraph) lastEdge(obj int) int {
+	edges := g.Objects[obj].Edges
+	if len(edges) == 0 {
+		return -1
+	}
+	return edges[len(edges)-1].To
+}
+
+// scanMap follows the pointers in the keys and the values of the map of
+// type typ at addr.
+func (g *ObjectGraph) scanMap(obj int, addr uint64, typ godwarf.Type, label string) {
+	mt := godwarf.ResolveTypedef(typ).(*godwarf.MapType)
+	keyPtrs, valPtrs := hasPointers(mt.KeyType), hasPointers(mt.ElemType)
+	keyLabels := isBasic(mt.KeyType)
+	if !keyPtrs && !valPtrs {
+		return
+	}
+
+	v := newVariable("", addr, typ, g.bi, g.mem)
+	it := v.mapIterator(0)
+	if it == nil {
+		return
+	}
+	storage := uint64(0)
+	for it.next() {
+		key, val := it.key(), it.value()
+		if g.heap != nil {
+			// the buckets or groups holding the entries are retained by the
+			// map, they are only pointed to by untyped pointers
+			if base, _, ok := g.heap.ObjectOf(key.Addr); ok && base != storage {
+				storage = base
+				g.follow(obj, label, 0, base, nil)
+			}
+		}
+		if keyLabels {
+			key.loadValue(LoadConfig{MaxStringLen: 64})
+		}
+		l := label + "[" + mapKeyLabel(key) + "]"
+		if keyPtrs {
+			g.scanVariable(obj, key, l+"(key)")
+		}
+		if valPtrs {
+			g.scanVariable(obj, val, l)
+		}
+	}
+}
+
+func (g *ObjectGraph) scanVariable(obj int, v *Variable, label string) {
+	if v.Unreadable != nil || v.Addr == 0 {
+		return
+	}
+	size := v.RealType.Size()
+	if size <= 0 || size > maxGraphRead {
+		return
+	}
+	buf := make([]byte, size)
+	if _, err := g.mem.ReadMemory(buf, v.Addr); err != nil {
+		return
+	}
+	g.scan(obj, buf, v.Addr, v.RealType, label)
+}
+
+// mapKeyLabel returns key as written in an index expression.
+func mapKeyLabel(key *Variable) string {
+	if key.Value != nil && key.Unreadable == nil {
+		if key.Kind == reflect.String {
+			s := strconv.Quote(constant.StringVal(key.Value))
+			if int64(len(constant.StringVal(key.Value))) < key.Len {
+				s += "..."
+			}
+			return s
+		}
+		return key.Value.ExactString()
+	}
+	return fmt.Sprintf("%s@%#x", typeName(key.RealType), key.Addr)
+}
+
+// ifaceType returns the dynamic type of the interface of type typ at addr,
+// tab is the address of its type or itab.
+func (g *ObjectGraph) ifaceType(addr uint64, typ *godwarf.InterfaceType, tab uint64) ifaceType {
+	if tab == 0 {
+		return ifaceType{}
+	}
+	if it, ok := g.ifaces[tab]; ok {
+		return it
+	}
+
+	it := ifaceType{}
+	v := newVariable("", addr, typ, g.bi, g.mem)
+	_type, data, isnil := v.readInterface()
+	if isnil || _type == nil || data == nil || v.Unreadable != nil {
+		return it
+	}
+	mds, err := g.moduleData()
+	if err != nil {
+		it.err = err
+		g.ifaces[tab] = it
+		return it
+	}
+	var kind int64
+	it.typ, kind, it.err = RuntimeTypeToDIE(_type, data.Addr, mds)
+	if it.err != nil {
+		it.typ = nil
+	}
+	if it.typ != nil {
+		it.direct = kind&kindDirectIface != 0 || isDirectIface(it.typ)
+	}
+	g.ifaces[tab] = it
+	return it
+}
+
+// typeName returns the name of typ as written in Go.
+func typeName(typ godwarf.Type) string {
+	if name := typ.Common().Name; name != "" {
+		return name
+	}
+	return typ.String()
+}
+
+// hasPointers reports whether values of type typ can hold pointers.
+func hasPointers(typ godwarf.Type) bool {
+	switch t := godwarf.ResolveTypedef(typ).(type) {
+	case *godwarf.StructType:
+		for _, f := range t.Field {
+			if hasPointers(f.Type) {
+				return true
+			}
+		}
+		return false
+	case *godwarf.ArrayType:
+		return t.Count > 0 && hasPointers(t.Type)
+	case *godwarf.PtrType, *godwarf.ChanType, *godwarf.StringType,
+		*godwarf.SliceType, *godwarf.MapType, *godwarf.InterfaceType, *godwarf.FuncType:
+		return true
+	}
+	return false
+}
+
+// isBasic reports whether typ is a boolean, a number or a string.
+func isBasic(typ godwarf.Type) bool {
+	switch godwarf.ResolveTypedef(typ).(type) {
+	case *godwarf.BoolType, *godwarf.IntType, *godwarf.UintType, *godwarf.FloatType,
+		*godwarf.ComplexType, *godwarf.StringType, *godwarf.CharType, *godwarf.UcharType:
+		return true
+	}
+	return false
+}
+
+// Dominators returns the immediate dominator of each object of the walked
+// graph: the last object all the paths from the roots to it go through.
+// Roots, and the objects reachable from several roots through no common
+// object, have none: -1.
+func (g *ObjectGraph) Dominators() []int {
+	idom, _ := g.dominators()
+	return idom
+}
+
+// Retained returns the number of bytes and of objects retained by each
+// object of the walked graph: the objects it dominates, itself included,
+// which would be freed if it were. Objects in static data are never freed,
+// they only retain the objects they dominate.
+func (g *ObjectGraph) Retained() (size, count []uint64) {
+	idom, postorder := g.dominators()
+	size = make([]uint64, len(g.Objects))
+	count = make([]uint64, len(g.Objects))
+	// objects come after all the objects they dominate in postorder
+	for _, i := range postorder {
+		if !g.Objects[i].Static {
+			size[i] += g.Objects[i].Size
+			count[i]++
+		}
+		if d := idom[i]; d >= 0 {
+			size[d] += size[i]
+			count[d] += count[i]
+		}
+	}
+	return size, count
+}
+
+// dominators computes the dominator tree of the graph, with a virtual
+// object pointing to all the roots, following "A Simple, Fast Dominance
+// Algorithm" by Cooper, Harvey and Kennedy. It also returns the objects in
+// postorder, without the virtual object.
+func (g *ObjectGraph) dominators() (idom, postorder []int) {
+	n := len(g.Objects)
+	virtual := n
+	succ := func(i int, yield func(int)) {
+		if i == virtual {
+			for _, r := range g.roots {
+				yield(r)
+			}
+			return
+		}
+		for _, e := range g.Objects[i].Edges {
+			if e.To >= 0 {
+				yield(e.To)
+			}
+		}
+	}
+
+	// predecessors, in compressed rows
+	start := make([]int, n+2)
+	for i := 0; i <= n; i++ {
+		succ(i, func(to int) { start[to+1]++ })
+	}
+	for i := 1; i < len(start); i++ {
+		start[i] += start[i-1]
+	}
+	preds := make([]int, start[n+1])
+	next := append([]int(nil), start...)
+	for i := 0; i <= n; i++ {
+		succ(i, func(to int) {
+			preds[next[to]] = i
+			next[to]++
+		})
+	}
+
+	// postorder numbers, by an iterative depth first search
+	po := make([]int, n+1)
+	for i := range po {
+		po[i] = -1
+	}
+	order := make([]int, 0, n+1)
+	type frame struct {
+		obj  int
+		succ []int
+	}
+	children := func(i int) []int {
+		var r []int
+		succ(i, func(to int) { r = append(r, to) })
+		return r
+	}
+	visited := make([]bool, n+1)
+	visited[virtual] = true
+	stack := []frame{{virtual, children(virtual)}}
+	for len(stack) > 0 {
+		f := &stack[len(stack)-1]
+		if len(f.succ) == 0 {
+			po[f.obj] = len(order)
+			order = append(order, f.obj)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		to := f.succ[0]
+		f.succ = f.succ[1:]
+		if !visited[to] {
+			visited[to] = true
+			stack = append(stack, frame{to, children(to)})
+		}
+	}
+
+	idom = make([]int, n+1)
+	for i := range idom {
+		idom[i] = -1
+	}
+	idom[virtual] = virtual
+	intersect := func(a, b int) int {
+		for a != b {
+			for po[a] < po[b] {
+				a = idom[a]
+			}
+			for po[b] < po[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		// reverse postorder, the virtual object is last in postorder
+		for k := len(order) - 2; k >= 0; k-- {
+			b := order[k]
+			d := -1
+			for _, p := range preds[start[b]:start[b+1]] {
+				if idom[p] < 0 {
+					continue
+				}
+				if d < 0 {
+					d = p
+				} else {
+					d = intersect(p, d)
+				}
+			}
+			if d != idom[b] {
+				idom[b] = d
+				changed = true
+			}
+		}
+	}
+
+	for i := range idom[:n] {
+		if idom[i] == virtual {
+			idom[i] = -1
+		}
+	}
+	return idom[:n], order[:len(order)-1]
+}
@@ -0,0 +1,131 @@
+package proc
+
+import (
+	"testing"
+
+	"explore/pkg/dwarf/godwarf"
+)
+
+func TestObjectGraph(t *testing.T) {
+	// a list of two nodes pointing to each other, the first one is a root
+	node := &godwarf.StructType{CommonType: godwarf.CommonType{ByteSize: 8, Name: "main.node"}, StructName: "main.node", Kind: "struct"}
+	ptr := &godwarf.PtrType{CommonType: godwarf.CommonType{ByteSize: 8, Name: "*main.node"}, Type: node}
+	node.Field = []*godwarf.StructField{{Name: "next", Type: ptr, ByteOffset: 0}}
+	mem := &bufMemory{base: 0x1000, buf: []byte{
+		0x10, 0x10, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0x00, 0x10, 0, 0, 0, 0, 0, 0,
+	}}
+
+	g := NewObjectGraph(NewBinaryInfo("linux", "amd64"), mem, nil)
+	g.AddRoot("main.head", 0x1000, node)
+	g.Walk(-1)
+
+	if len(g.Objects) != 2 {
+		t.Fatalf("%d objects, want 2", len(g.Objects))
+	}
+	o := g.Objects[1]
+	if o.Addr != 0x1010 || o.Type != "main.node" || o.Depth != 1 {
+		t.Errorf("object %+v", o)
+	}
+	if path := g.Path(1); path != "main.head.next" {
+		t.Errorf("path %q, want main.head.next", path)
+	}
+	if edges := o.Edges; len(edges) != 1 || edges[0].To != 0 || edges[0].Addr != 0x1010 {
+		t.Errorf("edges of the second node %+v, want one to the root", edges)
+	}
+	if i := g.ObjectOf(0x1004); i != 0 {
+		t.Errorf("ObjectOf = %d, want 0", i)
+	}
+	if i := g.ObjectOf(0x1010); i != 1 {
+		t.Errorf("ObjectOf = %d, want 1", i)
+	}
+
+	g = NewObjectGraph(NewBinaryInfo("linux", "amd64"), mem, nil)
+	g.AddRoot("main.head", 0x1000, node)
+	g.Walk(0)
+	if len(g.Objects) != 2 || len(g.Objects[1].Edges) != 0 {
+		t.Errorf("pointers deeper than the maximum depth followed")
+	}
+}
+
+func TestDominators(t *testing.T) {
+	// roots 0 and 1, 0 -> 2 -> 4, 0 -> 3 -> 4, 1 -> 5, 1 -> 6, 0 -> 6
+	edges := [][]int{{2, 3, 6}, {5, 6}, {4}, {4}, {}, {}, {}}
+	g := &ObjectGraph{roots: []int{0, 1}}
+	for i, to := range edges {
+		o := GraphObject{Size: uint64(10 * (i + 1)), Parent: -1}
+		for _, j := range to {
+			o.Edges = append(o.Edges, GraphEdge{To: j})
+		}
+		g.Objects = append(g.Objects, o)
+	}
+
+	idom := g.Dominators()
+	want := []int{-1, -1, 0, 0, 0, 1, -1}
+	for i := range want {
+		if idom[i] != want[i] {
+			t.Errorf("dominator of %d: %d, want %d", i, idom[i], want[i])
+		}
+	}
+
+	size, count := g.Retained()
+	if size[0] != 10+30+40+50 || count[0] != 4 {
+		t.Errorf("root 0 retains %d bytes in %d objects, want 130 in 4", size[0], count[0])
+	}
+	if size[1] != 20+60 || count[1] != 2 {
+		t.Errorf("root 1 retains %d bytes in %d objects, want 80 in 2", size[1], count[1])
+	}
+}
+
+func TestRetainedStatic(t *testing.T) {
+	// root 0 -> static 1 -> 2, the static object is never freed but retains
+	// what it points to
+	g := &ObjectGraph{roots: []int{0}}
+	g.Objects = []GraphObject{
+		{Size: 8, Parent: -1, Edges: []GraphEdge{{To: 1}}},
+		{Size: 16, Parent: 0, Static: true, Edges: []GraphEdge{{To: 2}}},
+		{Size: 32, Parent: 1},
+	}
+	size, count := g.Retained()
+	if size[1] != 32 || count[1] != 1 {
+		t.Errorf("static object retains %d bytes in %d objects, want 32 in 1", size[1], count[1])
+	}
+	if size[0] != 8+32 || count[0] != 2 {
+		t.Errorf("root retains %d bytes in %d objects, want 40 in 2", size[0], count[0])
+	}
+}
+
+func TestObjectGraphStatic(t *testing.T) {
+	// var cfg = &config{...}, the linker allocates the config in the static
+	// data of the module, outside of the heap
+	conf := &godwarf.StructType{CommonType: godwarf.CommonType{ByteSize: 16, Name: "main.config"}, StructName: "main.config", Kind: "struct"}
+	ptr := &godwarf.PtrType{CommonType: godwarf.CommonType{ByteSize: 8, Name: "*main.config"}, Type: conf}
+	conf.Field = []*godwarf.StructField{
+		{Name: "n", Type: &godwarf.IntType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{ByteSize: 8, Name: "int"}}}, ByteOffset: 0},
+		{Name: "next", Type: ptr, ByteOffset: 8},
+	}
+	mem := &bufMemory{base: 0x1000, buf: []byte{
+		0x10, 0x10, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		7, 0, 0, 0, 0, 0, 0, 0, 0x10, 0x10, 0, 0, 0, 0, 0, 0,
+	}}
+
+	bi := NewBinaryInfo("linux", "amd64")
+	g := NewObjectGraph(bi, mem, &Heap{bi: bi, mem: mem, heapArenaBytes: 1 << 26})
+	g.mds = []ModuleData{{noptrdata: 0x1000, enoptrbss: 0x2000}}
+	g.AddRoot("main.cfg", 0x1000, ptr)
+	g.Walk(-1)
+
+	if len(g.Objects) != 2 {
+		t.Fatalf("%d objects, want 2", len(g.Objects))
+	}
+	o := g.Objects[1]
+	if o.Addr != 0x1010 || o.Size != 16 || o.Type != "main.config" || !o.Static {
+		t.Errorf("object %+v", o)
+	}
+	if edges := o.Edges; len(edges) != 1 || edges[0].To != 1 || edges[0].Label != ".next" {
+		t.Errorf("edges of the config %+v, want one to itself", edges)
+	}
+	if i := g.ObjectOf(0x1018); i != 1 {
+		t.Errorf("ObjectOf = %d, want 1", i)
+	}
+}
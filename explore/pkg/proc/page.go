@@ -0,0 +1,123 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// MapCursor is the position of the iteration of a map after the last entry
+// of a page, the next page of the map is read from there without iterating
+// again over the entries before it. A cursor is only valid as long as the
+// map does not grow.
+type MapCursor struct {
+	// Entries is the number of entries of the map before the position
+	Entries int64
+	// Table is the index of the table in the directory of a swiss map
+	Table int64
+	// Bucket is the index of the bucket of a classic map, or of the group
+	// in the table of a swiss map
+	Bucket uint64
+	// Slot is the index of the next entry in the bucket or in the group
+	Slot int64
+	// Addr is the address of the bucket of a classic map being iterated,
+	// which can be an overflow bucket
+	Addr uint64
+}
+
+// String returns the cursor in the form parsed by ParseMapCursor.
+func (c *MapCursor) String() string {
+	return fmt.Sprintf("%d:%d:%d:%d:%x", c.Entries, c.Table, c.Bucket, c.Slot, c.Addr)
+}
+
+// ParseMapCursor parses a cursor returned by MapCursor.String.
+func ParseMapCursor(s string) (*MapCursor, error) {
+	c := &MapCursor{}
+	if _, err := fmt.Sscanf(s, "%d:%d:%d:%d:%x", &c.Entries, &c.Table, &c.Bucket, &c.Slot, &c.Addr); err != nil {
+		return nil, fmt.Errorf("invalid map cursor %q", s)
+	}
+	if c.Entries < 0 || c.Table < 0 || c.Slot < 0 {
+		return nil, fmt.Errorf("invalid map cursor %q", s)
+	}
+	return c, nil
+}
+
+var errNotCollection = errors.New("only arrays, slices, strings and maps can be paginated")
+
+// LoadPage loads at most limit elements of the array, slice, string or map
+// v (or of the one v points to) starting from the element at offset, or
+// for maps from offset entries after cursor if it isn't nil. A limit of 0
+// reads as many elements as allowed by cfg.
+//
+// The returned variable has the length of the whole collection, Offset is
+// the index of its first child and, when more entries of a map are left,
+// MapCursor is the position of the next page.
+func (v *Variable) LoadPage(cfg LoadConfig, offset, limit int64, cursor *MapCursor) (*Variable, error) {
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	if v.Kind == reflect.Ptr {
+		v = v.maybeDereference()
+		v.loadValue(LoadConfig{})
+		if v.Unreadable != nil {
+			return nil, v.Unreadable
+		}
+	}
+	if offset < 0 || limit < 0 {
+		return nil, errors.New("negative offset or limit")
+	}
+	if cursor != nil && v.Kind != reflect.Map {
+		return nil, errors.New("cursors can only be used with maps")
+	}
+
+	switch v.Kind {
+	case reflect.Slice, reflect.Array, reflect.String:
+		if offset > v.Len {
+			return nil, errors.New("index out of bounds")
+		}
+		if limit == 0 {
+			limit = int64(cfg.MaxArrayValues)
+			if v.Kind == reflect.String {
+				limit = int64(cfg.MaxStringLen)
+			}
+		}
+		high := min(offset+limit, v.Len)
+		r, err := v.reslice(offset, high, false)
+		if err != nil {
+			return nil, err
+		}
+		if v.Kind == reflect.String {
+			cfg.MaxStringLen = int(high - offset)
+		} else {
+			cfg.MaxArrayValues = int(high - offset)
+		}
+		r.loadValue(cfg)
+
+		page := v.clone()
+		page.Children = r.Children
+		page.Value = r.Value
+		page.Unreadable = r.Unreadable
+		page.Offset = offset
+		return page, nil
+
+	case reflect.Map:
+		if cursor == nil {
+			cursor = &MapCursor{}
+		}
+		if limit > 0 {
+			cfg.MaxArrayValues = int(limit)
+		}
+		// the entries of the page are bounded by the limit, a bound on the
+		// buckets would hide the entries after the first pages
+		cfg.MaxMapBuckets = 0
+
+		page := v.clone()
+		page.Children = nil
+		page.loaded = false
+		page.mapSkip += int(offset)
+		page.mapCursor = cursor
+		page.loadValueInternal(0, cfg)
+		return page, nil
+	}
+	return nil, errNotCollection
+}
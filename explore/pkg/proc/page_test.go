@@ -0,0 +1,163 @@
+package proc
+
+import (
+	"go/constant"
+	"go/token"
+	"slices"
+	"testing"
+)
+
+func TestParseMapCursor(t *testing.T) {
+	c := &MapCursor{Entries: 1050, Table: 3, Bucket: 17, Slot: 5, Addr: 0xc000123400}
+	got, err := ParseMapCursor(c.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *c {
+		t.Errorf("parsed %+v, want %+v", got, c)
+	}
+
+	for _, s := range []string{"", "1:2:3", "a:0:0:0:0", "-1:0:0:0:0", "1:0:0:-2:0"} {
+		if _, err := ParseMapCursor(s); err == nil {
+			t.Errorf("cursor %q parsed without error", s)
+		}
+	}
+}
+
+func TestLoadPage(t *testing.T) {
+	scope := fixtureScope(t)
+	shallow := LoadConfig{MaxVariableRecurse: 1}
+	eval := func(expr string) *Variable {
+		t.Helper()
+		v, err := scope.EvalExpression(expr, shallow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	ints := func(v *Variable) []int64 {
+		r := make([]int64, len(v.Children))
+		for i := range v.Children {
+			r[i], _ = constant.Int64Val(v.Children[i].Value)
+		}
+		return r
+	}
+
+	numbers := eval("main.numbers")
+	for _, test := range []struct {
+		offset, limit int64
+		want          []int64
+	}{
+		{10, 5, []int64{10, 11, 12, 13, 14}},
+		{997, 5, []int64{997, 998, 999}},
+		{1000, 5, []int64{}},
+	} {
+		page, err := numbers.LoadPage(loadFullValue, test.offset, test.limit, nil)
+		if err != nil {
+			t.Errorf("page %d+%d: %v", test.offset, test.limit, err)
+			continue
+		}
+		if got := ints(page); !slices.Equal(got, test.want) || page.Offset != test.offset || page.Len != 1000 {
+			t.Errorf("page %d+%d: %v at %d of %d, want %v", test.offset, test.limit, got, page.Offset, page.Len, test.want)
+		}
+	}
+	// without a limit, as many elements as the load configuration allows
+	if page, err := numbers.LoadPage(loadFullValue, 100, 0, nil); err != nil || len(page.Children) != loadFullValue.MaxArrayValues {
+		t.Errorf("page without limit: %v", err)
+	}
+	for _, test := range []struct {
+		offset, limit int64
+		cursor        *MapCursor
+	}{
+		{1001, 5, nil},
+		{-1, 5, nil},
+		{0, -1, nil},
+		{0, 5, &MapCursor{}},
+	} {
+		if _, err := numbers.LoadPage(loadFullValue, test.offset, test.limit, test.cursor); err == nil {
+			t.Errorf("page %d+%d with cursor %v did not fail", test.offset, test.limit, test.cursor)
+		}
+	}
+
+	text := eval("main.text")
+	if page, err := text.LoadPage(loadFullValue, 10, 6, nil); err != nil || constant.StringVal(page.Value) != "abcdef" || page.Offset != 10 || page.Len != 36 {
+		t.Errorf("page of a string: %v, %v", page.Value, err)
+	}
+	if page, err := eval("main.cfg.Tags").LoadPage(loadFullValue, 1, 1, nil); err != nil || len(page.Children) != 1 || constant.StringVal(page.Children[0].Value) != "b" {
+		t.Errorf("page of an array: %v", err)
+	}
+	if _, err := eval("main.cfg").LoadPage(loadFullValue, 0, 1, nil); err != errNotCollection {
+		t.Errorf("page of a pointer to a struct: %v, want %v", err, errNotCollection)
+	}
+}
+
+func TestLoadPageMap(t *testing.T) {
+	scope := fixtureScope(t)
+	squares, err := scope.EvalExpression("main.squares", LoadConfig{MaxVariableRecurse: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the pages of 30 entries following the cursors hold all the entries
+	seen := make(map[int64]bool)
+	var cursor *MapCursor
+	for pages := 0; ; pages++ {
+		if pages > 4 {
+			t.Fatal("more than 4 pages of 30 entries")
+		}
+		page, err := squares.LoadPage(loadFullValue, 0, 30, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i+1 < len(page.Children); i += 2 {
+			k, _ := constant.Int64Val(page.Children[i].Value)
+			v, _ := constant.Int64Val(page.Children[i+1].Value)
+			if seen[k] || v != k*k {
+				t.Errorf("entry %d: %d, seen before: %v", k, v, seen[k])
+			}
+			seen[k] = true
+		}
+		if page.MapCursor == nil {
+			break
+		}
+		if n := int64(len(seen)); page.MapCursor.Entries != n || len(page.Children) != 60 {
+			t.Errorf("cursor after %d entries, %d children", page.MapCursor.Entries, len(page.Children))
+		}
+		cursor = page.MapCursor
+	}
+	if len(seen) != 100 {
+		t.Errorf("%d entries in the pages, want 100", len(seen))
+	}
+
+	// an offset skips entries after the cursor
+	first, err := squares.LoadPage(loadFullValue, 0, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipped, err := squares.LoadPage(loadFullValue, 5, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := squares.LoadPage(loadFullValue, 0, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := squares.LoadPage(loadFullValue, 2, 5, head.MapCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 10 {
+		if k, want := skipped.Children[i].Value, first.Children[10+i].Value; !constant.Compare(k, token.EQL, want) {
+			t.Errorf("child %d after an offset of 5: %v, want %v", i, k, want)
+		}
+		if k, want := resumed.Children[i].Value, first.Children[10+i].Value; !constant.Compare(k, token.EQL, want) {
+			t.Errorf("child %d after a cursor at 3 and an offset of 2: %v, want %v", i, k, want)
+		}
+	}
+
+	// out of range offsets give an empty page
+	page, err := squares.LoadPage(loadFullValue, 150, 10, nil)
+	if err != nil || len(page.Children) != 0 || page.MapCursor != nil {
+		t.Errorf("page after the end: %d children, cursor %v, %v", len(page.Children), page.MapCursor, err)
+	}
+}
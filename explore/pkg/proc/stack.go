@@ -0,0 +1,472 @@
+package proc
+
+import (
+	"debug/dwarf"
+	"errors"
+	"fmt"
+
+	"explore/pkg/dwarf/frame"
+	"explore/pkg/dwarf/op"
+	"explore/pkg/dwarf/reader"
+	"explore/pkg/logflags"
+)
+
+// ErrGoroutineRunning is returned when the stack of a goroutine that is
+// running is requested: the registers saved in its G struct are stale and
+// the thread executing it can not be inspected without stopping it.
+var ErrGoroutineRunning = errors.New("goroutine is running, its stack can not be read without stopping its thread")
+
+// Stackframe represents a frame in a system stack.
+//
+// Each stack frame has two locations Current and Call.
+//
+// For the topmost stackframe Current and Call are the same location.
+//
+// For stackframes after the first Current is the location corresponding to
+// the return address and Call is the location of the CALL instruction that
+// was last executed on the frame. Note however that Call.PC is always equal
+// to Current.PC, because finding the correct value for Call.PC would
+// require disassembling each function in the stacktrace.
+//
+// For synthetic stackframes generated for inlined function calls Current.Fn
+// is the function containing the inlining and Call.Fn in the inlined
+// function.
+type Stackframe struct {
+	Current, Call Location
+
+	// Frame registers.
+	Regs op.DwarfRegisters
+	// High address of the stack.
+	stackHi uint64
+	// Return address for this stack frame (as read from the stack frame itself).
+	Ret uint64
+	// Err is set if an error occurred during stacktrace
+	Err error
+	// SystemStack is true if this frame belongs to a system stack.
+	SystemStack bool
+	// Inlined is true if this frame is actually an inlined call.
+	Inlined bool
+	// Bottom is true if this is the bottom of the stack
+	Bottom bool
+
+	// lastpc is a memory address guaranteed to belong to the last instruction
+	// executed in this stack frame.
+	// For the topmost stack frame this will be the same as Current.PC and
+	// Call.PC, for other stack frames it will usually be Current.PC-1, but
+	// could be different when inlined calls are involved in the stacktrace.
+	// Note that this address isn't guaranteed to belong to the start of an
+	// instruction and, for this reason, should not be propagated outside of
+	// pkg/proc.
+	// Use this value to determine active lexical scopes for the stackframe.
+	lastpc uint64
+}
+
+// FrameOffset returns the address of the stack frame, absolute for system
+// stack frames or as an offset from stackhi for goroutine stacks (a
+// negative value).
+func (frame *Stackframe) FrameOffset() int64 {
+	if frame.SystemStack {
+		return frame.Regs.CFA
+	}
+	return frame.Regs.CFA - int64(frame.stackHi)
+}
+
+// GoroutineStacktrace returns the stack trace of the parked goroutine g,
+// unwound from the registers saved in its G struct, up to depth frames.
+// Running goroutines can only be unwound if the thread running them is
+// known.
+// Frames for inlined calls are included.
+func GoroutineStacktrace(g *G, depth int) ([]Stackframe, error) {
+	if g.Unreadable != nil {
+		return nil, g.Unreadable
+	}
+	bi := g.variable.bi
+	if g.Status&^Gscan == Grunning {
+		if g.Thread == nil {
+			return nil, ErrGoroutineRunning
+		}
+		// unwind from the registers of the thread running g
+		tregs, err := g.Thread.Registers()
+		if err != nil {
+			return nil, err
+		}
+		so := bi.PCToImage(tregs.PC())
+		regs := bi.Arch.RegistersToDwarfRegisters(so.StaticBase, tregs)
+		g.SystemStack = tregs.SP() < g.stack.lo || tregs.SP() >= g.stack.hi
+		it := newStackIterator(bi, g.variable.mem, *regs, g.stack.hi, g)
+		return it.stacktrace(depth)
+	}
+
+	so := bi.PCToImage(g.PC)
+	regs := bi.Arch.addrAndStackRegsToDwarfRegisters(so.StaticBase, g.PC, g.SP, g.BP, g.LR)
+	it := newStackIterator(bi, g.variable.mem, regs, g.stack.hi, g)
+	return it.stacktrace(depth)
+}
+
+// stackIterator holds information
+// required to iterate and walk the program
+// stack.
+type stackIterator struct {
+	pc    uint64
+	top   bool
+	atend bool
+	frame Stackframe
+	bi    *BinaryInfo
+	mem   MemoryReadWriter
+	err   error
+
+	stackhi     uint64
+	systemstack bool
+
+	// regs is the register set for the current frame
+	regs op.DwarfRegisters
+
+	g                  *G     // the goroutine being stacktraced, nil if we are stacktracing a goroutine-less thread
+	g0_sched_sp        uint64 // value of g0.sched.sp (see comments around its use)
+	g0_sched_sp_loaded bool   // g0_sched_sp was loaded from g0
+}
+
+func newStackIterator(bi *BinaryInfo, mem MemoryReadWriter, regs op.DwarfRegisters, stackhi uint64, g *G) *stackIterator {
+	systemstack := true
+	if g != nil {
+		systemstack = g.SystemStack
+	}
+	return &stackIterator{pc: regs.PC(), regs: regs, top: true, bi: bi, mem: mem, err: nil, atend: false, stackhi: stackhi, systemstack: systemstack, g: g}
+}
+
+// Next points the iterator to the next stack frame.
+func (it *stackIterator) Next() bool {
+	if it.err != nil || it.atend {
+		return false
+	}
+
+	callFrameRegs, ret, retaddr := it.advanceRegs()
+	it.frame = it.newStackframe(ret, retaddr)
+
+	if it.bi.Arch.switchStack(it, &callFrameRegs) {
+		return true
+	}
+
+	if it.frame.Ret <= 0 {
+		it.atend = true
+		return true
+	}
+
+	it.top = false
+	it.pc = it.frame.Ret
+	it.regs = callFrameRegs
+	return true
+}
+
+func (it *stackIterator) switchToGoroutineStack() {
+	it.systemstack = false
+	it.top = false
+	it.pc = it.g.PC
+	it.regs.Reg(it.regs.SPRegNum).Uint64Val = it.g.SP
+	it.regs.AddReg(it.regs.BPRegNum, op.DwarfRegisterFromUint64(it.g.BP))
+	if it.bi.Arch.Name == "arm64" {
+		it.regs.Reg(it.regs.LRRegNum).Uint64Val = it.g.LR
+	}
+}
+
+// Frame returns the frame the iterator is pointing at.
+func (it *stackIterator) Frame() Stackframe {
+	it.frame.Bottom = it.atend
+	return it.frame
+}
+
+// Err returns the error encountered during stack iteration.
+func (it *stackIterator) Err() error {
+	return it.err
+}
+
+// frameBase calculates the frame base pseudo-register for DWARF for fn and
+// the current frame.
+func (it *stackIterator) frameBase(fn *Function) int64 {
+	dwarfTree, err := fn.cu.image.getDwarfTree(fn.offset)
+	if err != nil {
+		return 0
+	}
+	fb, _, _, _ := it.bi.Location(dwarfTree.Entry, dwarf.AttrFrameBase, it.pc, it.regs, it.mem)
+	return fb
+}
+
+func (it *stackIterator) newStackframe(ret, retaddr uint64) Stackframe {
+	if retaddr == 0 {
+		it.err = fmt.Errorf("could not find the return address at %#x", it.pc)
+		return Stackframe{}
+	}
+	f, l, fn := it.bi.PCToLine(it.pc)
+	if fn == nil {
+		f = "?"
+		l = -1
+	} else {
+		it.regs.FrameBase = it.frameBase(fn)
+	}
+	r := Stackframe{Current: Location{PC: it.pc, File: f, Line: l, Fn: fn}, Regs: it.regs, Ret: ret, stackHi: it.stackhi, SystemStack: it.systemstack, lastpc: it.pc}
+	if r.Regs.Reg(it.regs.PCRegNum) == nil {
+		r.Regs.AddReg(it.regs.PCRegNum, op.DwarfRegisterFromUint64(it.pc))
+	}
+	if !it.top {
+		fnname := ""
+		if r.Current.Fn != nil {
+			fnname = r.Current.Fn.Name
+		}
+		switch fnname {
+		case "runtime.mstart", "runtime.systemstack_switch":
+			// these frames are inserted by runtime.systemstack and there is no CALL
+			// instruction to look for at pc - 1
+			r.Call = r.Current
+		default:
+			r.lastpc = it.pc - 1
+			r.Call.File, r.Call.Line, r.Call.Fn = it.bi.PCToLine(it.pc - 1)
+			if r.Call.Fn == nil {
+				r.Call.File = "?"
+				r.Call.Line = -1
+			}
+			r.Call.PC = r.Current.PC
+		}
+	} else {
+		r.Call = r.Current
+	}
+	return r
+}
+
+func (it *stackIterator) stacktrace(depth int) ([]Stackframe, error) {
+	if depth < 0 {
+		return nil, errors.New("negative maximum stack depth")
+	}
+	frames := make([]Stackframe, 0, depth+1)
+	for it.Next() {
+		if !it.appendInlineCalls(func(frame Stackframe) bool {
+			frames = append(frames, frame)
+			return len(frames) < depth+1
+		}, it.Frame()) {
+			break
+		}
+	}
+	if err := it.Err(); err != nil {
+		if len(frames) == 0 {
+			return nil, err
+		}
+		frames = append(frames, Stackframe{Err: err})
+	}
+	return frames, nil
+}
+
+// appendInlineCalls calls callback for each of the calls inlined at the
+// last pc of frame, innermost first, and then for frame itself.
+func (it *stackIterator) appendInlineCalls(callback func(Stackframe) bool, frame Stackframe) bool {
+	if frame.Call.Fn == nil {
+		return callback(frame)
+	}
+	if frame.Call.Fn.cu.lineInfo == nil {
+		return callback(frame)
+	}
+
+	callpc := frame.lastpc
+
+	dwarfTree, err := frame.Call.Fn.cu.image.getDwarfTree(frame.Call.Fn.offset)
+	if err != nil {
+		return callback(frame)
+	}
+
+	for _, entry := range reader.InlineStack(dwarfTree, callpc) {
+		fnname, okname := entry.Val(dwarf.AttrName).(string)
+		fileidx, okfileidx := entry.Val(dwarf.AttrCallFile).(int64)
+		line, okline := entry.Val(dwarf.AttrCallLine).(int64)
+
+		if !okname || !okfileidx || !okline {
+			break
+		}
+		var e *dwarf.Entry
+		filepath, fileErr := frame.Call.Fn.cu.filePath(int(fileidx), e)
+		if fileErr != nil {
+			break
+		}
+
+		inlfn := &Function{Name: fnname, Entry: frame.Call.Fn.Entry, End: frame.Call.Fn.End, offset: entry.Offset, cu: frame.Call.Fn.cu}
+		if !callback(Stackframe{
+			Current:     frame.Current,
+			Call:        Location{PC: frame.Call.PC, File: frame.Call.File, Line: frame.Call.Line, Fn: inlfn},
+			Regs:        frame.Regs,
+			stackHi:     frame.stackHi,
+			Ret:         frame.Ret,
+			Err:         frame.Err,
+			SystemStack: frame.SystemStack,
+			Inlined:     true,
+			lastpc:      frame.lastpc,
+		}) {
+			return false
+		}
+
+		frame.Call.File = filepath
+		frame.Call.Line = int(line)
+	}
+
+	return callback(frame)
+}
+
+// advanceRegs calculates the DwarfRegisters for a next stack frame
+// (corresponding to it.pc).
+//
+// The computation uses the registers for the current stack frame (it.regs) and
+// the corresponding Frame Descriptor Entry (FDE) retrieved from the DWARF info.
+//
+// The new set of registers is returned. it.regs is not updated, except for
+// it.regs.CFA; the caller has to eventually switch it.regs when the iterator
+// advances to the next frame.
+func (it *stackIterator) advanceRegs() (callFrameRegs op.DwarfRegisters, ret uint64, retaddr uint64) {
+	logger := logflags.StackLogger()
+
+	fde, err := it.bi.frameEntries.FDEForPC(it.pc)
+	var framectx *frame.FrameContext
+	if _, nofde := err.(*frame.ErrNoFDEForPC); nofde {
+		framectx = it.bi.Arch.fixFrameUnwindContext(nil, it.pc, it.bi)
+	} else {
+		framectx = it.bi.Arch.fixFrameUnwindContext(fde.EstablishFrame(it.pc), it.pc, it.bi)
+	}
+
+	logger.Debugf("advanceRegs at %#x", it.pc)
+
+	cfareg, err := it.executeFrameRegRule(0, framectx.CFA, 0)
+	if cfareg == nil {
+		it.err = fmt.Errorf("CFA becomes undefined at PC %#x: %v", it.pc, err)
+		return op.DwarfRegisters{}, 0, 0
+	}
+	it.regs.CFA = int64(cfareg.Uint64Val)
+
+	callimage := it.bi.PCToImage(it.pc)
+
+	callFrameRegs = op.DwarfRegisters{
+		StaticBase: callimage.StaticBase,
+		ByteOrder:  it.regs.ByteOrder,
+		PCRegNum:   it.regs.PCRegNum,
+		SPRegNum:   it.regs.SPRegNum,
+		BPRegNum:   it.regs.BPRegNum,
+		LRRegNum:   it.regs.LRRegNum,
+	}
+
+	// According to the standard the compiler should be responsible for emitting
+	// rules for the RSP register so that it can then be used to calculate CFA,
+	// however neither Go nor GCC do this.
+	// In the following line we copy GDB's behaviour by assuming this is
+	// implicit.
+	// See also the comment in dwarf2_frame_default_init in
+	// $GDB_SOURCE/dwarf2/frame.c
+	callFrameRegs.AddReg(callFrameRegs.SPRegNum, cfareg)
+
+	for i, regRule := range framectx.Regs {
+		reg, err := it.executeFrameRegRule(i, regRule, it.regs.CFA)
+		if reg != nil {
+			logger.Debugf("\t%#x: %#x (%v)", i, reg.Uint64Val, err)
+		}
+		callFrameRegs.AddReg(i, reg)
+		if i == framectx.RetAddrReg {
+			if reg == nil {
+				if err == nil {
+					err = fmt.Errorf("undefined return address at %#x", it.pc)
+				}
+				it.err = err
+			} else {
+				ret = reg.Uint64Val
+			}
+			retaddr = uint64(it.regs.CFA + regRule.Offset)
+		}
+	}
+
+	if it.bi.Arch.usesLR {
+		if ret == 0 && it.regs.Reg(it.regs.LRRegNum) != nil {
+			ret = it.regs.Reg(it.regs.LRRegNum).Uint64Val
+		}
+	}
+
+	return callFrameRegs, ret, retaddr
+}
+
+func (it *stackIterator) executeFrameRegRule(regnum uint64, rule frame.DWRule, cfa int64) (*op.DwarfRegister, error) {
+	switch rule.Rule {
+	default:
+		fallthrough
+	case frame.RuleUndefined:
+		return nil, nil
+	case frame.RuleSameVal:
+		if it.regs.Reg(regnum) == nil {
+			return nil, nil
+		}
+		reg := *it.regs.Reg(regnum)
+		return &reg, nil
+	case frame.RuleOffset:
+		return it.readRegisterAt(regnum, uint64(cfa+rule.Offset))
+	case frame.RuleValOffset:
+		return op.DwarfRegisterFromUint64(uint64(cfa + rule.Offset)), nil
+	case frame.RuleRegister:
+		return it.regs.Reg(rule.Reg), nil
+	case frame.RuleExpression:
+		v, _, err := op.ExecuteStackProgram(it.regs, rule.Expression, it.bi.Arch.PtrSize(), it.mem.ReadMemory)
+		if err != nil {
+			return nil, err
+		}
+		return it.readRegisterAt(regnum, uint64(v))
+	case frame.RuleValExpression:
+		v, _, err := op.ExecuteStackProgram(it.regs, rule.Expression, it.bi.Arch.PtrSize(), it.mem.ReadMemory)
+		if err != nil {
+			return nil, err
+		}
+		return op.DwarfRegisterFromUint64(uint64(v)), nil
+	case frame.RuleArchitectural:
+		return nil, errors.New("architectural frame rules are unsupported")
+	case frame.RuleCFA:
+		if it.regs.Reg(rule.Reg) == nil {
+			return nil, nil
+		}
+		return op.DwarfRegisterFromUint64(uint64(int64(it.regs.Uint64Val(rule.Reg)) + rule.Offset)), nil
+	case frame.RuleFramePointer:
+		curReg := it.regs.Reg(rule.Reg)
+		if curReg == nil {
+			return nil, nil
+		}
+		if curReg.Uint64Val <= uint64(cfa) {
+			return it.readRegisterAt(regnum, curReg.Uint64Val)
+		}
+		newReg := *curReg
+		return &newReg, nil
+	}
+}
+
+func (it *stackIterator) readRegisterAt(regnum uint64, addr uint64) (*op.DwarfRegister, error) {
+	buf := make([]byte, it.bi.Arch.regSize(regnum))
+	_, err := it.mem.ReadMemory(buf, addr)
+	if err != nil {
+		return nil, err
+	}
+	return op.DwarfRegisterFromBytes(buf), nil
+}
+
+// loadG0SchedSP loads the value of g0.sched.sp of the M running the
+// goroutine, used to find the system stack frames of cgo callbacks.
+func (it *stackIterator) loadG0SchedSP() {
+	if it.g0_sched_sp_loaded {
+		return
+	}
+	it.g0_sched_sp_loaded = true
+	if it.g == nil {
+		return
+	}
+	mvar, _ := it.g.variable.structMember("m")
+	if mvar == nil {
+		return
+	}
+	mvar = mvar.maybeDereference()
+	if mvar.Addr == 0 {
+		return
+	}
+	g0var, _ := mvar.structMember("g0")
+	if g0var == nil {
+		return
+	}
+	g0, _ := g0var.parseG()
+	if g0 != nil {
+		it.g0_sched_sp = g0.SP
+	}
+}
@@ -0,0 +1,127 @@
+package proc
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// testThread is a thread stopped with the given pc, sp and bp.
+type testThread struct {
+	regs testRegisters
+}
+
+func (t *testThread) ThreadID() int                 { return 1 }
+func (t *testThread) Registers() (Registers, error) { return &t.regs, nil }
+
+type testRegisters struct {
+	pc, sp, bp uint64
+}
+
+func (r *testRegisters) PC() uint64               { return r.pc }
+func (r *testRegisters) SP() uint64               { return r.sp }
+func (r *testRegisters) BP() uint64               { return r.bp }
+func (r *testRegisters) LR() uint64               { return 0 }
+func (r *testRegisters) TLS() uint64              { return 0 }
+func (r *testRegisters) GAddr() (uint64, bool)    { return 0, false }
+func (r *testRegisters) Copy() (Registers, error) { c := *r; return &c, nil }
+
+func (r *testRegisters) Slice(floatingPoint bool) ([]Register, error) {
+	var regs []Register
+	regs = AppendUint64Register(regs, "Rip", r.pc)
+	regs = AppendUint64Register(regs, "Rsp", r.sp)
+	regs = AppendUint64Register(regs, "Rbp", r.bp)
+	return regs, nil
+}
+
+func TestGoroutineStacktraceRunning(t *testing.T) {
+	bi := NewBinaryInfo("linux", "amd64")
+	for _, status := range []uint64{Grunning, Gscan | Grunning} {
+		g := &G{Status: status, variable: &Variable{bi: bi}}
+		if _, err := GoroutineStacktrace(g, 10); !errors.Is(err, ErrGoroutineRunning) {
+			t.Errorf("stack of a running goroutine without thread: %v, want %v", err, ErrGoroutineRunning)
+		}
+	}
+}
+
+func TestGoroutineStacktrace(t *testing.T) {
+	scope := fixtureScope(t)
+	bi := scope.BinInfo
+	if bi.Arch.Name != "amd64" {
+		t.Skip("synthetic registers of amd64 only")
+	}
+	var mainFn, goexit *Function
+	for i := range bi.Functions {
+		switch bi.Functions[i].Name {
+		case "main.main":
+			mainFn = &bi.Functions[i]
+		case "runtime.goexit":
+			goexit = &bi.Functions[i]
+		}
+	}
+	if mainFn == nil || goexit == nil {
+		t.Fatal("main.main or runtime.goexit not found")
+	}
+
+	// a goroutine stack at 0x2000-0x3000 whose last frame is main.main,
+	// before its prologue, called by runtime.goexit, and a system stack at
+	// 0x8000
+	mem := &bufMemory{base: 0x2000, buf: make([]byte, 0x7000)}
+	binary.LittleEndian.PutUint64(mem.buf[0x2f00-mem.base:], goexit.Entry+1)
+	newG := func(status uint64) *G {
+		return &G{
+			ID:       1,
+			Status:   status,
+			PC:       mainFn.Entry + 1,
+			SP:       0x2f00,
+			BP:       0x2f80,
+			stack:    stack{lo: 0x2000, hi: 0x3000},
+			variable: &Variable{bi: bi, mem: mem},
+		}
+	}
+	check := func(name string, frames []Stackframe, want ...string) {
+		t.Helper()
+		if len(frames) != len(want) {
+			t.Fatalf("%s: %d frames, want %d: %+v", name, len(frames), len(want), frames)
+		}
+		for i, frame := range frames {
+			fn := "?"
+			if frame.Call.Fn != nil {
+				fn = frame.Call.Fn.Name
+			}
+			if frame.Err != nil || fn != want[i] {
+				t.Errorf("%s: frame %d in %s (%v), want %s", name, i, fn, frame.Err, want[i])
+			}
+		}
+		if !frames[len(frames)-1].Bottom {
+			t.Errorf("%s: last frame not at the bottom of the stack", name)
+		}
+	}
+
+	frames, err := GoroutineStacktrace(newG(Gwaiting), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("parked", frames, "main.main", "runtime.goexit")
+	if frames[0].SystemStack {
+		t.Error("frame of a parked goroutine on the system stack")
+	}
+
+	// running on the system stack, at an unknown pc: the stack switches to
+	// the goroutine stack saved in the G struct
+	g := newG(Grunning)
+	binary.LittleEndian.PutUint64(mem.buf[0x8000-mem.base:], 0x10)
+	g.Thread = &testThread{testRegisters{pc: 0x1, sp: 0x8000, bp: 0x8080}}
+	frames, err = GoroutineStacktrace(g, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("running", frames, "?", "main.main", "runtime.goexit")
+	if !frames[0].SystemStack || frames[1].SystemStack {
+		t.Errorf("system stack frames %v, %v, want true, false", frames[0].SystemStack, frames[1].SystemStack)
+	}
+
+	if frames, err := GoroutineStacktrace(newG(Gwaiting), 0); err != nil || len(frames) != 1 {
+		t.Errorf("stack of depth 0: %d frames, %v", len(frames), err)
+	}
+}
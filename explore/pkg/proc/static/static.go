@@ -0,0 +1,110 @@
+// Package static reads the memory of a process as it is before the process
+// starts, from the sections of its ELF executable, so that the initial
+// values of package variables can be inspected without running it.
+package static
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ErrReadOnly is returned when writing to the memory of an executable.
+var ErrReadOnly = errors.New("the memory of an executable can not be changed")
+
+// Executable is the initial memory of a process, made of the allocated
+// sections of its executable: .data, .noptrdata and .rodata among others are
+// read from the file, .bss and .noptrbss are all zeroes.
+type Executable struct {
+	// GOARCH is the architecture of the executable, e.g. "amd64"
+	GOARCH string
+	// Path is the path of the executable
+	Path string
+
+	f        *os.File
+	sections []*elf.Section // allocated sections, sorted by address
+}
+
+// Open opens the ELF executable at path.
+func Open(path string) (*Executable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &Executable{Path: path, f: f}
+	if err := e.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Executable) load() error {
+	ef, err := elf.NewFile(e.f)
+	if err != nil {
+		return fmt.Errorf("%s is not an ELF executable: %v", e.Path, err)
+	}
+	switch ef.Machine {
+	case elf.EM_X86_64:
+		e.GOARCH = "amd64"
+	case elf.EM_AARCH64:
+		e.GOARCH = "arm64"
+	default:
+		return fmt.Errorf("unsupported architecture %v", ef.Machine)
+	}
+
+	for _, sec := range ef.Sections {
+		if sec.Flags&elf.SHF_ALLOC == 0 || sec.Size == 0 {
+			continue
+		}
+		// thread local sections do not have an address of their own
+		if sec.Flags&elf.SHF_TLS != 0 {
+			continue
+		}
+		e.sections = append(e.sections, sec)
+	}
+	sort.Slice(e.sections, func(i, j int) bool { return e.sections[i].Addr < e.sections[j].Addr })
+	return nil
+}
+
+// ReadMemory reads len(data) bytes at addr from the sections of the
+// executable.
+func (e *Executable) ReadMemory(data []byte, addr uint64) (int, error) {
+	n := 0
+	for n < len(data) {
+		cur := addr + uint64(n)
+		sec := e.find(cur)
+		if sec == nil {
+			return n, fmt.Errorf("could not read memory at %#x: address not in any section of the executable", cur)
+		}
+		size := int(min(uint64(len(data)-n), sec.Addr+sec.Size-cur))
+		if sec.Type == elf.SHT_NOBITS {
+			clear(data[n : n+size])
+		} else if _, err := sec.ReadAt(data[n:n+size], int64(cur-sec.Addr)); err != nil {
+			return n, err
+		}
+		n += size
+	}
+	return n, nil
+}
+
+// find returns the section containing addr, nil if there is none.
+func (e *Executable) find(addr uint64) *elf.Section {
+	i := sort.Search(len(e.sections), func(i int) bool { return e.sections[i].Addr+e.sections[i].Size > addr })
+	if i < len(e.sections) && e.sections[i].Addr <= addr {
+		return e.sections[i]
+	}
+	return nil
+}
+
+// WriteMemory always fails, the memory of an executable can not be changed.
+func (e *Executable) WriteMemory(addr uint64, data []byte) (int, error) {
+	return 0, ErrReadOnly
+}
+
+// Close closes the executable.
+func (e *Executable) Close() error {
+	return e.f.Close()
+}
@@ -0,0 +1,53 @@
+package static
+
+import (
+	"bytes"
+	"debug/elf"
+	"os"
+	"testing"
+)
+
+func TestReadMemory(t *testing.T) {
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ef, err := elf.Open(path)
+	if err != nil {
+		t.Skipf("test binary is not an ELF file: %v", err)
+	}
+	defer ef.Close()
+
+	exe, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exe.Close()
+
+	rodata := ef.Section(".rodata")
+	want, err := rodata.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(want))
+	if n, err := exe.ReadMemory(buf, rodata.Addr); err != nil || n != len(buf) {
+		t.Fatalf("ReadMemory = %d, %v", n, err)
+	}
+	if !bytes.Equal(buf, want) {
+		t.Error("contents of .rodata differ")
+	}
+
+	if bss := ef.Section(".noptrbss"); bss != nil {
+		buf := bytes.Repeat([]byte{0xff}, int(min(bss.Size, 64)))
+		if _, err := exe.ReadMemory(buf, bss.Addr); err != nil || !bytes.Equal(buf, make([]byte, len(buf))) {
+			t.Errorf("ReadMemory of .noptrbss = %x, %v", buf, err)
+		}
+	}
+
+	if _, err := exe.ReadMemory(buf[:8], 0); err == nil {
+		t.Error("read at address 0 did not fail")
+	}
+	if _, err := exe.WriteMemory(rodata.Addr, []byte{0}); err != ErrReadOnly {
+		t.Errorf("WriteMemory = %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+// The fixture is the process whose variables the tests of proc read: it
+// prints ready once its variables are initialized and exits when its
+// standard input is closed.
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+type Point struct {
+	X, Y int
+}
+
+type Config struct {
+	Name   string
+	Port   uint16
+	Points []Point
+	Tags   [3]string
+	Limits map[string]int
+	Next   *Config
+}
+
+var (
+	cfg = &Config{
+		Name:   "api",
+		Port:   8080,
+		Points: []Point{{1, 2}, {3, 4}, {5, 6}},
+		Tags:   [3]string{"a", "b", "c"},
+		Limits: map[string]int{"api": 100, "web": 20},
+	}
+	pathErr = &fs.PathError{Op: "open", Path: "/etc/app.conf", Err: fs.ErrNotExist}
+	digest  = [16]byte{0xde, 0xad, 0xbe, 0xef}
+	numbers []int
+	text    string
+	squares map[int]int
+	points  []*Point
+)
+
+// worker blocks until ch is closed.
+func worker(ch chan int) {
+	for range ch {
+	}
+}
+
+func main() {
+	numbers = make([]int, 1000)
+	for i := range numbers {
+		numbers[i] = i
+	}
+	text = fmt.Sprint("0123456789abcdefghijklmnopqrstuvwxyz")
+	squares = make(map[int]int)
+	for i := range 100 {
+		squares[i] = i * i
+	}
+	// large enough for its type to be recorded in an allocation header
+	points = make([]*Point, 100)
+	for i := range points {
+		points[i] = &Point{i, i}
+	}
+	fmt.Fprintln(io.Discard, cfg, pathErr, digest)
+	ch := make(chan int)
+	go worker(ch)
+
+	fmt.Println("ready")
+	io.Copy(io.Discard, os.Stdin)
+}
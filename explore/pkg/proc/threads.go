@@ -0,0 +1,62 @@
+package proc
+
+// Thread is a thread of the process whose registers are known, e.g. a
+// thread saved in a core file.
+type Thread interface {
+	ThreadID() int
+	Registers() (Registers, error)
+}
+
+// SetGoroutineThreads records in each running goroutine of gs the thread
+// executing it. Threads are matched to goroutines through the M structs of
+// the runtime, read from runtime.allm: m.procid is the id of the thread and
+// m.curg the goroutine it runs.
+func SetGoroutineThreads(bi *BinaryInfo, mem MemoryReadWriter, gs []*G, threads []Thread) error {
+	if len(threads) == 0 {
+		return nil
+	}
+	allm, err := findGlobal(bi, mem, "runtime", "allm")
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[uint64]Thread, len(threads))
+	for _, th := range threads {
+		byID[uint64(th.ThreadID())] = th
+	}
+	byAddr := make(map[uint64]*G, len(gs))
+	for _, g := range gs {
+		if g.Unreadable == nil {
+			byAddr[g.variable.Addr] = g
+		}
+	}
+
+	// the list is short, the bound only protects against a corrupted one
+	m := allm.maybeDereference()
+	for i := 0; i < len(threads)+1024 && m.Addr != 0 && m.Unreadable == nil; i++ {
+		tid, err1 := m.readUintField("procid")
+		gaddr, err2 := m.readUintField("curg")
+		if err1 == nil && err2 == nil && gaddr != 0 {
+			if th, ok := byID[tid]; ok {
+				if g := byAddr[gaddr]; g != nil {
+					g.Thread = th
+				}
+			}
+		}
+		next, err := m.structMember("alllink")
+		if err != nil {
+			return err
+		}
+		m = next.maybeDereference()
+	}
+	return nil
+}
+
+// readUintField reads the integer or pointer field name of the struct v.
+func (v *Variable) readUintField(name string) (uint64, error) {
+	f, err := v.structMember(name)
+	if err != nil {
+		return 0, err
+	}
+	return readUintRaw(f.mem, f.Addr, f.RealType.Size())
+}
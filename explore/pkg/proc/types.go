@@ -21,6 +21,22 @@ const (
 	kindMask        = (1 << 5) - 1 // +rtype kindMask|internal/abi.KindMask
 )
 
+// isDirectIface reports whether values of type typ are stored directly in
+// the data word of interfaces: pointers and the types holding a single
+// pointer. Recent runtimes flag them in the tflag field rather than in the
+// kind, this reads it from the layout of the type instead.
+func isDirectIface(typ godwarf.Type) bool {
+	switch t := godwarf.ResolveTypedef(typ).(type) {
+	case *godwarf.PtrType, *godwarf.ChanType, *godwarf.MapType, *godwarf.FuncType:
+		return true
+	case *godwarf.StructType:
+		return len(t.Field) == 1 && isDirectIface(t.Field[0].Type)
+	case *godwarf.ArrayType:
+		return t.Count == 1 && isDirectIface(t.Type)
+	}
+	return false
+}
+
 type runtimeTypeDIE struct {
 	offset dwarf.Offset
 	kind   int64
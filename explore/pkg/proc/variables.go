@@ -20,7 +20,7 @@ import (
 
 	"explore/pkg/dwarf/godwarf"
 	"explore/pkg/dwarf/op"
-	//"explore/pkg/goversion"
+	"explore/pkg/goversion"
 	//"explore/pkg/logflags"
 )
 
@@ -49,6 +49,8 @@ const (
 	FakeAddressBase     = 0xbeef000000000000
 	fakeAddressUnresolv = 0xbeed000000000000 // this address never resolves to memory
 
+	goDictionaryName = ".dict"       // name of the dictionary argument of generic functions
+	goClosurePtr     = ".closureptr" // name of the variable holding the closure pointer
 )
 
 type floatSpecial uint8
@@ -140,12 +142,21 @@ type Variable struct {
 
 	// number of elements to skip when loading a map
 	mapSkip int
+	// position to start loading a map from, entries are skipped after it
+	mapCursor *MapCursor
 
 	// Children lists the variables sub-variables. What constitutes a child
 	// depends on the variable's type. For pointers, there's one child
 	// representing the pointed-to variable.
 	Children []Variable
 
+	// Offset is the index of the first element in Children for a page of
+	// a collection loaded by LoadPage
+	Offset int64
+	// MapCursor is the position of the next page of a map loaded by
+	// LoadPage, nil if the page reaches the end of the map
+	MapCursor *MapCursor
+
 	loaded     bool
 	Unreadable error
 
@@ -211,6 +222,11 @@ const (
 	Gdead                         // 6
 	Genqueue                      // 7 Only the Gscanenqueue is used.
 	Gcopystack                    // 8 in this state when newstack is moving the stack
+	Gpreempted                    // 9 stopped by a suspendG preemption
+	Gleaked                       // 10 blocked forever, reported by the goroutine leak detector
+	Gdeadextra                    // 11 dead, reserved for an extra M of cgo callbacks
+
+	Gscan uint64 = 0x1000 // set in addition to the status while the stack is scanned
 )
 
 // G represents a runtime G (goroutine) structure (at least the
@@ -232,16 +248,20 @@ type G struct {
 	SystemStack bool // SystemStack is true if this goroutine is currently executing on a system stack.
 
 	// Information on goroutine location
-	//CurrentLoc Location
+	CurrentLoc Location
 
-	// Thread that this goroutine is currently allocated to
-	//Thread Thread
+	// Thread that this goroutine is currently allocated to, only known for
+	// running goroutines of processes whose threads were recorded, see
+	// SetGoroutineThreads.
+	Thread Thread
 
 	variable *Variable
 
 	Unreadable error // could not read the G struct
 
 	labels *map[string]string // G's pprof labels, computed on demand in Labels() method
+
+	waitReasons []string // names of the wait reasons, from runtime.waitReasonStrings
 }
 
 // stack represents a stack span in the target process.
@@ -249,6 +269,11 @@ type stack struct {
 	hi, lo uint64
 }
 
+// Stack returns the lowest and the highest address of the stack of g.
+func (g *G) Stack() (lo, hi uint64) {
+	return g.stack.lo, g.stack.hi
+}
+
 type Ancestor struct {
 	ID         int64 // Goroutine ID
 	Unreadable error
@@ -508,137 +533,131 @@ func (ng ErrNoGoroutine) Error() string {
 	return fmt.Sprintf("no G executing on thread %d", ng.tid)
 }
 
-//var ErrUnreadableG = errors.New("could not read G struct")
+var ErrUnreadableG = errors.New("could not read G struct")
 
-//func (v *Variable) parseG() (*G, error) {
-//	mem := v.mem
-//	gaddr := v.Addr
-//	_, deref := v.RealType.(*godwarf.PtrType)
-//
-//	if deref {
-//		var err error
-//		gaddr, err = readUintRaw(mem, gaddr, int64(v.bi.Arch.PtrSize()))
-//		if err != nil {
-//			return nil, fmt.Errorf("error derefing *G %s", err)
-//		}
-//	}
-//	if gaddr == 0 {
-//		id := 0
-//		if thread, ok := mem.(Thread); ok {
-//			id = thread.ThreadID()
-//		}
-//		return nil, ErrNoGoroutine{tid: id}
-//	}
-//	isptr := func(t godwarf.Type) bool {
-//		_, ok := t.(*godwarf.PtrType)
-//		return ok
-//	}
-//	for isptr(v.RealType) {
-//		v = v.maybeDereference() // +rtype g
-//	}
-//
-//	v.mem = cacheMemory(v.mem, v.Addr, int(v.RealType.Size()))
-//
-//	schedVar := v.loadFieldNamed("sched") // +rtype gobuf
-//	if schedVar == nil {
-//		return nil, ErrUnreadableG
-//	}
-//	pc, _ := constant.Int64Val(schedVar.fieldVariable("pc").Value) // +rtype uintptr
-//	sp, _ := constant.Int64Val(schedVar.fieldVariable("sp").Value) // +rtype uintptr
-//	var bp, lr int64
-//	if bpvar := schedVar.fieldVariable("bp"); /* +rtype -opt uintptr */ bpvar != nil && bpvar.Value != nil {
-//		bp, _ = constant.Int64Val(bpvar.Value)
-//	}
-//	if lrvar := schedVar.fieldVariable("lr"); /* +rtype -opt uintptr */ lrvar != nil && lrvar.Value != nil {
-//		lr, _ = constant.Int64Val(lrvar.Value)
-//	}
-//
-//	unreadable := false
-//
-//	loadInt64Maybe := func(name string) int64 {
-//		vv := v.loadFieldNamed(name)
-//		if vv == nil {
-//			unreadable = true
-//			return 0
-//		}
-//		n, _ := constant.Int64Val(vv.Value)
-//		return n
-//	}
-//
-//	loadUint64Maybe := func(name string) uint64 {
-//		vv := v.loadFieldNamed(name)
-//		if vv == nil {
-//			unreadable = true
-//			return 0
-//		}
-//		n, _ := constant.Uint64Val(vv.Value)
-//		return n
-//	}
-//
-//	id := loadUint64Maybe("goid")            // +rtype int64|uint64
-//	gopc := loadInt64Maybe("gopc")           // +rtype uintptr
-//	startpc := loadInt64Maybe("startpc")     // +rtype uintptr
-//	waitSince := loadInt64Maybe("waitsince") // +rtype int64
-//	waitReason := int64(0)
-//	if producer := v.bi.Producer(); producer != "" && goversion.ProducerAfterOrEqual(producer, 1, 11) {
-//		waitReason = loadInt64Maybe("waitreason") // +rtype -opt waitReason
-//	}
-//	var stackhi, stacklo uint64
-//	if stackVar := v.loadFieldNamed("stack"); /* +rtype stack */ stackVar != nil {
-//		if stackhiVar := stackVar.fieldVariable("hi"); /* +rtype uintptr */ stackhiVar != nil && stackhiVar.Value != nil {
-//			stackhi, _ = constant.Uint64Val(stackhiVar.Value)
-//		} else {
-//			unreadable = true
-//		}
-//		if stackloVar := stackVar.fieldVariable("lo"); /* +rtype uintptr */ stackloVar != nil && stackloVar.Value != nil {
-//			stacklo, _ = constant.Uint64Val(stackloVar.Value)
-//		} else {
-//			unreadable = true
-//		}
-//	}
-//
-//	status := uint64(0)
-//	if atomicStatus := v.loadFieldNamed("atomicstatus"); /* +rtype uint32|runtime/internal/atomic.Uint32|internal/runtime/atomic.Uint32 */ atomicStatus != nil {
-//		if constant.Val(atomicStatus.Value) != nil {
-//			status, _ = constant.Uint64Val(atomicStatus.Value)
-//		} else {
-//			atomicStatus := atomicStatus              // +rtype runtime/internal/atomic.Uint32|internal/runtime/atomic.Uint32
-//			vv := atomicStatus.fieldVariable("value") // +rtype uint32
-//			if vv == nil {
-//				unreadable = true
-//			} else {
-//				status, _ = constant.Uint64Val(vv.Value)
-//			}
-//		}
-//	} else {
-//		unreadable = true
-//	}
-//
-//	if unreadable {
-//		return nil, ErrUnreadableG
-//	}
-//
-//	f, l, fn := v.bi.PCToLine(uint64(pc))
-//
-//	v.Name = "runtime.curg"
-//
-//	g := &G{
-//		ID:         int64(id),
-//		GoPC:       uint64(gopc),
-//		StartPC:    uint64(startpc),
-//		PC:         uint64(pc),
-//		SP:         uint64(sp),
-//		BP:         uint64(bp),
-//		LR:         uint64(lr),
-//		Status:     status,
-//		WaitSince:  waitSince,
-//		WaitReason: waitReason,
-//		CurrentLoc: Location{PC: uint64(pc), File: f, Line: l, Fn: fn},
-//		variable:   v,
-//		stack:      stack{hi: stackhi, lo: stacklo},
-//	}
-//	return g, nil
-//}
+func (v *Variable) parseG() (*G, error) {
+	mem := v.mem
+	gaddr := v.Addr
+	_, deref := v.RealType.(*godwarf.PtrType)
+
+	if deref {
+		var err error
+		gaddr, err = readUintRaw(mem, gaddr, int64(v.bi.Arch.PtrSize()))
+		if err != nil {
+			return nil, fmt.Errorf("error derefing *G %s", err)
+		}
+	}
+	if gaddr == 0 {
+		return nil, ErrNoGoroutine{}
+	}
+	isptr := func(t godwarf.Type) bool {
+		_, ok := t.(*godwarf.PtrType)
+		return ok
+	}
+	for isptr(v.RealType) {
+		v = v.maybeDereference() // +rtype g
+	}
+
+	schedVar := v.loadFieldNamed("sched") // +rtype gobuf
+	if schedVar == nil {
+		return nil, ErrUnreadableG
+	}
+	pc, _ := constant.Int64Val(schedVar.fieldVariable("pc").Value) // +rtype uintptr
+	sp, _ := constant.Int64Val(schedVar.fieldVariable("sp").Value) // +rtype uintptr
+	var bp, lr int64
+	if bpvar := schedVar.fieldVariable("bp"); /* +rtype -opt uintptr */ bpvar != nil && bpvar.Value != nil {
+		bp, _ = constant.Int64Val(bpvar.Value)
+	}
+	if lrvar := schedVar.fieldVariable("lr"); /* +rtype -opt uintptr */ lrvar != nil && lrvar.Value != nil {
+		lr, _ = constant.Int64Val(lrvar.Value)
+	}
+
+	unreadable := false
+
+	loadInt64Maybe := func(name string) int64 {
+		vv := v.loadFieldNamed(name)
+		if vv == nil {
+			unreadable = true
+			return 0
+		}
+		n, _ := constant.Int64Val(vv.Value)
+		return n
+	}
+
+	loadUint64Maybe := func(name string) uint64 {
+		vv := v.loadFieldNamed(name)
+		if vv == nil {
+			unreadable = true
+			return 0
+		}
+		n, _ := constant.Uint64Val(vv.Value)
+		return n
+	}
+
+	id := loadUint64Maybe("goid")            // +rtype int64|uint64
+	gopc := loadInt64Maybe("gopc")           // +rtype uintptr
+	startpc := loadInt64Maybe("startpc")     // +rtype uintptr
+	waitSince := loadInt64Maybe("waitsince") // +rtype int64
+	waitReason := int64(0)
+	if producer := v.bi.Producer(); producer != "" && goversion.ProducerAfterOrEqual(producer, 1, 11) {
+		waitReason = loadInt64Maybe("waitreason") // +rtype -opt waitReason
+	}
+	var stackhi, stacklo uint64
+	if stackVar := v.loadFieldNamed("stack"); /* +rtype stack */ stackVar != nil {
+		if stackhiVar := stackVar.fieldVariable("hi"); /* +rtype uintptr */ stackhiVar != nil && stackhiVar.Value != nil {
+			stackhi, _ = constant.Uint64Val(stackhiVar.Value)
+		} else {
+			unreadable = true
+		}
+		if stackloVar := stackVar.fieldVariable("lo"); /* +rtype uintptr */ stackloVar != nil && stackloVar.Value != nil {
+			stacklo, _ = constant.Uint64Val(stackloVar.Value)
+		} else {
+			unreadable = true
+		}
+	}
+
+	status := uint64(0)
+	if atomicStatus := v.loadFieldNamed("atomicstatus"); /* +rtype uint32|runtime/internal/atomic.Uint32|internal/runtime/atomic.Uint32 */ atomicStatus != nil {
+		if constant.Val(atomicStatus.Value) != nil {
+			status, _ = constant.Uint64Val(atomicStatus.Value)
+		} else {
+			atomicStatus := atomicStatus              // +rtype runtime/internal/atomic.Uint32|internal/runtime/atomic.Uint32
+			vv := atomicStatus.fieldVariable("value") // +rtype uint32
+			if vv == nil {
+				unreadable = true
+			} else {
+				status, _ = constant.Uint64Val(vv.Value)
+			}
+		}
+	} else {
+		unreadable = true
+	}
+
+	if unreadable {
+		return nil, ErrUnreadableG
+	}
+
+	f, l, fn := v.bi.PCToLine(uint64(pc))
+
+	v.Name = "runtime.curg"
+
+	g := &G{
+		ID:         int64(id),
+		GoPC:       uint64(gopc),
+		StartPC:    uint64(startpc),
+		PC:         uint64(pc),
+		SP:         uint64(sp),
+		BP:         uint64(bp),
+		LR:         uint64(lr),
+		Status:     status,
+		WaitSince:  waitSince,
+		WaitReason: waitReason,
+		CurrentLoc: Location{PC: uint64(pc), File: f, Line: l, Fn: fn},
+		variable:   v,
+		stack:      stack{hi: stackhi, lo: stacklo},
+	}
+	return g, nil
+}
 
 func (v *Variable) loadFieldNamed(name string) *Variable {
 	v, err := v.structMember(name)
@@ -1170,7 +1189,7 @@ func convertToEface(srcv, dstv *Variable) error {
 	if err != nil {
 		return fmt.Errorf("can not convert value of type %s to %s: %v", srcv.DwarfType.String(), dstv.DwarfType.String(), err)
 	}
-	if !runtimeTypeFound || typeKind&kindDirectIface == 0 {
+	if !runtimeTypeFound || (typeKind&kindDirectIface == 0 && !isDirectIface(srcv.RealType)) {
 		return &typeConvErr{srcv.DwarfType, dstv.RealType}
 	}
 	return dstv.writeEmptyInterface(typeAddr, srcv)
@@ -1707,10 +1726,23 @@ func (v *Variable) loadMap(recurseLevel int, cfg LoadConfig) {
 		return
 	}
 
-	if v.Len == 0 || int64(v.mapSkip) >= v.Len || cfg.MaxArrayValues == 0 {
+	skipped := int64(v.mapSkip)
+	if v.mapCursor != nil {
+		skipped += v.mapCursor.Entries
+		v.Offset = skipped
+	}
+
+	if v.Len == 0 || skipped >= v.Len || cfg.MaxArrayValues == 0 {
 		return
 	}
 
+	if v.mapCursor != nil {
+		if err := it.seek(v.mapCursor); err != nil {
+			v.Unreadable = err
+			return
+		}
+	}
+
 	for skip := 0; skip < v.mapSkip; skip++ {
 		if ok := it.next(); !ok {
 			v.Unreadable = errors.New("map index out of bounds")
@@ -1737,6 +1769,11 @@ func (v *Variable) loadMap(recurseLevel int, cfg LoadConfig) {
 			break
 		}
 	}
+
+	if v.mapCursor != nil && skipped+int64(count) < v.Len && v.Unreadable == nil {
+		v.MapCursor = it.cursor()
+		v.MapCursor.Entries = skipped + int64(count)
+	}
 }
 
 func (v *Variable) readInterface() (_type, data *Variable, isnil bool) {
@@ -1824,7 +1861,7 @@ func (v *Variable) loadInterface(recurseLevel int, loadData bool, cfg LoadConfig
 	}
 
 	deref := false
-	if kind&kindDirectIface == 0 {
+	if kind&kindDirectIface == 0 && !isDirectIface(typ) {
 		realtyp := godwarf.ResolveTypedef(typ)
 		if _, isptr := realtyp.(*godwarf.PtrType); !isptr {
 			typ = pointerTo(typ, v.bi.Arch)
@@ -1839,8 +1876,16 @@ func (v *Variable) loadInterface(recurseLevel int, loadData bool, cfg LoadConfig
 	}
 
 	v.Children = []Variable{*data}
-	if loadData && recurseLevel <= cfg.MaxVariableRecurse {
-		v.Children[0].loadValueInternal(recurseLevel, cfg)
+	if loadData {
+		// the dynamic value is loaded past the recursion limit too, so that
+		// its concrete type and its address are known; its own children
+		// still stop at the limit, but the ones of the value pointer-shaped
+		// values point to, which are one level further
+		lvl := recurseLevel
+		if v.Children[0].Kind == reflect.Ptr {
+			lvl = min(recurseLevel, cfg.MaxVariableRecurse)
+		}
+		v.Children[0].loadValueInternal(lvl, cfg)
 	} else {
 		v.Children[0].OnlyAddr = true
 	}
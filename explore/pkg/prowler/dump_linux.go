@@ -0,0 +1,88 @@
+//go:build linux && (amd64 || arm64)
+
+package prowler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"explore/pkg/proc/core"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Dump saves the state of the process to w as an ELF core file that can be
+// opened with NewCoreProwler: the readable memory mappings, the registers
+// of every thread, the auxiliary vector and the build IDs of the
+// executable, used to check that the dump is opened with the same binary.
+// The threads of the process are stopped until the dump is complete.
+func (p *Prowler) Dump(w io.WriteSeeker) error {
+	if !p.live() {
+		return fmt.Errorf("only live processes can be dumped")
+	}
+
+	// ptrace requests must all come from the thread that attached.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	stopped, err := p.stopThreads()
+	defer resumeThreads(stopped)
+	if err != nil {
+		return err
+	}
+
+	d := &core.Dump{Mem: p.mem}
+	for _, th := range stopped {
+		regs, err := threadRegs(th.tid)
+		if err != nil {
+			return fmt.Errorf("could not read the registers of thread %d: %v", th.tid, err)
+		}
+		d.Threads = append(d.Threads, core.ThreadRegs{Tid: th.tid, Regs: regs})
+	}
+
+	maps, err := parseProcMaps(p.pid)
+	if err != nil {
+		return err
+	}
+	for _, m := range maps {
+		d.Mappings = append(d.Mappings, core.Mapping{Start: m.Start, End: m.End, Perms: m.Perms, Offset: m.Offset, Path: m.Path})
+	}
+
+	d.Auxv, err = os.ReadFile(fmt.Sprintf("/proc/%d/auxv", p.pid))
+	if err != nil {
+		return fmt.Errorf("could not read auxiliary vector: %v", err)
+	}
+
+	procExe := fmt.Sprintf("/proc/%d/exe", p.pid)
+	exe, err := os.Readlink(procExe)
+	if err != nil {
+		return err
+	}
+	gnu, goID, err := core.ExeBuildIDs(procExe)
+	if err != nil {
+		return err
+	}
+	d.Info = core.DumpInfo{
+		Pid:       p.pid,
+		Exe:       exe,
+		GOOS:      p.bi.GOOS,
+		GOARCH:    p.bi.Arch.Name,
+		BuildID:   gnu,
+		GoBuildID: goID,
+		Time:      time.Now(),
+	}
+
+	return core.Write(w, d)
+}
+
+// regsWords returns the registers in regs, a struct made of 64 bit
+// registers in the layout used by ptrace, as a slice.
+func regsWords(regs any) []uint64 {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, regs)
+	words := make([]uint64, buf.Len()/8)
+	binary.Read(&buf, binary.LittleEndian, words)
+	return words
+}
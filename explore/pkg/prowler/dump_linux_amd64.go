@@ -0,0 +1,13 @@
+package prowler
+
+import "golang.org/x/sys/unix"
+
+// threadRegs returns the registers of the stopped thread tid in the layout
+// of struct user_regs_struct.
+func threadRegs(tid int) ([]uint64, error) {
+	var regs unix.PtraceRegs
+	if err := unix.PtraceGetRegs(tid, &regs); err != nil {
+		return nil, err
+	}
+	return regsWords(&regs), nil
+}
@@ -0,0 +1,13 @@
+package prowler
+
+import "golang.org/x/sys/unix"
+
+// threadRegs returns the registers of the stopped thread tid in the layout
+// of struct user_pt_regs.
+func threadRegs(tid int) ([]uint64, error) {
+	var regs unix.PtraceRegsArm64
+	if err := unix.PtraceGetRegSetArm64(tid, 1 /* NT_PRSTATUS */, &regs); err != nil {
+		return nil, err
+	}
+	return regsWords(&regs), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux || !(amd64 || arm64)
+
+package prowler
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+func (p *Prowler) Dump(w io.WriteSeeker) error {
+	return fmt.Errorf("dumping processes is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+}
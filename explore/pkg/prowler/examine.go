@@ -0,0 +1,148 @@
+package prowler
+
+import (
+	"explore/pkg/proc"
+	"explore/pkg/proc/desc"
+	"fmt"
+	"go/constant"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// maxExamineLen is the largest number of bytes read by Examine.
+const maxExamineLen = 1 << 20
+
+// ExamineOptions are how much memory Examine reads and how it is printed.
+type ExamineOptions struct {
+	// Format is one of desc.MemoryFormats, hex by default
+	Format string `json:"format,omitempty"`
+	// Count is the number of values read, 16 by default and 64 for str
+	Count int `json:"count,omitempty"`
+	// Size is the size in bytes of each value: 1, 2, 4 or 8. The default
+	// is 1, or the size of pointers for ptr
+	Size int `json:"size,omitempty"`
+}
+
+// Examine reads the memory of the process at the address expr evaluates
+// to. expr is either an address, e.g. 0xc000012340, or a Go expression:
+// the memory pointed to by pointers, the backing array of slices and
+// strings, the value of integers and otherwise the memory of the value
+// itself is read. Pointers that land on functions or package variables
+// are annotated with their names.
+func (p *Prowler) Examine(expr string, opts ExamineOptions) (*desc.Memory, error) {
+	p.refresh()
+	if err := p.examineDefaults(&opts); err != nil {
+		return nil, err
+	}
+
+	addr, err := p.examineAddr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &desc.Memory{Addr: addr, Format: opts.Format, Size: opts.Size, Data: make([]byte, opts.Count*opts.Size)}
+	if _, err := p.ReadMemory(m.Data, addr); err != nil {
+		return nil, fmt.Errorf("could not read %d bytes at %#x: %v", len(m.Data), addr, err)
+	}
+
+	if opts.Format == desc.MemoryPtr {
+		for i := 0; i < opts.Count; i++ {
+			ptr := m.Value(i)
+			name, base := p.bi.SymLookup(ptr)
+			if name == "" || ptr == 0 {
+				continue
+			}
+			if m.Symbols == nil {
+				m.Symbols = make(map[int]string)
+			}
+			if ptr != base {
+				name = fmt.Sprintf("%s+%#x", name, ptr-base)
+			}
+			m.Symbols[i] = name
+		}
+	}
+	return m, nil
+}
+
+// examineDefaults checks opts and fills in the defaults of the fields left
+// empty.
+func (p *Prowler) examineDefaults(opts *ExamineOptions) error {
+	if opts.Format == "" {
+		opts.Format = desc.MemoryHex
+	}
+	if !slices.Contains(desc.MemoryFormats, opts.Format) {
+		return fmt.Errorf("unknown format %q, expected one of %v", opts.Format, desc.MemoryFormats)
+	}
+
+	size := 0
+	switch opts.Format {
+	case desc.MemoryChar, desc.MemoryStr:
+		size = 1
+	case desc.MemoryPtr:
+		size = p.bi.Arch.PtrSize()
+	}
+	switch {
+	case size != 0 && opts.Size != 0 && opts.Size != size:
+		return fmt.Errorf("the size of %s values is %d", opts.Format, size)
+	case size != 0:
+		opts.Size = size
+	case opts.Size == 0:
+		opts.Size = 1
+	case opts.Size != 1 && opts.Size != 2 && opts.Size != 4 && opts.Size != 8:
+		return fmt.Errorf("invalid size %d, expected 1, 2, 4 or 8", opts.Size)
+	}
+
+	if opts.Count == 0 {
+		opts.Count = 16
+		if opts.Format == desc.MemoryStr {
+			opts.Count = 64
+		}
+	}
+	if opts.Count < 0 || opts.Count*opts.Size > maxExamineLen {
+		return fmt.Errorf("invalid count %d, at most %d bytes can be read", opts.Count, maxExamineLen)
+	}
+	return nil
+}
+
+// examineAddr returns the address of the memory examined for expr.
+func (p *Prowler) examineAddr(expr string) (uint64, error) {
+	if addr, err := strconv.ParseUint(expr, 0, 64); err == nil {
+		return addr, nil
+	}
+
+	v, err := p.scope().EvalExpression(expr, proc.LoadConfig{})
+	if err != nil {
+		return 0, err
+	}
+	if v.Unreadable != nil {
+		return 0, v.Unreadable
+	}
+
+	switch v.Kind {
+	case reflect.Ptr, reflect.UnsafePointer:
+		if len(v.Children) == 0 || v.Children[0].Addr == 0 {
+			return 0, fmt.Errorf("%s is nil", expr)
+		}
+		return v.Children[0].Addr, nil
+	case reflect.Slice, reflect.String:
+		if v.Base == 0 {
+			return 0, fmt.Errorf("%s is nil", expr)
+		}
+		return v.Base, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Value == nil {
+			return 0, fmt.Errorf("%s has no value", expr)
+		}
+		addr, ok := constant.Uint64Val(constant.ToInt(v.Value))
+		if !ok {
+			return 0, fmt.Errorf("%s is not a valid address", v.Value)
+		}
+		return addr, nil
+	}
+	if v.Addr == 0 {
+		return 0, fmt.Errorf("%s has no address", expr)
+	}
+	return v.Addr, nil
+}
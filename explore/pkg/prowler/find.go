@@ -0,0 +1,279 @@
+package prowler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"explore/pkg/proc"
+	"explore/pkg/proc/desc"
+	"explore/utils"
+	"fmt"
+	"go/constant"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// findChunkSize is the size of the reads of the memory of the process
+// while searching it.
+const findChunkSize = 1 << 20
+
+// findPageSize is the granularity of the memory that can not be read.
+const findPageSize = 4096
+
+// DefaultMaxMatches is the number of matches after which Find stops.
+const DefaultMaxMatches = 1000
+
+// Pattern is a sequence of bytes searched for in the memory of the process.
+type Pattern struct {
+	Bytes []byte
+	// Align is the alignment of the matches, e.g. 8 for an uint64
+	Align uint64
+}
+
+// StringPattern searches for the bytes of s.
+func StringPattern(s string) (Pattern, error) {
+	if s == "" {
+		return Pattern{}, fmt.Errorf("empty string")
+	}
+	return Pattern{Bytes: []byte(s), Align: 1}, nil
+}
+
+// HexPattern searches for the bytes written in hexadecimal in s, spaces
+// between them are ignored, e.g. "de ad be ef".
+func HexPattern(s string) (Pattern, error) {
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil || len(b) == 0 {
+		return Pattern{}, fmt.Errorf("invalid hex bytes %q", s)
+	}
+	return Pattern{Bytes: b, Align: 1}, nil
+}
+
+// Uint64Pattern searches for the aligned 64 bit integer n, e.g. an ID or
+// a pointer.
+func Uint64Pattern(s string) (Pattern, error) {
+	n, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return Pattern{}, fmt.Errorf("invalid uint64 %q", s)
+	}
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, n)
+	return Pattern{Bytes: b, Align: 8}, nil
+}
+
+// Find searches the readable memory mappings of the process for pat and
+// returns the matches grouped by mapping and kind of memory, with the
+// package variable or the heap object holding them when known. The search
+// stops after max matches.
+func (p *Prowler) Find(pat Pattern, max int) (*desc.MemorySearch, error) {
+	if !p.live() {
+		return nil, fmt.Errorf("only live processes can be searched")
+	}
+	if max <= 0 {
+		max = DefaultMaxMatches
+	}
+	p.refresh()
+
+	maps, err := parseProcMaps(p.pid)
+	if err != nil {
+		return nil, err
+	}
+	o := p.newOwners()
+
+	res := &desc.MemorySearch{}
+	n := 0
+	buf := make([]byte, findChunkSize+len(pat.Bytes)-1)
+	for _, m := range maps {
+		if len(m.Perms) == 0 || m.Perms[0] != 'r' || m.Path == "[vvar]" || m.Path == "[vvar_vclock]" || m.Path == "[vsyscall]" {
+			continue
+		}
+		more := searchMemory(p.mem, m.Start, m.End, pat, buf, func(match uint64) bool {
+			if n >= max {
+				res.Truncated = true
+				return false
+			}
+			addMatch(res, o, m, match)
+			n++
+			return true
+		})
+		if !more {
+			break
+		}
+	}
+	return res, nil
+}
+
+// searchMemory calls found with the addresses of the matches of pat in the
+// memory of mem from start to end, read in chunks of the size of buf, until
+// found returns false, in which case it returns false too. Reads can be
+// short, e.g. at a page of a mapping past the end of its file: only the
+// bytes read are searched, and the search goes on after them, at the next
+// page if nothing could be read.
+func searchMemory(mem proc.MemoryReader, start, end uint64, pat Pattern, buf []byte, found func(addr uint64) bool) bool {
+	overlap := len(pat.Bytes) - 1
+	for addr := start; addr < end; {
+		chunk := buf[:min(uint64(len(buf)), end-addr)]
+		n, _ := mem.ReadMemory(chunk, addr)
+		if n <= 0 {
+			addr = (addr + findPageSize) &^ (findPageSize - 1)
+			continue
+		}
+		chunk = chunk[:n]
+		next := n
+		if n == len(buf) && addr+uint64(n) < end {
+			// the matches starting in the last bytes are found again by
+			// the next read
+			next -= overlap
+		}
+
+		for off := 0; ; off++ {
+			i := bytes.Index(chunk[off:], pat.Bytes)
+			if i < 0 {
+				break
+			}
+			off += i
+			if off >= next {
+				break
+			}
+			match := addr + uint64(off)
+			if match%pat.Align != 0 {
+				continue
+			}
+			if !found(match) {
+				return false
+			}
+		}
+		addr += uint64(next)
+	}
+	return true
+}
+
+// addMatch records the match addr in the mapping m.
+func addMatch(res *desc.MemorySearch, o *owners, m MemoryRegion, addr uint64) {
+	kind := o.kind(m, addr)
+	if l := len(res.Mappings); l == 0 || res.Mappings[l-1].Start != m.Start || res.Mappings[l-1].Kind != kind {
+		res.Mappings = append(res.Mappings, desc.MemoryMatches{Kind: kind, Start: m.Start, End: m.End, Perms: m.Perms, Path: m.Path})
+	}
+	mm := &res.Mappings[len(res.Mappings)-1]
+	mm.Matches = append(mm.Matches, desc.MemoryMatch{Addr: addr, Owner: o.owner(addr)})
+}
+
+// owners finds the kind of memory holding an address of the process and
+// the package variable or the heap object containing it.
+type owners struct {
+	p        *Prowler
+	exe      string
+	vars     []ownerVar // sorted by address
+	sections []section
+	heap     *proc.Heap // nil if the layout of the heap is not known
+	gs       []*proc.G  // loaded when a match is in a goroutine stack
+}
+
+type ownerVar struct {
+	name       string
+	addr, size uint64
+}
+
+// section is a range of addresses of the executable, from the module data
+// of the runtime.
+type section struct {
+	kind       string
+	start, end uint64
+}
+
+func (p *Prowler) newOwners() *owners {
+	o := &owners{p: p}
+	o.exe = utils.FindExecutable("", p.pid)
+
+	for _, v := range p.vars {
+		if v.Addr == 0 || v.ty == nil || *v.ty == nil {
+			continue
+		}
+		o.vars = append(o.vars, ownerVar{name: v.Name, addr: v.Addr, size: uint64((*v.ty).Size())})
+	}
+	sort.Slice(o.vars, func(i, j int) bool { return o.vars[i].addr < o.vars[j].addr })
+
+	field := func(name string) uint64 {
+		v, err := p.scope().EvalExpression("runtime.firstmoduledata."+name, proc.LoadConfig{})
+		if err != nil || v.Value == nil {
+			return 0
+		}
+		n, _ := constant.Uint64Val(v.Value)
+		return n
+	}
+	for _, s := range []struct{ kind, start, end string }{
+		{"text", "text", "etext"},
+		{"data", "noptrdata", "enoptrdata"},
+		{"data", "data", "edata"},
+		{"bss", "bss", "ebss"},
+		{"bss", "noptrbss", "enoptrbss"},
+	} {
+		if start, end := field(s.start), field(s.end); start < end {
+			o.sections = append(o.sections, section{kind: s.kind, start: start, end: end})
+		}
+	}
+
+	// without the heap matches are still reported, only not their owners
+	o.heap, _ = proc.NewHeap(p.bi, p)
+	return o
+}
+
+// kind returns the kind of memory holding addr, in the mapping m.
+func (o *owners) kind(m MemoryRegion, addr uint64) string {
+	for _, s := range o.sections {
+		if addr >= s.start && addr < s.end {
+			return s.kind
+		}
+	}
+	if o.heap != nil {
+		if s := o.heap.SpanOf(addr); s != nil && s.Manual {
+			return "stack"
+		}
+		if o.heap.Contains(addr) {
+			return "heap"
+		}
+	}
+	switch {
+	case m.Path == "[heap]":
+		return "heap"
+	case strings.HasPrefix(m.Path, "[stack"):
+		return "stack"
+	case m.Path == o.exe:
+		return "rodata"
+	case m.Path == "":
+		return "anon"
+	case strings.HasPrefix(m.Path, "["):
+		return strings.Trim(m.Path, "[]")
+	}
+	return "file"
+}
+
+// owner describes the package variable, the heap object or the goroutine
+// stack containing addr, empty if it is not known.
+func (o *owners) owner(addr uint64) string {
+	i := sort.Search(len(o.vars), func(i int) bool { return o.vars[i].addr > addr }) - 1
+	if i >= 0 && addr < o.vars[i].addr+max(o.vars[i].size, 1) {
+		if off := addr - o.vars[i].addr; off > 0 {
+			return fmt.Sprintf("%s+%#x", o.vars[i].name, off)
+		}
+		return o.vars[i].name
+	}
+
+	if o.heap == nil {
+		return ""
+	}
+	if base, size, ok := o.heap.ObjectOf(addr); ok {
+		return fmt.Sprintf("heap object %#x+%#x (%d bytes)", base, addr-base, size)
+	}
+	if s := o.heap.SpanOf(addr); s != nil && s.Manual {
+		if o.gs == nil {
+			o.gs, _ = proc.Goroutines(o.p.bi, o.p)
+		}
+		for _, g := range o.gs {
+			if lo, hi := g.Stack(); g.Unreadable == nil && addr >= lo && addr < hi {
+				return fmt.Sprintf("stack of goroutine %d", g.ID)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package prowler
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestPatterns(t *testing.T) {
+	pat, err := HexPattern("de ad  beef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0xde, 0xad, 0xbe, 0xef}; !bytes.Equal(pat.Bytes, want) || pat.Align != 1 {
+		t.Errorf("HexPattern = %x/%d, want %x/1", pat.Bytes, pat.Align, want)
+	}
+
+	pat, err = Uint64Pattern("0x1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x34, 0x12, 0, 0, 0, 0, 0, 0}; !bytes.Equal(pat.Bytes, want) || pat.Align != 8 {
+		t.Errorf("Uint64Pattern = %x/%d, want %x/8", pat.Bytes, pat.Align, want)
+	}
+
+	for _, bad := range []func() (Pattern, error){
+		func() (Pattern, error) { return HexPattern("de a") },
+		func() (Pattern, error) { return HexPattern(" ") },
+		func() (Pattern, error) { return Uint64Pattern("-1") },
+		func() (Pattern, error) { return StringPattern("") },
+	} {
+		if _, err := bad(); err == nil {
+			t.Error("invalid pattern accepted")
+		}
+	}
+}
+
+// sparseMemory is memory whose unreadable pages are missing, the reads
+// stop short at them without an error, as process_vm_readv does.
+type sparseMemory struct {
+	pages map[uint64][]byte // by address, findPageSize bytes each
+}
+
+func (m *sparseMemory) ReadMemory(data []byte, addr uint64) (int, error) {
+	n := 0
+	for n < len(data) {
+		a := addr + uint64(n)
+		page, ok := m.pages[a&^(findPageSize-1)]
+		if !ok {
+			break
+		}
+		n += copy(data[n:], page[a&(findPageSize-1):])
+	}
+	return n, nil
+}
+
+func TestSearchMemory(t *testing.T) {
+	mem := &sparseMemory{pages: make(map[uint64][]byte)}
+	for _, addr := range []uint64{0x1000, 0x3000, 0x4000} {
+		mem.pages[addr] = make([]byte, findPageSize)
+	}
+	copy(mem.pages[0x1000][0xff0:], "secret")
+	// across two pages
+	copy(mem.pages[0x3000][0xffd:], "sec")
+	copy(mem.pages[0x4000], "ret")
+	copy(mem.pages[0x4000][0x800:], "secret")
+
+	pat, _ := StringPattern("secret")
+	for _, size := range []int{0x1800, 0x100, 0x10} {
+		// the bytes of a previous read left in the buffer are not searched
+		buf := bytes.Repeat([]byte("secret"), (size+len(pat.Bytes)-1)/len(pat.Bytes)+1)[:size+len(pat.Bytes)-1]
+		var got []uint64
+		searchMemory(mem, 0x1000, 0x5000, pat, buf, func(addr uint64) bool {
+			got = append(got, addr)
+			return true
+		})
+		if want := []uint64{0x1ff0, 0x3ffd, 0x4800}; !slices.Equal(got, want) {
+			t.Errorf("chunks of %#x bytes: matches %#x, want %#x", size, got, want)
+		}
+	}
+}
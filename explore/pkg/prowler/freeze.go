@@ -0,0 +1,34 @@
+package prowler
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultFreezeTimeout is how long the memory of the process is accessed
+// while it is stopped by Freeze when no timeout is given.
+const DefaultFreezeTimeout = 500 * time.Millisecond
+
+// ErrFreezeTimeout is returned by the memory accesses of a Freeze after its
+// timeout, the result of the read or the write is incomplete.
+var ErrFreezeTimeout = errors.New("the freeze timeout passed while the process was stopped, the read or write is incomplete")
+
+// freezeState describes the process while it is stopped by Freeze.
+type freezeState struct {
+	deadline time.Time
+	tracer   int  // OS thread that stopped the process, running the frozen request
+	ptraced  bool // the threads are stopped with ptrace by tracer
+	expired  atomic.Bool
+}
+
+// checkFrozen fails once the deadline of a Freeze has passed, for the
+// memory accesses of the request that froze the process only.
+func (p *Prowler) checkFrozen() error {
+	f := p.frozen.Load()
+	if f == nil || time.Now().Before(f.deadline) || f.tracer != gettid() {
+		return nil
+	}
+	f.expired.Store(true)
+	return ErrFreezeTimeout
+}
@@ -0,0 +1,188 @@
+package prowler
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// freezePollInterval is how often the state of the process is checked
+// while waiting for it to stop without ptrace.
+const freezePollInterval = time.Millisecond
+
+// Freeze stops all the threads of the process, runs fn and resumes them, so
+// that the many reads of a large value, or a write, see the process in a
+// single state. The threads are stopped with ptrace, or with SIGSTOP or the
+// cgroup freezer if the process can not be traced. The timeout,
+// DefaultFreezeTimeout if it is zero, bounds the memory accesses of fn
+// only: those after it fail, so that fn returns soon, and Freeze returns
+// ErrFreezeTimeout. The process is not resumed before fn returns, ptraced
+// threads can only be resumed by the thread running fn. Freezes are
+// serialized. Core files and executables never change, fn is just run.
+func (p *Prowler) Freeze(timeout time.Duration, fn func() error) error {
+	if !p.live() {
+		return fn()
+	}
+	if timeout <= 0 {
+		timeout = DefaultFreezeTimeout
+	}
+
+	p.freezeMu.Lock()
+	defer p.freezeMu.Unlock()
+
+	// ptrace requests must all come from the thread that attached, fn runs
+	// on it too so that allocations reuse the stopped threads.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	f := &freezeState{deadline: time.Now().Add(timeout), tracer: gettid()}
+	stopped, err := p.stopThreads()
+	switch {
+	case err == nil:
+		f.ptraced = true
+		defer resumeThreads(stopped)
+	case len(stopped) == 0 && errors.Is(err, unix.EPERM):
+		thaw, err := p.sigstop(f.deadline)
+		if err != nil {
+			var cerr error
+			if thaw, cerr = p.freezeCgroup(f.deadline); cerr != nil {
+				return fmt.Errorf("could not stop the process: %v, %v", err, cerr)
+			}
+		}
+		defer thaw()
+	default:
+		resumeThreads(stopped)
+		return err
+	}
+
+	p.frozen.Store(f)
+	defer p.frozen.Store(nil)
+	err = fn()
+	if f.expired.Load() {
+		// the error of fn, if any, may be a consequence of the timeout
+		return ErrFreezeTimeout
+	}
+	return err
+}
+
+// sigstop stops the process with SIGSTOP and waits until all its threads
+// are stopped. The returned function resumes the process, unless it was
+// already stopped.
+func (p *Prowler) sigstop(deadline time.Time) (func(), error) {
+	if allThreadsStopped(p.pid) {
+		return func() {}, nil
+	}
+	if err := unix.Kill(p.pid, unix.SIGSTOP); err != nil {
+		return nil, fmt.Errorf("SIGSTOP: %v", err)
+	}
+	resume := func() { unix.Kill(p.pid, unix.SIGCONT) }
+
+	for !allThreadsStopped(p.pid) {
+		if time.Now().After(deadline) {
+			resume()
+			return nil, errors.New("SIGSTOP: timed out waiting for the threads to stop")
+		}
+		time.Sleep(freezePollInterval)
+	}
+	return resume, nil
+}
+
+// allThreadsStopped reports whether all the threads of pid are in the
+// stopped state.
+func allThreadsStopped(pid int) bool {
+	entries, err := os.ReadDir(fmt.Sprintf("/proc/%d/task", pid))
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/task/%s/stat", pid, entry.Name()))
+		if err != nil {
+			return false
+		}
+		// the state follows the command name, which is in parentheses
+		i := bytes.LastIndexByte(stat, ')')
+		if i < 0 || i+2 >= len(stat) || (stat[i+2] != 'T' && stat[i+2] != 't') {
+			return false
+		}
+	}
+	return true
+}
+
+// freezeCgroup stops the process with the freezer of its cgroup, cgroup v2
+// only. All the processes of the cgroup are stopped, which must not contain
+// this process. The returned function thaws the cgroup, unless it was
+// already frozen.
+func (p *Prowler) freezeCgroup(deadline time.Time) (func(), error) {
+	cg, err := procCgroup(p.pid)
+	if err != nil {
+		return nil, err
+	}
+	self, err := procCgroup(os.Getpid())
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(self+"/", strings.TrimSuffix(cg, "/")+"/") {
+		return nil, fmt.Errorf("cgroup freezer: the process shares cgroup %s with explore", cg)
+	}
+
+	dir := filepath.Join("/sys/fs/cgroup", cg)
+	freeze := filepath.Join(dir, "cgroup.freeze")
+	if state, err := os.ReadFile(freeze); err != nil {
+		return nil, fmt.Errorf("cgroup freezer: %v", err)
+	} else if strings.TrimSpace(string(state)) == "1" {
+		return func() {}, nil
+	}
+	if err := os.WriteFile(freeze, []byte("1"), 0); err != nil {
+		return nil, fmt.Errorf("cgroup freezer: %v", err)
+	}
+	thaw := func() { os.WriteFile(freeze, []byte("0"), 0) }
+
+	for !cgroupFrozen(dir) {
+		if time.Now().After(deadline) {
+			thaw()
+			return nil, errors.New("cgroup freezer: timed out waiting for the cgroup to freeze")
+		}
+		time.Sleep(freezePollInterval)
+	}
+	return thaw, nil
+}
+
+// procCgroup returns the cgroup v2 path of pid, from /proc/<pid>/cgroup.
+func procCgroup(pid int) (string, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if path, ok := strings.CutPrefix(s.Text(), "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("process %d is not in a cgroup v2 hierarchy", pid)
+}
+
+// cgroupFrozen reports whether the cgroup in dir is frozen, according to
+// its cgroup.events file.
+func cgroupFrozen(dir string) bool {
+	events, err := os.ReadFile(filepath.Join(dir, "cgroup.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(events), "\n") {
+		if line == "frozen 1" {
+			return true
+		}
+	}
+	return false
+}
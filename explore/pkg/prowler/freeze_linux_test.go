@@ -0,0 +1,43 @@
+package prowler
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestSigstop(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skip(err)
+	}
+	defer cmd.Process.Kill()
+
+	p := &Prowler{pid: cmd.Process.Pid}
+	resume, err := p.sigstop(time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !allThreadsStopped(p.pid) {
+		t.Error("process not stopped")
+	}
+
+	// stopping a stopped process must not resume it
+	again, err := p.sigstop(time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again()
+	if !allThreadsStopped(p.pid) {
+		t.Error("process resumed by the second sigstop")
+	}
+
+	resume()
+	deadline := time.Now().Add(time.Second)
+	for allThreadsStopped(p.pid) {
+		if time.Now().After(deadline) {
+			t.Fatal("process not resumed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
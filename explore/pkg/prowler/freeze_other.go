@@ -0,0 +1,16 @@
+//go:build !linux
+
+package prowler
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+func (p *Prowler) Freeze(timeout time.Duration, fn func() error) error {
+	if !p.live() {
+		return fn()
+	}
+	return fmt.Errorf("freezing processes is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+}
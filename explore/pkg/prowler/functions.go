@@ -0,0 +1,126 @@
+package prowler
+
+import (
+	"bufio"
+	e "explore/error"
+	"explore/pkg/proc"
+	"explore/pkg/proc/desc"
+	"fmt"
+	"os"
+)
+
+// flavours are the assembly syntaxes of Disassemble by name.
+var flavours = map[string]proc.AssemblyFlavour{
+	"intel": proc.IntelFlavour,
+	"gnu":   proc.GNUFlavour,
+	"go":    proc.GoFlavour,
+}
+
+// Function returns the location, the signature and how the compiler
+// handled the function name, e.g. main.(*Server).Serve.
+func (p *Prowler) Function(name string) (*desc.Function, error) {
+	fn, ok := p.functions[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, e.FunctionNotFound)
+	}
+	params, results, err := fn.Signature()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &desc.Function{
+		Name:      fn.Name,
+		Entry:     fn.Entry,
+		End:       fn.End,
+		Optimized: fn.Optimized(),
+		Inlined:   len(fn.InlinedCalls),
+	}
+	if fn.Entry != fn.End {
+		// functions inlined at all their call sites have no code, nor
+		// location
+		f.File, f.Line = p.bi.EntryLineForFunc(fn)
+	}
+	for _, param := range params {
+		f.Params = append(f.Params, desc.Param{Name: param.Name, Type: typeName(param.Type)})
+	}
+	for _, res := range results {
+		f.Results = append(f.Results, desc.Param{Name: res.Name, Type: typeName(res.Type)})
+	}
+	return f, nil
+}
+
+// Disassemble returns the machine instructions of the function name in the
+// assembly syntax flavour, intel, gnu or go, with the source lines they
+// were compiled from when the source files are on this machine.
+func (p *Prowler) Disassemble(name, flavour string) (*desc.Disassembly, error) {
+	fl, ok := flavours[flavour]
+	if !ok {
+		return nil, fmt.Errorf("unknown flavour %q, use intel, gnu or go", flavour)
+	}
+	fn, ok := p.functions[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, e.FunctionNotFound)
+	}
+	if fn.Entry == fn.End {
+		return nil, fmt.Errorf("%s has no code, all its calls are inlined", name)
+	}
+	insts, err := p.disassemble(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &desc.Disassembly{Function: fn.Name, Flavour: flavour, Source: make(map[string]map[int]string)}
+	wanted := make(map[string]map[int]bool)
+	for i := range insts {
+		inst := &insts[i]
+		d.Instructions = append(d.Instructions, desc.Instruction{
+			PC:    inst.Loc.PC,
+			Bytes: inst.Bytes,
+			Text:  inst.Text(fl, p.bi),
+			File:  inst.Loc.File,
+			Line:  inst.Loc.Line,
+		})
+		if wanted[inst.Loc.File] == nil {
+			wanted[inst.Loc.File] = make(map[int]bool)
+		}
+		wanted[inst.Loc.File][inst.Loc.Line] = true
+	}
+	for file, lines := range wanted {
+		if src := sourceLines(file, lines); src != nil {
+			d.Source[file] = src
+		}
+	}
+	return d, nil
+}
+
+// maxSourceLine is the length of the longest line of a source file read by
+// sourceLines, generated files can have long lines.
+const maxSourceLine = 1 << 20
+
+// sourceLines returns the lines of the source file path whose numbers are
+// in wanted, nil if it cannot be read, e.g. on another machine than the one
+// of the build.
+func sourceLines(path string, wanted map[int]bool) map[int]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	last := 0
+	for n := range wanted {
+		last = max(last, n)
+	}
+	lines := make(map[int]string, len(wanted))
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, maxSourceLine)
+	for n := 1; n <= last && s.Scan(); n++ {
+		if wanted[n] {
+			lines[n] = s.Text()
+		}
+	}
+	if s.Err() != nil {
+		return nil
+	}
+	return lines
+}
@@ -0,0 +1,41 @@
+package prowler
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSourceLines(t *testing.T) {
+	long := strings.Repeat("x", 100<<10)
+	path := filepath.Join(t.TempDir(), "src.go")
+	src := "package main\n" + long + "\nfunc main() {\n}\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the wanted lines, a line longer than the default of the scanner
+	// and a line past the end of the file
+	got := sourceLines(path, map[int]bool{2: true, 4: true, 9: true})
+	want := map[int]string{2: long, 4: "}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lines 2, 4 and 9: %d lines, want %d", len(got), len(want))
+	}
+	if got := sourceLines(path, map[int]bool{1: true}); !reflect.DeepEqual(got, map[int]string{1: "package main"}) {
+		t.Errorf("line 1: %q", got)
+	}
+
+	// a line too long for the scanner
+	tooLong := strings.Repeat("x", maxSourceLine+1)
+	if err := os.WriteFile(path, []byte("package main\n"+tooLong+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := sourceLines(path, map[int]bool{1: true, 2: true}); got != nil {
+		t.Errorf("lines of a file with a line too long: %d lines, want none", len(got))
+	}
+	if got := sourceLines(filepath.Join(t.TempDir(), "missing.go"), map[int]bool{1: true}); got != nil {
+		t.Errorf("lines of a missing file: %q", got)
+	}
+}
@@ -0,0 +1,370 @@
+package prowler
+
+import (
+	"bytes"
+	"explore/pkg/dwarf/godwarf"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GenTypes returns Go source declaring, in the package name, the named
+// types of the packages matching one of the patterns pkgs, e.g. main or
+// github.com/acme/svc/... for svc and the packages below it.
+//
+// The fields of structs keep their names, types and order, so that the
+// declared types have the layout of the ones of the process. The types of
+// other packages they use are imported from the standard library when
+// they are exported, otherwise they are declared as opaque types of the
+// same size and alignment. The methods of interfaces are not in the debug
+// information, interfaces are declared empty.
+func (p *Prowler) GenTypes(pkgs []string, name string) ([]byte, error) {
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no package given")
+	}
+	names, err := p.bi.Types()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	g := &typeGen{p: p, local: make(map[string]string), imports: make(map[string]string), stubs: make(map[string]string), std: p.stdPackages()}
+	var decls []string
+	for _, n := range names {
+		pkg := typePackage(n)
+		if pkg == "" || pkg == "unsafe" || !matchPackage(pkg, pkgs) || synthetic(n) {
+			continue
+		}
+		decls = append(decls, n)
+	}
+	if len(decls) == 0 {
+		return nil, fmt.Errorf("no type found in %s", strings.Join(pkgs, ", "))
+	}
+	g.localNames(decls)
+
+	var body bytes.Buffer
+	for _, n := range decls {
+		typ, err := p.bi.FindType(n)
+		if err != nil {
+			continue
+		}
+		g.declare(&body, n, typ)
+	}
+	stubs := make([]string, 0, len(g.stubs))
+	for _, s := range g.stubs {
+		stubs = append(stubs, s)
+	}
+	sort.Strings(stubs)
+	for _, s := range stubs {
+		body.WriteString(s)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by exp gentypes from the debug information of the process. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", name)
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		src.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&src, "\t%q\n", path)
+		}
+		src.WriteString(")\n\n")
+	}
+	src.Write(body.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return src.Bytes(), fmt.Errorf("the generated source is invalid: %v", err)
+	}
+	return out, nil
+}
+
+// matchPackage reports whether pkg matches one of the patterns, as the
+// patterns of the go command.
+func matchPackage(pkg string, patterns []string) bool {
+	for _, pat := range patterns {
+		if pat == "..." || pat == pkg {
+			return true
+		}
+		if base, ok := strings.CutSuffix(pat, "/..."); ok && (pkg == base || strings.HasPrefix(pkg, base+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeGen writes the declarations of the types of the process.
+type typeGen struct {
+	p *Prowler
+	// local are the names of the declared types in the generated source
+	local map[string]string
+	// imports are the package names of the imported packages by path
+	imports map[string]string
+	// stubs are the declarations of the opaque types by name
+	stubs map[string]string
+	std   func(pkg string) bool
+}
+
+// localNames names the types decls in the generated source by their name
+// in their package, qualified by the import path of the package if several
+// packages declare it.
+func (g *typeGen) localNames(decls []string) {
+	count := make(map[string]int)
+	for _, n := range decls {
+		count[identifier(baseName(n))]++
+	}
+	for _, n := range decls {
+		id := identifier(baseName(n))
+		if count[id] > 1 {
+			id = identifier(typePackage(n)) + "_" + id
+		}
+		g.local[n] = id
+	}
+}
+
+// declare writes the declaration of the type n.
+func (g *typeGen) declare(buf *bytes.Buffer, n string, typ godwarf.Type) {
+	rtyp := godwarf.ResolveTypedef(typ)
+	fmt.Fprintf(buf, "// %s, %d bytes\n", n, typ.Size())
+	fmt.Fprintf(buf, "type %s ", g.local[n])
+	switch t := rtyp.(type) {
+	case *godwarf.StructType:
+		buf.WriteString("struct {\n")
+		for _, f := range t.Field {
+			if f.Embedded {
+				fmt.Fprintf(buf, "\t%s\n", g.rewrite(typeName(f.Type)))
+			} else {
+				fmt.Fprintf(buf, "\t%s %s\n", f.Name, g.rewrite(typeName(f.Type)))
+			}
+		}
+		buf.WriteString("}\n\n")
+	case *godwarf.InterfaceType:
+		buf.WriteString("interface {\n\t// the methods are not in the debug information\n}\n\n")
+	default:
+		fmt.Fprintf(buf, "%s%s\n\n", g.rewrite(typeDef(typ)), funcParams(rtyp))
+	}
+}
+
+// rewrite returns the type written as s in the debug information, e.g.
+// map[string]*io/fs.PathError, with the qualified names of types replaced
+// by their names in the generated source.
+func (g *typeGen) rewrite(s string) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !unicode.IsLetter(r) && r != '_' {
+			buf.WriteRune(r)
+			i += size
+			continue
+		}
+		j := i
+		for j < len(s) {
+			r, size := utf8.DecodeRuneInString(s[j:])
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_./-~", r) {
+				break
+			}
+			j += size
+		}
+		if !strings.Contains(s[i:j], ".") {
+			// a keyword, a predeclared type or the name of a field
+			buf.WriteString(s[i:j])
+			i = j
+			continue
+		}
+		if j < len(s) && (s[j] == '[' || s[j] == '<') {
+			// the arguments of a generic type, or of an internal type of
+			// the runtime
+			j = closing(s, j)
+		}
+		if j+1 < len(s) && s[j] == ' ' && !strings.ContainsRune(";}", rune(s[j+1])) {
+			// the name of an unexported field of a struct, qualified by
+			// its package, followed by its type
+			buf.WriteString(baseName(s[i:j]))
+			i = j
+			continue
+		}
+		buf.WriteString(g.ref(s[i:j]))
+		i = j
+	}
+	return buf.String()
+}
+
+// closing returns the index after the bracket closing the one at i in s.
+func closing(s string, i int) int {
+	open, close := s[i], byte(']')
+	if open == '<' {
+		close = '>'
+	}
+	depth := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+// synthetic reports whether the type named n is made by the compiler for
+// its own use, e.g. the shapes of generic functions or the groups of maps.
+func synthetic(n string) bool {
+	return strings.Contains(n, "go.shape.") || strings.ContainsRune(n, '<') ||
+		strings.HasPrefix(n, "noalg.") || strings.HasPrefix(n, "go:")
+}
+
+// ref returns the name in the generated source of the type named n in the
+// debug information.
+func (g *typeGen) ref(n string) string {
+	if id, ok := g.local[n]; ok {
+		return id
+	}
+	pkg := typePackage(n)
+	base := baseName(n)
+	if n == "unsafe.Pointer" {
+		g.imports[pkg] = pkg
+		return n
+	}
+	if g.importable(pkg) && !strings.Contains(base, "[") && token.IsExported(base) {
+		pkgName := packageName(pkg)
+		taken := false
+		for path, name := range g.imports {
+			taken = taken || (name == pkgName && path != pkg)
+		}
+		if !taken {
+			g.imports[pkg] = pkgName
+			return pkgName + "." + base
+		}
+	}
+
+	id := identifier(pkg) + "_" + identifier(base)
+	if _, ok := g.stubs[id]; ok {
+		return id
+	}
+	typ, err := g.p.bi.FindType(n)
+	if err != nil {
+		g.stubs[id] = fmt.Sprintf("// %s, unknown type\ntype %s struct{}\n\n", n, id)
+		return id
+	}
+	if _, ok := godwarf.ResolveTypedef(typ).(*godwarf.InterfaceType); ok {
+		g.stubs[id] = fmt.Sprintf("// %s, opaque\ntype %s interface{}\n\n", n, id)
+		return id
+	}
+	size := typ.Size()
+	align := typeAlign(typ, int64(g.p.bi.Arch.PtrSize()))
+	elem := map[int64]string{1: "byte", 2: "uint16", 4: "uint32", 8: "uint64"}[align]
+	if elem == "" {
+		elem, align = "byte", 1
+	}
+	g.stubs[id] = fmt.Sprintf("// %s, opaque, %d bytes\ntype %s struct{ _ [%d]%s }\n\n", n, size, id, size/align, elem)
+	return id
+}
+
+// baseName returns the name of the named type n in its package.
+func baseName(n string) string {
+	name, args, _ := strings.Cut(n, "[")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if args != "" {
+		return name + "[" + args
+	}
+	return name
+}
+
+// packageName returns the name of the package with the import path pkg,
+// assuming it is its last element other than a major version.
+func packageName(pkg string) string {
+	elems := strings.Split(pkg, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = elems[len(elems)-2]
+	}
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// stdPackages returns whether the packages of the process are in the
+// standard library: the ones whose files are all in the directory of the
+// runtime, and the ones without code left but with a path outside of the
+// modules of the process.
+func (p *Prowler) stdPackages() func(pkg string) bool {
+	pkgs := p.bi.ListPackagesBuildInfo(true)
+	goroot := ""
+	for _, pkg := range pkgs {
+		if pkg.ImportPath == "runtime" {
+			// empty for executables built with -trimpath
+			goroot = strings.TrimSuffix(pkg.DirectoryPath, "runtime")
+		}
+	}
+	std := make(map[string]bool)
+	for _, pkg := range pkgs {
+		first := strings.SplitN(pkg.ImportPath, "/", 2)[0]
+		isStd := pkg.ImportPath != "main" && !strings.Contains(first, ".")
+		// the files of other packages are listed too when their functions
+		// are inlined, and names such as <autogenerated>
+		for file := range pkg.Files {
+			isStd = isStd && (strings.HasPrefix(file, goroot) || !strings.Contains(file, "/"))
+		}
+		std[pkg.ImportPath] = isStd
+	}
+	modules := make(map[string]bool)
+	for path, isStd := range std {
+		if !isStd {
+			modules[strings.SplitN(path, "/", 2)[0]] = true
+		}
+	}
+	return func(pkg string) bool {
+		if isStd, ok := std[pkg]; ok {
+			return isStd
+		}
+		first := strings.SplitN(pkg, "/", 2)[0]
+		return !strings.Contains(first, ".") && !modules[first]
+	}
+}
+
+// importable reports whether pkg is a package of the standard library
+// other packages can import.
+func (g *typeGen) importable(pkg string) bool {
+	if !g.std(pkg) || strings.HasPrefix(pkg, "vendor/") {
+		return false
+	}
+	for _, elem := range strings.Split(pkg, "/") {
+		if elem == "internal" {
+			return false
+		}
+	}
+	return true
+}
+
+// identifier returns s with the characters not allowed in identifiers,
+// e.g. the brackets of generic types, replaced by underscores.
+func identifier(s string) string {
+	var buf strings.Builder
+	under := false
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			buf.WriteRune(r)
+			under = false
+		} else if !under {
+			buf.WriteByte('_')
+			under = true
+		}
+	}
+	id := strings.TrimSuffix(buf.String(), "_")
+	if r, _ := utf8.DecodeRuneInString(id); !unicode.IsLetter(r) && r != '_' {
+		id = "_" + id
+	}
+	return id
+}
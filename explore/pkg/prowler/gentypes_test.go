@@ -0,0 +1,38 @@
+package prowler
+
+import "testing"
+
+func TestMatchPackage(t *testing.T) {
+	pats := []string{"main", "github.com/acme/svc/..."}
+	for pkg, want := range map[string]bool{
+		"main":                      true,
+		"github.com/acme/svc":       true,
+		"github.com/acme/svc/store": true,
+		"github.com/acme/svcx":      false,
+		"github.com/acme":           false,
+		"example.com/main":          false,
+	} {
+		if got := matchPackage(pkg, pats); got != want {
+			t.Errorf("matchPackage(%q) = %v, want %v", pkg, got, want)
+		}
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	for n, want := range map[string]string{
+		"main.Config":                   "Config",
+		"main.List[int]":                "List_int",
+		"main.Pair[string,*io/fs.File]": "Pair_string_io_fs_File",
+		"github.com/acme/svc.Cache":     "Cache",
+	} {
+		if got := identifier(baseName(n)); got != want {
+			t.Errorf("identifier(baseName(%q)) = %q, want %q", n, got, want)
+		}
+	}
+	if got, want := identifier("25d7"), "_25d7"; got != want {
+		t.Errorf("identifier(%q) = %q, want %q", "25d7", got, want)
+	}
+	if got, want := packageName("math/rand/v2"), "rand"; got != want {
+		t.Errorf("packageName = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+package prowler
+
+import (
+	"explore/pkg/proc"
+	"explore/pkg/proc/desc"
+	"fmt"
+	"strings"
+)
+
+// GoroutineFilter selects the goroutines returned by Goroutines, empty
+// fields match any goroutine.
+type GoroutineFilter struct {
+	// Status matches the status or the wait reason of the goroutine, e.g.
+	// "runnable" or "chan receive".
+	Status string
+	// StartFunc is a prefix of the name of the function started by the
+	// goroutine, e.g. "net/http." or "main.worker".
+	StartFunc string
+}
+
+func (f GoroutineFilter) match(g *desc.Goroutine) bool {
+	if f.Status != "" && !strings.EqualFold(f.Status, g.Status) && !strings.EqualFold(f.Status, g.WaitReason) {
+		return false
+	}
+	if f.StartFunc != "" && !strings.HasPrefix(g.StartLoc.Function, f.StartFunc) {
+		return false
+	}
+	return true
+}
+
+// Goroutines returns the goroutines of the process selected by filter.
+func (p *Prowler) Goroutines(filter GoroutineFilter) (desc.Goroutines, error) {
+	p.refresh()
+	gs, err := proc.Goroutines(p.bi, p)
+	if err != nil {
+		return nil, err
+	}
+
+	now := p.clock()
+	var r desc.Goroutines
+	for _, g := range gs {
+		dg := p.ToPrintGoroutine(g, now)
+		if !filter.match(dg) {
+			continue
+		}
+		r = append(r, dg)
+	}
+	return r, nil
+}
+
+func (p *Prowler) ToPrintGoroutine(g *proc.G, now int64) *desc.Goroutine {
+	if g.Unreadable != nil {
+		return &desc.Goroutine{Unreadable: g.Unreadable.Error()}
+	}
+
+	return &desc.Goroutine{
+		ID:             g.ID,
+		Status:         g.StatusString(),
+		WaitReason:     g.WaitReasonString(),
+		WaitFor:        g.WaitFor(now),
+		CurrentLoc:     toPrintLocation(g.CurrentLoc),
+		GoStatementLoc: toPrintLocation(g.Go()),
+		StartLoc:       toPrintLocation(g.StartLoc()),
+	}
+}
+
+func toPrintLocation(loc proc.Location) desc.Location {
+	l := desc.Location{PC: loc.PC, File: loc.File, Line: loc.Line}
+	if loc.Fn != nil {
+		l.Function = loc.Fn.Name
+	}
+	return l
+}
+
+// Stacktrace returns the stack of the parked goroutine gid, up to depth
+// frames.
+func (p *Prowler) Stacktrace(gid int64, depth int) (desc.Stacktrace, error) {
+	g, err := p.findGoroutine(gid)
+	if err != nil {
+		return nil, err
+	}
+
+	frames, err := proc.GoroutineStacktrace(g, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	r := make(desc.Stacktrace, 0, len(frames))
+	for _, frame := range frames {
+		if frame.Err != nil {
+			r = append(r, desc.Stackframe{Err: frame.Err.Error()})
+			continue
+		}
+		r = append(r, desc.Stackframe{Location: toPrintLocation(frame.Call), Inlined: frame.Inlined})
+	}
+	return r, nil
+}
+
+// FrameVariables returns the local variables, or the arguments if args is
+// set, of the frame of the parked goroutine gid. Frames are numbered as in
+// the output of Stacktrace, inlined calls included.
+func (p *Prowler) FrameVariables(gid int64, frame int, args bool) (desc.Variables, error) {
+	if frame < 0 {
+		return nil, fmt.Errorf("invalid frame %d", frame)
+	}
+	g, err := p.findGoroutine(gid)
+	if err != nil {
+		return nil, err
+	}
+
+	frames, err := proc.GoroutineStacktrace(g, frame+1)
+	if err != nil {
+		return nil, err
+	}
+	if frame >= len(frames) {
+		return nil, fmt.Errorf("frame %d does not exist", frame)
+	}
+	if frames[frame].Err != nil {
+		return nil, frames[frame].Err
+	}
+
+	scope := proc.FrameToScope(p.bi, p, frames[frame:]...)
+	var vars []*proc.Variable
+	if args {
+		vars, err = scope.FunctionArguments(p.LoadConfig)
+	} else {
+		vars, err = scope.LocalVariables(p.LoadConfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := make(desc.Variables, 0, len(vars))
+	for _, v := range vars {
+		r = append(r, p.ToPrintVar(v))
+	}
+	return r, nil
+}
+
+// findGoroutine returns the goroutine gid, with the thread running it if
+// the threads of the process are known.
+func (p *Prowler) findGoroutine(gid int64) (*proc.G, error) {
+	p.refresh()
+	g, err := proc.FindGoroutine(p.bi, p, gid)
+	if err != nil {
+		return nil, err
+	}
+	if err := proc.SetGoroutineThreads(p.bi, p, []*proc.G{g}, p.threads); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
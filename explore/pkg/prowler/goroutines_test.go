@@ -0,0 +1,33 @@
+package prowler
+
+import (
+	"explore/pkg/proc/desc"
+	"testing"
+)
+
+func TestGoroutineFilter(t *testing.T) {
+	worker := &desc.Goroutine{Status: "waiting", WaitReason: "chan receive", StartLoc: desc.Location{Function: "main.worker"}}
+	serve := &desc.Goroutine{Status: "runnable", StartLoc: desc.Location{Function: "net/http.(*conn).serve"}}
+	for _, test := range []struct {
+		filter GoroutineFilter
+		g      *desc.Goroutine
+		want   bool
+	}{
+		{GoroutineFilter{}, worker, true},
+		{GoroutineFilter{Status: "waiting"}, worker, true},
+		{GoroutineFilter{Status: "Chan Receive"}, worker, true},
+		{GoroutineFilter{Status: "chan send"}, worker, false},
+		{GoroutineFilter{Status: "waiting"}, serve, false},
+		{GoroutineFilter{Status: "runnable"}, serve, true},
+		{GoroutineFilter{StartFunc: "main.worker"}, worker, true},
+		{GoroutineFilter{StartFunc: "main."}, worker, true},
+		{GoroutineFilter{StartFunc: "net/http."}, worker, false},
+		{GoroutineFilter{StartFunc: "net/http."}, serve, true},
+		{GoroutineFilter{Status: "waiting", StartFunc: "main."}, worker, true},
+		{GoroutineFilter{Status: "runnable", StartFunc: "main."}, worker, false},
+	} {
+		if got := test.filter.match(test.g); got != test.want {
+			t.Errorf("%+v matches %s: %v, want %v", test.filter, test.g.StartLoc.Function, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package prowler
+
+import (
+	"explore/pkg/proc"
+	"explore/pkg/proc/desc"
+	"fmt"
+)
+
+// DefaultGraphDepth is the number of pointers followed from the variable
+// by Graph.
+const DefaultGraphDepth = 5
+
+// maxGraphNodes is the number of objects after which Graph stops.
+const maxGraphNodes = 10000
+
+// Graph returns the graph of the objects reachable from the value expr
+// evaluates to, following at most depth pointers, DefaultGraphDepth if
+// depth is not positive. The nodes are typed
+// objects and the edges are labelled with a field, an index or a key.
+// Objects reached again, e.g. in cycles, are only added once. The memory
+// of maps holding their entries is left out.
+func (p *Prowler) Graph(expr string, depth int) (*desc.Graph, error) {
+	if depth <= 0 {
+		depth = DefaultGraphDepth
+	}
+	p.refresh()
+	v, err := p.scope().EvalExpression(expr, proc.LoadConfig{})
+	if err != nil {
+		return nil, err
+	}
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	if v.Addr == 0 {
+		return nil, fmt.Errorf("%s has no address", expr)
+	}
+
+	heap, _ := proc.NewHeap(p.bi, p)
+	g := proc.NewObjectGraph(p.bi, p, heap)
+	g.MaxObjects = maxGraphNodes
+	g.AddRoot(expr, v.Addr, v.DwarfType)
+	// the objects of the last level are added but not walked
+	g.Walk(depth - 1)
+
+	res := &desc.Graph{Truncated: g.Truncated}
+	ids := map[int]int{0: 0}
+	node := func(i int) int {
+		id, ok := ids[i]
+		if !ok {
+			id = len(ids)
+			ids[i] = id
+		}
+		return id
+	}
+	for i, o := range g.Objects {
+		for _, e := range o.Edges {
+			if e.To < 0 || e.Addr == 0 {
+				continue
+			}
+			res.Edges = append(res.Edges, desc.GraphEdge{From: node(i), To: node(e.To), Label: e.Label})
+		}
+	}
+	res.Nodes = make([]desc.GraphNode, len(ids))
+	for i, id := range ids {
+		o := g.Objects[i]
+		res.Nodes[id] = desc.GraphNode{ID: id, Object: desc.Object{Addr: o.Addr, Size: o.Size, Type: o.Type, Name: o.Name, Static: o.Static}, Depth: o.Depth}
+	}
+	return res, nil
+}
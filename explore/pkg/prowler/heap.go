@@ -0,0 +1,36 @@
+package prowler
+
+import (
+	"explore/pkg/proc"
+	"explore/pkg/proc/desc"
+)
+
+// DefaultHeapTop is the number of types printed by the heap command.
+const DefaultHeapTop = 30
+
+// HeapInventory counts the allocated objects of the Go heap by type, from
+// the spans of the runtime and the types of allocation headers. Only the
+// top types with the most bytes are returned, all of them if top is 0.
+func (p *Prowler) HeapInventory(top int) (*desc.HeapInventory, error) {
+	p.refresh()
+	h, err := proc.NewHeap(p.bi, p)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := h.Inventory()
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &desc.HeapInventory{}
+	for _, o := range objs {
+		inv.Objects += o.Objects
+		inv.Bytes += o.Bytes
+		if top > 0 && len(inv.Types) >= top {
+			inv.Others++
+			continue
+		}
+		inv.Types = append(inv.Types, desc.HeapType{Type: o.Type, Size: o.Size, NoScan: o.NoScan, Objects: o.Objects, Bytes: o.Bytes})
+	}
+	return inv, nil
+}
@@ -0,0 +1,86 @@
+package prowler
+
+import (
+	"explore/pkg/proc"
+	"fmt"
+	"strconv"
+)
+
+// DefaultLoadConfig is how much of a value is read unless configured
+// otherwise: pointers are followed, nested values are read one level deep
+// and strings, arrays, slices and maps are truncated to 64 bytes or
+// elements.
+var DefaultLoadConfig = proc.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1, MaxMapBuckets: 64}
+
+// LoadOptions override some of the fields of a proc.LoadConfig, those left
+// nil keep their value. They are sent by clients along with get requests.
+type LoadOptions struct {
+	FollowPointers     *bool `json:"followPointers,omitempty"`
+	MaxVariableRecurse *int  `json:"maxVariableRecurse,omitempty"`
+	MaxStringLen       *int  `json:"maxStringLen,omitempty"`
+	MaxArrayValues     *int  `json:"maxArrayValues,omitempty"`
+	MaxStructFields    *int  `json:"maxStructFields,omitempty"`
+	MaxMapBuckets      *int  `json:"maxMapBuckets,omitempty"`
+}
+
+// Apply returns cfg with the fields set in o replaced.
+func (o *LoadOptions) Apply(cfg proc.LoadConfig) proc.LoadConfig {
+	if o == nil {
+		return cfg
+	}
+	if o.FollowPointers != nil {
+		cfg.FollowPointers = *o.FollowPointers
+	}
+	for _, f := range []struct {
+		opt *int
+		dst *int
+	}{
+		{o.MaxVariableRecurse, &cfg.MaxVariableRecurse},
+		{o.MaxStringLen, &cfg.MaxStringLen},
+		{o.MaxArrayValues, &cfg.MaxArrayValues},
+		{o.MaxStructFields, &cfg.MaxStructFields},
+		{o.MaxMapBuckets, &cfg.MaxMapBuckets},
+	} {
+		if f.opt != nil {
+			*f.dst = *f.opt
+		}
+	}
+	return cfg
+}
+
+// LoadOptionNames are the names of the options accepted by Set.
+var LoadOptionNames = []string{"follow-pointers", "max-variable-recurse", "max-string-len", "max-array-values", "max-struct-fields", "max-map-buckets"}
+
+// Set sets the option name, one of LoadOptionNames, to value.
+func (o *LoadOptions) Set(name, value string) error {
+	if name == "follow-pointers" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s", value, name)
+		}
+		o.FollowPointers = &b
+		return nil
+	}
+
+	var dst **int
+	switch name {
+	case "max-variable-recurse":
+		dst = &o.MaxVariableRecurse
+	case "max-string-len":
+		dst = &o.MaxStringLen
+	case "max-array-values":
+		dst = &o.MaxArrayValues
+	case "max-struct-fields":
+		dst = &o.MaxStructFields
+	case "max-map-buckets":
+		dst = &o.MaxMapBuckets
+	default:
+		return fmt.Errorf("unknown option %s", name)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s", value, name)
+	}
+	*dst = &n
+	return nil
+}
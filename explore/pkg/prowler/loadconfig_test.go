@@ -0,0 +1,31 @@
+package prowler
+
+import "testing"
+
+func TestLoadOptions(t *testing.T) {
+	var opts LoadOptions
+	for _, o := range []struct{ name, value string }{{"max-string-len", "1024"}, {"follow-pointers", "false"}, {"max-struct-fields", "-1"}} {
+		if err := opts.Set(o.name, o.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := opts.Set("max-array-values", "many"); err == nil {
+		t.Error("invalid value accepted")
+	}
+	if err := opts.Set("max-depth", "1"); err == nil {
+		t.Error("unknown option accepted")
+	}
+
+	cfg := opts.Apply(DefaultLoadConfig)
+	want := DefaultLoadConfig
+	want.MaxStringLen = 1024
+	want.FollowPointers = false
+	if cfg != want {
+		t.Errorf("Apply = %+v, want %+v", cfg, want)
+	}
+
+	var none *LoadOptions
+	if cfg := none.Apply(DefaultLoadConfig); cfg != DefaultLoadConfig {
+		t.Errorf("nil options changed the configuration: %+v", cfg)
+	}
+}
@@ -3,13 +3,9 @@ package prowler
 import (
 	"fmt"
 	"io/ioutil"
-	"sort"
 	"strconv"
 	"strings"
-)
-
-var (
-	allocated []MemoryRegion
+	"sync"
 )
 
 type MemoryRegion struct {
@@ -19,6 +15,7 @@ type MemoryRegion struct {
 	Offset uint64
 	Device string
 	Inode  uint64
+	Path   string
 }
 
 // 解析 /proc/[pid]/maps
@@ -52,35 +49,79 @@ func parseProcMaps(pid int) ([]MemoryRegion, error) {
 			Device: fields[3],
 			Inode:  parseHex(fields[4]),
 		}
+		if len(fields) > 5 {
+			region.Path = strings.Join(fields[5:], " ")
+		}
 		regions = append(regions, region)
 	}
 	return regions, nil
 }
 
-func findFreeMemory(pid int, minSize uint64) (uint64, error) {
-	regions, err := parseProcMaps(pid)
-	if err != nil {
-		return 0, err
+// allocChunkSize is the minimum size of the memory mapped into the target
+// every time the allocator runs out of space.
+const allocChunkSize = 64 << 10
+
+// allocator hands out memory in the target process. Memory is obtained in
+// chunks from a mmap syscall executed by the target itself, so it never
+// overlaps objects owned by the Go runtime, and chunks are carved up in
+// order, so that two allocations never overlap each other.
+//
+// The Go garbage collector does not scan this memory: values written here
+// must only point to other allocations or to memory that is kept alive by
+// the process itself.
+type allocator struct {
+	mmap   func(size uint64) (uint64, error)
+	chunks []MemoryRegion // memory mapped into the target
+	allocs []MemoryRegion // allocation table
+	next   uint64         // next free address in the last chunk
+	mu     sync.Mutex
+}
+
+func newAllocator(mmap func(size uint64) (uint64, error)) *allocator {
+	return &allocator{mmap: mmap}
+}
+
+// alloc returns the address of size bytes of unused memory aligned to align.
+func (a *allocator) alloc(size, align uint64) (uint64, error) {
+	if size == 0 {
+		return 0, nil
+	}
+	if align == 0 {
+		align = 1
 	}
 
-	// 查找匿名可写区（权限为 rw-p）
-	for _, r := range regions {
-		if r.Perms == "rw-p" && r.Offset == 0 && r.Inode == 0 {
-			if isRegionAllocated(allocated, r.Start, r.End) {
-				continue
-			}
-
-			if addr, found := findSpaceInRegion(allocated, r.Start, r.End, minSize); found {
-				// 记录分配
-				allocated = append(allocated, MemoryRegion{
-					Start: addr,
-					End:   addr + minSize,
-				})
-				return addr, nil
-			}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	addr := alignUp(a.next, align)
+	if len(a.chunks) == 0 || addr+size > a.chunks[len(a.chunks)-1].End {
+		chunkSize := alignUp(size, allocChunkSize)
+		start, err := a.mmap(chunkSize)
+		if err != nil {
+			return 0, fmt.Errorf("could not map memory in the target: %v", err)
 		}
+
+		chunk := MemoryRegion{Start: start, End: start + chunkSize, Perms: "rw-p"}
+		if isRegionAllocated(a.chunks, chunk.Start, chunk.End) {
+			return 0, fmt.Errorf("mapped memory %#x-%#x overlaps a previous mapping", chunk.Start, chunk.End)
+		}
+		a.chunks = append(a.chunks, chunk)
+		addr = alignUp(start, align)
 	}
-	return 0, fmt.Errorf("not found free memory")
+
+	a.allocs = append(a.allocs, MemoryRegion{Start: addr, End: addr + size})
+	a.next = addr + size
+	return addr, nil
+}
+
+// allocations returns a copy of the allocation table.
+func (a *allocator) allocations() []MemoryRegion {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r := make([]MemoryRegion, len(a.allocs))
+	copy(r, a.allocs)
+	return r
 }
 
 func isRegionAllocated(regions []MemoryRegion, start, end uint64) bool {
@@ -93,41 +134,8 @@ func isRegionAllocated(regions []MemoryRegion, start, end uint64) bool {
 	return false
 }
 
-func findSpaceInRegion(allocated []MemoryRegion, regionStart, regionEnd, minSize uint64) (uint64, bool) {
-	// 没有已分配区域，直接使用整个区域
-	if len(allocated) == 0 {
-		if regionEnd-regionStart >= minSize {
-			return regionStart, true
-		}
-		return 0, false
-	}
-
-	// 按起始地址排序
-	sort.Slice(allocated, func(i, j int) bool {
-		return allocated[i].Start < allocated[j].Start
-	})
-
-	// 检查区域开头的空间
-	if allocated[0].Start-regionStart >= minSize {
-		return regionStart, true
-	}
-
-	// 检查分配区域之间的空间
-	for i := 0; i < len(allocated)-1; i++ {
-		gapStart := allocated[i].End
-		gapEnd := allocated[i+1].Start
-		if gapEnd-gapStart >= minSize {
-			return gapStart, true
-		}
-	}
-
-	// 检查区域末尾的空间
-	last := allocated[len(allocated)-1]
-	if regionEnd-last.End >= minSize {
-		return last.End, true
-	}
-
-	return 0, false
+func alignUp(n, align uint64) uint64 {
+	return (n + align - 1) / align * align
 }
 
 func parseHex(s string) uint64 {
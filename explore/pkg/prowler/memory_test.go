@@ -2,6 +2,44 @@ package prowler
 
 import "testing"
 
-func TestFindFreeMemory(t *testing.T) {
-	//findFreeMemory()
+func TestAllocator(t *testing.T) {
+	var mapped []uint64
+	next := uint64(0x7f0000000000)
+	a := newAllocator(func(size uint64) (uint64, error) {
+		addr := next
+		next += size + 0x1000
+		mapped = append(mapped, size)
+		return addr, nil
+	})
+
+	sizes := []struct{ size, align uint64 }{{5, 1}, {16, 8}, {3, 1}, {24, 8}, {allocChunkSize, 8}, {1, 1}}
+	for _, s := range sizes {
+		addr, err := a.alloc(s.size, s.align)
+		if err != nil {
+			t.Fatalf("alloc(%d, %d): %v", s.size, s.align, err)
+		}
+		if addr%s.align != 0 {
+			t.Errorf("alloc(%d, %d) = %#x not aligned", s.size, s.align, addr)
+		}
+	}
+
+	allocs := a.allocations()
+	if len(allocs) != len(sizes) {
+		t.Fatalf("expected %d allocations, got %d", len(sizes), len(allocs))
+	}
+	for i, r := range allocs {
+		if !isRegionAllocated(a.chunks, r.Start, r.End) {
+			t.Errorf("allocation %#x-%#x is outside of mapped memory", r.Start, r.End)
+		}
+		if isRegionAllocated(append(allocs[:i:i], allocs[i+1:]...), r.Start, r.End) {
+			t.Errorf("allocation %#x-%#x overlaps another allocation", r.Start, r.End)
+		}
+	}
+	if len(mapped) != 3 {
+		t.Errorf("expected 3 mappings, got %d: %v", len(mapped), mapped)
+	}
+
+	if addr, err := a.alloc(0, 1); addr != 0 || err != nil {
+		t.Errorf("alloc(0) = %#x, %v", addr, err)
+	}
 }
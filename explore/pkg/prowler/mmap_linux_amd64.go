@@ -0,0 +1,178 @@
+package prowler
+
+import (
+	"fmt"
+	"runtime"
+
+	"golang.org/x/arch/x86/x86asm"
+	"golang.org/x/sys/unix"
+)
+
+// Kernel-internal error codes left in rax when a syscall is interrupted,
+// see include/linux/errno.h.
+const (
+	errRestartSys           = 512
+	errRestartNoIntr        = 513
+	errRestartNoHand        = 514
+	errRestartRestartBlock  = 516
+	sysRestartSyscall       = 219
+	maxSyscallInstrFuncSize = 4096
+)
+
+// syscallFuncs are functions of the runtime that contain a SYSCALL
+// instruction, the first one found in the target is used to inject syscalls.
+var syscallFuncs = []string{
+	"internal/runtime/syscall.Syscall6",
+	"runtime/internal/syscall.Syscall6",
+	"runtime.sysMmap",
+	"runtime.mmap",
+	"runtime.futex",
+}
+
+// mmap maps size bytes of anonymous read/write memory in the target. The
+// main thread of the target is stopped with ptrace, made to execute a mmap
+// syscall using a SYSCALL instruction that already exists in its text and
+// then resumed with its registers restored.
+func (p *Prowler) mmap(size uint64) (uint64, error) {
+	pc, err := p.findSyscallInstr()
+	if err != nil {
+		return 0, err
+	}
+
+	// ptrace requests must all come from the thread that attached.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	tid := p.pid
+	sig := 0
+	// when the process is stopped by Freeze on this thread the main thread
+	// is already attached, the signals reported while stopping it are
+	// delivered when it is resumed
+	if f := p.frozen.Load(); f == nil || !f.ptraced || f.tracer != gettid() {
+		if err := unix.PtraceSeize(tid); err != nil {
+			return 0, fmt.Errorf("could not attach to %d: %v", tid, err)
+		}
+		defer unix.PtraceDetach(tid)
+
+		if err := unix.PtraceInterrupt(tid); err != nil {
+			return 0, err
+		}
+		ws, err := waitStop(tid)
+		if err != nil {
+			return 0, err
+		}
+		// a signal may be reported before the interrupt, deliver it with
+		// the first resume.
+		if !isEventStop(ws) {
+			sig = int(ws.StopSignal())
+		}
+	}
+	if err := unix.PtraceSetOptions(tid, unix.PTRACE_O_TRACESYSGOOD); err != nil {
+		return 0, err
+	}
+
+	var saved unix.PtraceRegs
+	if err := unix.PtraceGetRegs(tid, &saved); err != nil {
+		return 0, err
+	}
+
+	regs := saved
+	regs.Rip = pc
+	regs.Orig_rax = ^uint64(0) // don't let the kernel restart an interrupted syscall on our registers
+	regs.Rax = unix.SYS_MMAP
+	regs.Rdi = 0
+	regs.Rsi = size
+	regs.Rdx = unix.PROT_READ | unix.PROT_WRITE
+	regs.R10 = unix.MAP_PRIVATE | unix.MAP_ANONYMOUS
+	regs.R8 = ^uint64(0)
+	regs.R9 = 0
+	if err := unix.PtraceSetRegs(tid, &regs); err != nil {
+		return 0, err
+	}
+
+	// run until syscall-enter-stop and then syscall-exit-stop, signals
+	// received in between are delivered to the target.
+	for stops := 0; stops < 2; {
+		if err := unix.PtraceSyscall(tid, sig); err != nil {
+			return 0, err
+		}
+		ws, err := waitStop(tid)
+		if err != nil {
+			return 0, err
+		}
+		sig = 0
+		switch {
+		case ws.StopSignal() == unix.SIGTRAP|0x80:
+			stops++
+		case isEventStop(ws):
+			// group-stop or PTRACE_INTERRUPT, nothing to deliver
+		default:
+			sig = int(ws.StopSignal())
+		}
+	}
+
+	if err := unix.PtraceGetRegs(tid, &regs); err != nil {
+		return 0, err
+	}
+	restoreInterruptedSyscall(&saved)
+	if err := unix.PtraceSetRegs(tid, &saved); err != nil {
+		return 0, err
+	}
+
+	if errno := int64(regs.Rax); errno < 0 && errno > -4096 {
+		return 0, fmt.Errorf("mmap: %v", unix.Errno(-errno))
+	}
+	return regs.Rax, nil
+}
+
+// restoreInterruptedSyscall rewinds regs so that a syscall the thread was
+// blocked in when it was stopped is executed again once it is resumed, as
+// the kernel would have done had it not been stopped.
+func restoreInterruptedSyscall(regs *unix.PtraceRegs) {
+	if int64(regs.Orig_rax) < 0 {
+		return
+	}
+	switch -int64(regs.Rax) {
+	case errRestartSys, errRestartNoIntr, errRestartNoHand:
+		regs.Rax = regs.Orig_rax
+		regs.Rip -= 2
+	case errRestartRestartBlock:
+		regs.Rax = sysRestartSyscall
+		regs.Rip -= 2
+	}
+}
+
+// findSyscallInstr returns the address of a SYSCALL instruction in the text
+// of the target.
+func (p *Prowler) findSyscallInstr() (uint64, error) {
+	for _, name := range syscallFuncs {
+		fns := p.bi.LookupFunc()[name]
+		if len(fns) == 0 || fns[0].Entry == 0 {
+			continue
+		}
+		fn := fns[0]
+
+		size := fn.End - fn.Entry
+		if size > maxSyscallInstrFuncSize {
+			size = maxSyscallInstrFuncSize
+		}
+		text := make([]byte, size)
+		if _, err := p.ReadMemory(text, fn.Entry); err != nil {
+			return 0, err
+		}
+
+		for off := 0; off < len(text); {
+			inst, err := x86asm.Decode(text[off:], 64)
+			if err != nil {
+				off++
+				continue
+			}
+			if inst.Op == x86asm.SYSCALL {
+				return fn.Entry + uint64(off), nil
+			}
+			off += inst.Len
+		}
+	}
+
+	return 0, fmt.Errorf("could not find a syscall instruction in the target")
+}
@@ -0,0 +1,12 @@
+//go:build !linux || !amd64
+
+package prowler
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func (p *Prowler) mmap(size uint64) (uint64, error) {
+	return 0, fmt.Errorf("memory allocation in the target is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,81 @@
+package prowler
+
+import (
+	"explore/pkg/proc"
+	"explore/pkg/proc/desc"
+	"fmt"
+	"strconv"
+)
+
+// Page selects the elements of an array, slice, string or map read by
+// GetPage. They are sent by clients along with get requests.
+type Page struct {
+	// Offset is the index of the first element, for maps the number of
+	// entries skipped after Cursor
+	Offset int64 `json:"offset,omitempty"`
+	// Limit is the maximum number of elements, 0 reads as many as the load
+	// configuration allows
+	Limit int64 `json:"limit,omitempty"`
+	// Cursor is the position of a page of a map returned by a previous
+	// request, see proc.MapCursor
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// PageOptionNames are the names of the options accepted by Set.
+var PageOptionNames = []string{"offset", "limit", "cursor"}
+
+// Set sets the option name, one of PageOptionNames, to value.
+func (pg *Page) Set(name, value string) error {
+	if name == "cursor" {
+		if _, err := proc.ParseMapCursor(value); err != nil {
+			return err
+		}
+		pg.Cursor = value
+		return nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid value %q for %s", value, name)
+	}
+	switch name {
+	case "offset":
+		pg.Offset = n
+	case "limit":
+		pg.Limit = n
+	default:
+		return fmt.Errorf("unknown option %q", name)
+	}
+	return nil
+}
+
+// GetPage is GetWithConfig reading only the page pg of the collection expr
+// evaluates to. The next page of a map starts from the Cursor of the
+// returned variable, the one of other collections from their Offset plus
+// the number of elements read.
+func (p *Prowler) GetPage(expr string, cfg proc.LoadConfig, pg Page) (*desc.Variable, error) {
+	var cursor *proc.MapCursor
+	if pg.Cursor != "" {
+		var err error
+		if cursor, err = proc.ParseMapCursor(pg.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	p.refresh()
+	// the elements are read by LoadPage, the expression is only evaluated
+	shallow := cfg
+	shallow.MaxArrayValues = 0
+	shallow.MaxStringLen = 0
+	v, err := p.scope().EvalExpression(expr, shallow)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := v.LoadPage(cfg, pg.Offset, pg.Limit, cursor)
+	if err != nil {
+		return nil, err
+	}
+	page.Name = v.Name
+	return p.ToPrintVar(page), nil
+}
@@ -0,0 +1,167 @@
+package prowler
+
+import (
+	"fmt"
+	"sync"
+
+	"explore/pkg/proc"
+)
+
+const (
+	cachePageSize = 4096
+	// cacheReadahead is the number of pages following a miss read with it,
+	// the elements of slices and arrays are usually loaded one after the
+	// other.
+	cacheReadahead = 4
+	// cacheMaxPages bounds the memory used by the cache, it is emptied when
+	// it holds more pages.
+	cacheMaxPages = 4096
+	// maxIovecs is the largest number of ranges passed to a single
+	// process_vm_readv call, IOV_MAX on Linux.
+	maxIovecs = 1024
+)
+
+// vectorReader is a memory that can read several ranges with a single
+// call, as process_vm_readv does.
+type vectorReader interface {
+	proc.MemoryReadWriter
+	// ReadMemoryv reads the ranges starting at addrs into bufs, in order,
+	// stopping at the first range that can not be read. It returns the
+	// number of bytes read.
+	ReadMemoryv(bufs [][]byte, addrs []uint64) (int, error)
+}
+
+// CacheStats are the counters of the page cache of a live process.
+type CacheStats struct {
+	Hits     uint64 // pages read from the cache
+	Misses   uint64 // pages read from the process
+	Syscalls uint64 // reads issued to the process
+}
+
+func (s CacheStats) String() string {
+	return fmt.Sprintf("cache: %d hits, %d misses, %d reads", s.Hits, s.Misses, s.Syscalls)
+}
+
+// pageCache is a proc.MemoryReadWriter that reads the memory of the process
+// a page at a time and keeps the pages it read. The pages missing for a read
+// are fetched with a single vectored read, together with the pages that
+// follow them. Writes invalidate the pages they touch. The process keeps
+// running, the cache is emptied at the start of each operation of the
+// Prowler so that values are never older than the operation reading them.
+type pageCache struct {
+	mem   vectorReader
+	mu    sync.Mutex
+	pages map[uint64][]byte
+	stats CacheStats
+}
+
+func newPageCache(mem vectorReader) *pageCache {
+	return &pageCache{mem: mem, pages: make(map[uint64][]byte)}
+}
+
+func (c *pageCache) ReadMemory(data []byte, addr uint64) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	first := addr &^ (cachePageSize - 1)
+	end := addr + uint64(len(data))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the pages of the range, nil for the missing ones: they are referenced
+	// here as fetch may empty the cache
+	var pages [][]byte
+	var missing []uint64
+	for page := first; page < end; page += cachePageSize {
+		buf, ok := c.pages[page]
+		if ok {
+			c.stats.Hits++
+		} else {
+			missing = append(missing, page)
+		}
+		pages = append(pages, buf)
+	}
+	if len(missing) > 0 {
+		c.fetch(missing)
+		for i := range pages {
+			if pages[i] != nil {
+				continue
+			}
+			buf, ok := c.pages[first+uint64(i)*cachePageSize]
+			if !ok {
+				// part of the range is unreadable, the process reports how
+				// much can be read
+				c.stats.Syscalls++
+				return c.mem.ReadMemory(data, addr)
+			}
+			pages[i] = buf
+		}
+	}
+
+	n := 0
+	for i, buf := range pages {
+		off := uint64(0)
+		if page := first + uint64(i)*cachePageSize; page < addr {
+			off = addr - page
+		}
+		n += copy(data[n:], buf[off:])
+	}
+	return n, nil
+}
+
+// fetch reads the pages missing, and the pages following the last one, with
+// a single vectored read. Pages that can not be read are not cached.
+func (c *pageCache) fetch(missing []uint64) {
+	if len(c.pages)+len(missing)+cacheReadahead > cacheMaxPages {
+		c.pages = make(map[uint64][]byte)
+	}
+
+	pages := missing
+	last := missing[len(missing)-1]
+	for i := uint64(1); i <= cacheReadahead; i++ {
+		if _, ok := c.pages[last+i*cachePageSize]; !ok {
+			pages = append(pages, last+i*cachePageSize)
+		}
+	}
+	pages = pages[:min(len(pages), maxIovecs)]
+
+	buf := make([]byte, len(pages)*cachePageSize)
+	bufs := make([][]byte, len(pages))
+	for i := range pages {
+		bufs[i] = buf[i*cachePageSize : (i+1)*cachePageSize]
+	}
+
+	c.stats.Syscalls++
+	n, _ := c.mem.ReadMemoryv(bufs, pages)
+	for i, page := range pages {
+		if (i+1)*cachePageSize > n {
+			break
+		}
+		c.pages[page] = bufs[i]
+		c.stats.Misses++
+	}
+}
+
+func (c *pageCache) WriteMemory(addr uint64, data []byte) (int, error) {
+	c.mu.Lock()
+	for page := addr &^ (cachePageSize - 1); page < addr+uint64(len(data)); page += cachePageSize {
+		delete(c.pages, page)
+	}
+	c.mu.Unlock()
+
+	return c.mem.WriteMemory(addr, data)
+}
+
+// invalidate empties the cache.
+func (c *pageCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.pages)
+}
+
+func (c *pageCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
@@ -0,0 +1,117 @@
+package prowler
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// fakeProcess is the memory of a process with 8 readable pages at 0x10000,
+// each byte holding the number of its page.
+type fakeProcess struct {
+	mem   []byte
+	reads int
+}
+
+const fakeBase = 0x10000
+
+func newFakeProcess() *fakeProcess {
+	mem := make([]byte, 8*cachePageSize)
+	for i := range mem {
+		mem[i] = byte(i / cachePageSize)
+	}
+	return &fakeProcess{mem: mem}
+}
+
+func (m *fakeProcess) ReadMemory(data []byte, addr uint64) (int, error) {
+	m.reads++
+	if addr < fakeBase || addr+uint64(len(data)) > fakeBase+uint64(len(m.mem)) {
+		return 0, errors.New("bad address")
+	}
+	return copy(data, m.mem[addr-fakeBase:]), nil
+}
+
+func (m *fakeProcess) ReadMemoryv(bufs [][]byte, addrs []uint64) (int, error) {
+	m.reads++
+	n := 0
+	for i, buf := range bufs {
+		if addrs[i] < fakeBase || addrs[i]+uint64(len(buf)) > fakeBase+uint64(len(m.mem)) {
+			return n, nil
+		}
+		n += copy(buf, m.mem[addrs[i]-fakeBase:])
+	}
+	return n, nil
+}
+
+func (m *fakeProcess) WriteMemory(addr uint64, data []byte) (int, error) {
+	return copy(m.mem[addr-fakeBase:], data), nil
+}
+
+func TestPageCache(t *testing.T) {
+	proc := newFakeProcess()
+	c := newPageCache(proc)
+
+	// a read across two pages fetches them and the next ones in one go
+	buf := make([]byte, 16)
+	if _, err := c.ReadMemory(buf, fakeBase+cachePageSize-8); err != nil {
+		t.Fatal(err)
+	}
+	if want := append(bytes.Repeat([]byte{0}, 8), bytes.Repeat([]byte{1}, 8)...); !bytes.Equal(buf, want) {
+		t.Errorf("read %x, want %x", buf, want)
+	}
+	for page := 2; page <= 5; page++ {
+		if _, err := c.ReadMemory(buf, fakeBase+uint64(page)*cachePageSize); err != nil || buf[0] != byte(page) {
+			t.Errorf("page %d: read %x, %v", page, buf, err)
+		}
+	}
+	if proc.reads != 1 {
+		t.Errorf("%d reads of the process, want 1", proc.reads)
+	}
+	if st := c.Stats(); st.Hits != 4 || st.Misses != 6 || st.Syscalls != 1 {
+		t.Errorf("stats %v", st)
+	}
+
+	// writes invalidate the pages they touch
+	if _, err := c.WriteMemory(fakeBase+2*cachePageSize, []byte{0xff}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReadMemory(buf[:1], fakeBase+2*cachePageSize); err != nil || buf[0] != 0xff {
+		t.Errorf("read %x after write, %v", buf[:1], err)
+	}
+
+	// the readahead stops at the end of the memory, reads past it fail
+	if _, err := c.ReadMemory(buf, fakeBase+7*cachePageSize); err != nil || buf[0] != 7 {
+		t.Errorf("last page: read %x, %v", buf, err)
+	}
+	if _, err := c.ReadMemory(buf, fakeBase+8*cachePageSize-8); err == nil {
+		t.Error("read past the end of the memory did not fail")
+	}
+
+	c.invalidate()
+	reads := proc.reads
+	if _, err := c.ReadMemory(buf, fakeBase); err != nil || proc.reads != reads+1 {
+		t.Errorf("read after invalidate: %v, %d reads", err, proc.reads-reads)
+	}
+}
+
+func TestPageCacheFull(t *testing.T) {
+	proc := newFakeProcess()
+	c := newPageCache(proc)
+
+	// the first page is cached and the cache is full, the read of the next
+	// one empties it
+	buf := make([]byte, 16)
+	if _, err := c.ReadMemory(buf[:1], fakeBase); err != nil {
+		t.Fatal(err)
+	}
+	delete(c.pages, fakeBase+cachePageSize)
+	for i := len(c.pages); i < cacheMaxPages; i++ {
+		c.pages[uint64(1+i)<<32] = nil
+	}
+	if _, err := c.ReadMemory(buf, fakeBase+cachePageSize-8); err != nil {
+		t.Fatal(err)
+	}
+	if want := append(bytes.Repeat([]byte{0}, 8), bytes.Repeat([]byte{1}, 8)...); !bytes.Equal(buf, want) {
+		t.Errorf("read %x, want %x", buf, want)
+	}
+}
@@ -5,14 +5,21 @@ import (
 	"debug/dwarf"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	e "explore/error"
 	"explore/pkg/dwarf/godwarf"
 	"explore/pkg/proc"
+	"explore/pkg/proc/core"
 	"explore/pkg/proc/desc"
+	"explore/pkg/proc/static"
 	"explore/utils"
 	"fmt"
 	"github.com/derekparker/trie"
+	"go/ast"
 	cst "go/constant"
+	"go/parser"
+	"go/scanner"
+	"go/token"
 	"io"
 	"math"
 	"os"
@@ -21,6 +28,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 type LsType int
@@ -29,21 +37,27 @@ const (
 	Vac LsType = iota
 	Variable
 	Constant
-)
-
-var (
-	loadFullValue = proc.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1, MaxMapBuckets: 64}
+	Function
+	Type
 )
 
 type Prowler struct {
 	pid                  int
+	mem                  proc.MemoryReadWriter
+	cache                *pageCache    // cache of mem, only for live processes
+	threads              []proc.Thread // threads with known registers, only for core files
+	clock                func() int64  // current value of the clock of the runtime, 0 if unknown
 	bi                   *proc.BinaryInfo
 	DebugInfoDirectories []string
+	LoadConfig           proc.LoadConfig // how much of values is read when not given by the caller
 	vars                 map[string]*GlobalVar
 	constants            map[string]*GlobalConst
 	functions            map[string]*proc.Function
 	trie                 *trie.Trie
+	alloc                *allocator
 	mu                   sync.Mutex
+	frozen               atomic.Pointer[freezeState] // set while the process is stopped by Freeze
+	freezeMu             sync.Mutex
 }
 
 type GlobalVar struct {
@@ -77,25 +91,86 @@ func (v *GlobalVar) Type() *godwarf.Type {
 }
 
 func NewProwler(pid int) (*Prowler, error) {
-	p := &Prowler{
-		pid:                  pid,
-		bi:                   proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH),
-		DebugInfoDirectories: []string{"/usr/lib/debug/.build-id"},
-		vars:                 make(map[string]*GlobalVar),
-		constants:            make(map[string]*GlobalConst),
-		functions:            make(map[string]*proc.Function),
-	}
+	p := newProwler(pid, runtime.GOOS, runtime.GOARCH)
+	p.mem = processMemory(pid)
+	p.cache = newPageCache(processMemory(pid))
+	p.clock = nanotime
+	p.alloc = newAllocator(p.mmap)
 
 	path, entry, di, err := p.LoadParam()
 	if err != nil {
 		return nil, err
 	}
 
-	err = p.bi.LoadBinaryInfo(path, entry, di)
+	if err := p.load(path, entry, di); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewCoreProwler returns a Prowler over the process saved in the core file
+// corePath, which was running the executable exePath. exePath can be empty
+// for dumps written by Dump. The memory of a core file is read-only.
+func NewCoreProwler(exePath, corePath string) (*Prowler, error) {
+	c, err := core.Open(corePath, exePath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newProwler(c.Pid, "linux", c.GOARCH)
+	p.mem = c
+	p.threads = c.Threads()
+	p.clock = func() int64 { return 0 }
+	p.alloc = newAllocator(func(uint64) (uint64, error) { return 0, core.ErrReadOnly })
+
+	if err := p.load(c.Exe, EntryPointFromAuxv(c.Auxv, p.bi.Arch.PtrSize()), p.DebugInfoDirectories); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewStaticProwler returns a Prowler over the executable at path, without a
+// running process. Package variables have the values they are initialized
+// with by the linker, variables initialized at run time are zero.
+func NewStaticProwler(path string) (*Prowler, error) {
+	exe, err := static.Open(path)
 	if err != nil {
 		return nil, err
 	}
 
+	p := newProwler(0, "linux", exe.GOARCH)
+	p.mem = exe
+	p.clock = func() int64 { return 0 }
+	p.alloc = newAllocator(func(uint64) (uint64, error) { return 0, static.ErrReadOnly })
+
+	if err := p.load(path, 0, p.DebugInfoDirectories); err != nil {
+		exe.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func newProwler(pid int, goos, goarch string) *Prowler {
+	return &Prowler{
+		pid:                  pid,
+		bi:                   proc.NewBinaryInfo(goos, goarch),
+		DebugInfoDirectories: []string{"/usr/lib/debug/.build-id"},
+		LoadConfig:           DefaultLoadConfig,
+		vars:                 make(map[string]*GlobalVar),
+		constants:            make(map[string]*GlobalConst),
+		functions:            make(map[string]*proc.Function),
+	}
+}
+
+// load reads the debug information of the executable at path and indexes
+// its package variables, constants and functions.
+func (p *Prowler) load(path string, entry uint64, debugInfoDirectories []string) error {
+	err := p.bi.LoadBinaryInfo(path, entry, debugInfoDirectories)
+	if err != nil {
+		return err
+	}
+
 	// 建立索引树
 	t := trie.New()
 
@@ -141,13 +216,19 @@ func NewProwler(pid int) (*Prowler, error) {
 
 	p.trie = t
 
-	return p, nil
+	return nil
 }
 
 func (p *Prowler) Get(name string) (*desc.Variable, error) {
+	return p.GetWithConfig(name, p.LoadConfig)
+}
+
+// GetWithConfig is Get reading as much of the value as allowed by cfg.
+func (p *Prowler) GetWithConfig(name string, cfg proc.LoadConfig) (*desc.Variable, error) {
+	p.refresh()
 	node, found := p.trie.Find(name)
 	if !found {
-		return nil, fmt.Errorf("%s not found in process", name)
+		return p.eval(name, cfg)
 	}
 
 	meta := node.Meta()
@@ -155,7 +236,7 @@ func (p *Prowler) Get(name string) (*desc.Variable, error) {
 	var v *proc.Variable
 	switch meta.(type) {
 	case *GlobalVar:
-		variable, err := p.getVariable(name)
+		variable, err := p.getVariable(name, cfg)
 		if err != nil {
 			return nil, err
 		}
@@ -177,7 +258,41 @@ func (p *Prowler) Get(name string) (*desc.Variable, error) {
 	return p.ToPrintVar(v), nil
 }
 
-func (p *Prowler) getVariable(name string) (*proc.Variable, error) {
+// GetAs reads the memory at the address expr evaluates to as a value of the
+// type typ, e.g. main.Config, as much of it as cfg sets. expr is an address,
+// e.g. 0xc000123400, or an expression as for Examine, whose pointers and
+// slices are followed.
+func (p *Prowler) GetAs(typ, expr string, cfg proc.LoadConfig) (*desc.Variable, error) {
+	p.refresh()
+	t, err := p.bi.FindType(typ)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := p.examineAddr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	v := proc.NewVariable(fmt.Sprintf("*(*%s)(%#x)", typ, addr), addr, t, p.bi, p)
+	v.LoadValue(cfg)
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	return p.ToPrintVar(v), nil
+}
+
+// eval evaluates expr as a Go expression over the package variables of the
+// process, e.g. main.cfg.Servers[2].Addr or len(main.cache).
+func (p *Prowler) eval(expr string, cfg proc.LoadConfig) (*desc.Variable, error) {
+	v, err := p.scope().EvalExpression(expr, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ToPrintVar(v), nil
+}
+
+func (p *Prowler) getVariable(name string, cfg proc.LoadConfig) (*proc.Variable, error) {
 	pkgVar, ok := p.vars[name]
 	if !ok {
 		return nil, e.VariableNotFound
@@ -185,7 +300,7 @@ func (p *Prowler) getVariable(name string) (*proc.Variable, error) {
 
 	addr := pkgVar.Addr
 
-	return p.ToVar(name, addr)
+	return p.toVar(name, addr, cfg)
 }
 
 func (p *Prowler) getConstant(name string) (*proc.Variable, error) {
@@ -225,24 +340,13 @@ func (p *Prowler) getFunction(name string) (*proc.Variable, error) {
 
 	v := proc.NewVariable(fn.Name, fn.Entry, &godwarf.FuncType{}, p.bi, p)
 	v.Value = cst.MakeString(fn.Name)
-	//text, err := p.loadFunction(fn)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//
-	//for _, t := range text {
-	//	tx := t.Text(proc.GoFlavour, p.bi)
-	//	fmt.Println("tx: ", tx)
-	//}
-	//filename, line, _ := p.bi.PCToLine(fn.Entry)
-	//fmt.Printf("file: %s, line: %d\n", filename, line)
-	//fmt.Printf("function: %v\n", p.bi.Sources)
 
 	return v, nil
 }
 
 func (p *Prowler) Set(name string, value string) error {
-	src, err := p.getVariable(name)
+	p.refresh()
+	src, err := p.getVariable(name, p.LoadConfig)
 	if err != nil {
 		return err
 	}
@@ -257,6 +361,124 @@ func (p *Prowler) Set(name string, value string) error {
 	return p.set(src, val)
 }
 
+// Assign evaluates the assignment lhs = rhs, where both sides are Go
+// expressions over the package variables of the process, e.g.
+// main.cfg.Limits["api"].Burst = 200. Assigning to a key that is not in a
+// map inserts it.
+func (p *Prowler) Assign(lhs, rhs string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.refresh()
+	return p.scope().SetVariable(lhs, rhs)
+}
+
+// Delete removes key from the map m, both are Go expressions as for
+// delete(m, key).
+func (p *Prowler) Delete(m, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.refresh()
+	return p.scope().DeleteMapKey(m, key)
+}
+
+// scope returns the scope used to evaluate expressions, string constants
+// assigned to variables are stored in memory allocated in the process.
+func (p *Prowler) scope() *proc.EvalScope {
+	scope := proc.GlobalScope(p.bi, p)
+	scope.Alloc = p.alloc.alloc
+	return scope
+}
+
+// SplitAssignment splits expr at its top level '=', returning the two sides
+// of the assignment. ok is false if expr is not an assignment of two
+// expressions, e.g. main.secret a=b, the name and the raw value of a set.
+func SplitAssignment(expr string) (lhs, rhs string, ok bool) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(expr))
+
+	var s scanner.Scanner
+	s.Init(file, []byte(expr), nil, 0)
+	for {
+		pos, tok, _ := s.Scan()
+		switch tok {
+		case token.EOF:
+			return "", "", false
+		case token.ASSIGN:
+			off := file.Offset(pos)
+			lhs, rhs = strings.TrimSpace(expr[:off]), strings.TrimSpace(expr[off+1:])
+			if _, err := parser.ParseExpr(lhs); err != nil {
+				return "", "", false
+			}
+			if _, err := parser.ParseExpr(rhs); err != nil {
+				return "", "", false
+			}
+			return lhs, rhs, true
+		}
+	}
+}
+
+// SplitDelete returns the two arguments of expr if it is a call to the
+// delete builtin, e.g. delete(main.cache, 2).
+func SplitDelete(expr string) (m, key string, ok bool) {
+	t, err := parser.ParseExpr(expr)
+	if err != nil {
+		return "", "", false
+	}
+
+	call, ok := ast.Unparen(t).(*ast.CallExpr)
+	if !ok || len(call.Args) != 2 || call.Ellipsis != token.NoPos {
+		return "", "", false
+	}
+	if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "delete" {
+		return "", "", false
+	}
+
+	return expr[call.Args[0].Pos()-1 : call.Args[0].End()-1], expr[call.Args[1].Pos()-1 : call.Args[1].End()-1], true
+}
+
+// SetArgs are the arguments of a set: a name and a raw value, a Go
+// assignment or a delete.
+type SetArgs struct {
+	Name  string
+	Value string
+	// Assign is set when Name and Value are the two sides of a Go assignment
+	Assign bool
+	// Delete is set when Name and Value are the map and the key of a delete
+	Delete bool
+}
+
+// ParseSet returns the arguments of a set, from args, its arguments as split
+// by a shell, and expr, the same arguments not split, so that the quotes of
+// Go expressions are kept. Two arguments are a name and a raw value, which
+// may hold a '=', e.g. main.secret a=b; otherwise expr must be a delete or an
+// assignment, e.g. main.limit = 200.
+func ParseSet(expr string, args []string) (*SetArgs, error) {
+	if m, key, ok := SplitDelete(expr); ok {
+		return &SetArgs{Name: m, Value: key, Delete: true}, nil
+	}
+	if len(args) == 2 {
+		return &SetArgs{Name: args[0], Value: args[1]}, nil
+	}
+	if lhs, rhs, ok := SplitAssignment(expr); ok {
+		return &SetArgs{Name: lhs, Value: rhs, Assign: true}, nil
+	}
+	return nil, errors.New("expected either <name> <value>, '<lhs> = <rhs>' or 'delete(<map>, <key>)'")
+}
+
+// Write writes to the process as asked by the arguments of a set.
+func (p *Prowler) Write(a *SetArgs) error {
+	switch {
+	case a.Delete:
+		return p.Delete(a.Name, a.Value)
+	case a.Assign:
+		return p.Assign(a.Name, a.Value)
+	default:
+		return p.Set(a.Name, a.Value)
+	}
+}
+
 func (p *Prowler) set(src *proc.Variable, val interface{}) error {
 	switch src.Kind {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -271,7 +493,7 @@ func (p *Prowler) set(src *proc.Variable, val interface{}) error {
 		src.Value = v
 	case reflect.String:
 		ss := val.(string)
-		ptr, err := findFreeMemory(p.pid, uint64(len(ss)))
+		ptr, err := p.alloc.alloc(uint64(len(ss)), 1)
 		if err != nil {
 			return err
 		}
@@ -337,44 +559,20 @@ func (p *Prowler) set(src *proc.Variable, val interface{}) error {
 
 		return fmt.Errorf("src variable not found buf: %+v\n", src)
 	case reflect.Map:
+		// keys that are not in the map yet are inserted
 		valMap := val.(map[interface{}]interface{})
-		cVars := src.Children
-
-		var key, value interface{}
-		addKV := make(map[interface{}]interface{})
-		for i, cv := range cVars {
-			tv := cv.Value.String()
-			if cv.Kind == reflect.String {
-				utv, err := strconv.Unquote(tv)
-				if err != nil {
-					return err
-				}
-				tv = utv
+		scope := p.scope()
+		for k, v := range valMap {
+			key := proc.NewConstant("", constantOf(k), p)
+			elem, err := scope.MapAssign(src, key, nil)
+			if err != nil {
+				return fmt.Errorf("key %v: %v", k, err)
 			}
-
-			if i%2 == 0 {
-				// key process
-				ek, err := p.Expression(tv, cv.RealType)
-				if err != nil {
-					return err
-				}
-				key = ek
-
-				if v, ok := valMap[key]; ok {
-					// modify the value of the key
-					value = v
-				} else {
-					// add key value pairs
-					addKV[key] = v
-				}
-			} else {
-				// value process
-				if value != nil {
-					if err := p.set(&cv, value); err != nil {
-						return err
-					}
-					value = nil
-				}
+			if err := p.loadValue(elem); err != nil {
+				return err
+			}
+			if err := p.set(elem, v); err != nil {
+				return err
 			}
 		}
 
@@ -383,7 +581,7 @@ func (p *Prowler) set(src *proc.Variable, val interface{}) error {
 		valSli := val.([]interface{})
 		// 计算内存
 		l := uintptr(len(valSli)) * reflect.TypeOf(valSli).Elem().Size()
-		ptr, err := findFreeMemory(p.pid, uint64(l))
+		ptr, err := p.alloc.alloc(uint64(l), uint64(p.bi.Arch.PtrSize()))
 		if err != nil {
 			return err
 		}
@@ -397,7 +595,7 @@ func (p *Prowler) set(src *proc.Variable, val interface{}) error {
 			case reflect.String:
 				elemLen := elem.Len()
 				elemStr := elem.String()
-				dataPtr, err := findFreeMemory(p.pid, uint64(elemLen))
+				dataPtr, err := p.alloc.alloc(uint64(elemLen), 1)
 				if err != nil {
 					return err
 				}
@@ -443,7 +641,7 @@ func (p *Prowler) set(src *proc.Variable, val interface{}) error {
 			case reflect.String:
 				elemLen := elem.Len()
 				elemStr := elem.String()
-				dataPtr, err := findFreeMemory(p.pid, uint64(elemLen))
+				dataPtr, err := p.alloc.alloc(uint64(elemLen), 1)
 				if err != nil {
 					return err
 				}
@@ -481,6 +679,24 @@ func (p *Prowler) set(src *proc.Variable, val interface{}) error {
 	return proc.SetValue(dst, src, "")
 }
 
+// constantOf converts a value returned by Expression for a scalar type to
+// a constant.
+func constantOf(v interface{}) cst.Value {
+	switch v := v.(type) {
+	case uint64:
+		return cst.MakeUint64(v)
+	case float64:
+		return cst.MakeFloat64(v)
+	}
+	return cst.Make(v)
+}
+
+// Allocations returns the memory allocated in the process to hold the
+// values written by Set and Assign.
+func (p *Prowler) Allocations() []MemoryRegion {
+	return p.alloc.allocations()
+}
+
 func (p *Prowler) writeString(addr, len, base uint64) error {
 	if err := p.writePointer(addr, base); err != nil {
 		return err
@@ -517,6 +733,10 @@ func (p *Prowler) List(t LsType, prefixes, suffixes []string) []string {
 		return p.ListVariables(prefixes, suffixes)
 	case Constant:
 		return p.ListConstants(prefixes, suffixes)
+	case Function:
+		return p.ListFunctions(prefixes, suffixes)
+	case Type:
+		return p.ListTypes(prefixes, suffixes)
 	default:
 		return nil
 	}
@@ -550,6 +770,33 @@ func (p *Prowler) ListConstants(prefixes, suffixes []string) []string {
 	return constants
 }
 
+func (p *Prowler) ListFunctions(prefixes, suffixes []string) []string {
+	all := len(prefixes) == 0 && len(suffixes) == 0
+
+	var functions []string
+	for name := range p.functions {
+		if all || utils.PrefixIn(name, prefixes) || utils.SuffixIn(name, suffixes) {
+			functions = append(functions, name)
+		}
+	}
+
+	return functions
+}
+
+func (p *Prowler) ListTypes(prefixes, suffixes []string) []string {
+	all := len(prefixes) == 0 && len(suffixes) == 0
+
+	types, _ := p.bi.Types()
+	var res []string
+	for _, name := range types {
+		if all || utils.PrefixIn(name, prefixes) || utils.SuffixIn(name, suffixes) {
+			res = append(res, name)
+		}
+	}
+
+	return res
+}
+
 func (p *Prowler) Expression(expr string, realType godwarf.Type) (interface{}, error) {
 	switch realType.(type) {
 	case *godwarf.BoolType:
@@ -746,23 +993,63 @@ func (p *Prowler) uintToBytes(val uint64) []byte {
 }
 
 func (p *Prowler) ReadMemory(bs []byte, addr uint64) (int, error) {
-	return readMemory(p.pid, bs, uintptr(addr))
+	if err := p.checkFrozen(); err != nil {
+		return 0, err
+	}
+	if p.cache != nil {
+		return p.cache.ReadMemory(bs, addr)
+	}
+	return p.mem.ReadMemory(bs, addr)
 }
 
 func (p *Prowler) WriteMemory(addr uint64, bs []byte) (int, error) {
-	return writeMemory(p.pid, bs, uintptr(addr))
+	if err := p.checkFrozen(); err != nil {
+		return 0, err
+	}
+	if p.cache != nil {
+		return p.cache.WriteMemory(addr, bs)
+	}
+	return p.mem.WriteMemory(addr, bs)
+}
+
+// refresh drops the memory of the process cached by previous operations,
+// it is called at the start of every operation reading the memory.
+func (p *Prowler) refresh() {
+	if p.cache != nil {
+		p.cache.invalidate()
+	}
+}
+
+// CacheStats returns the counters of the cache of the memory of the
+// process, zero for core files and executables.
+func (p *Prowler) CacheStats() CacheStats {
+	if p.cache == nil {
+		return CacheStats{}
+	}
+	return p.cache.Stats()
+}
+
+// live reports whether p inspects a running process, rather than a core
+// file or an executable.
+func (p *Prowler) live() bool {
+	_, ok := p.mem.(processMemory)
+	return ok
 }
 
 func (p *Prowler) ToVar(name string, addr uint64) (*proc.Variable, error) {
+	return p.toVar(name, addr, p.LoadConfig)
+}
+
+func (p *Prowler) toVar(name string, addr uint64, cfg proc.LoadConfig) (*proc.Variable, error) {
 	vv, ok := p.vars[name]
 	if !ok {
 		return nil, fmt.Errorf("variable %q not found", name)
 	}
 
 	v := proc.NewVariable(name, addr, *vv.ty, p.bi, p)
-	err := p.loadValue(v)
-	if err != nil {
-		return nil, err
+	v.LoadValue(cfg)
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
 	}
 
 	return v, nil
@@ -799,12 +1086,21 @@ func (p *Prowler) ToPrintVar(v *proc.Variable) *desc.Variable {
 		Cap:      v.Cap,
 		Flags:    desc.VariableFlags(v.Flags),
 		DeclLine: v.DeclLine,
+		Offset:   v.Offset,
+	}
+
+	if v.MapCursor != nil {
+		vv.Cursor = v.MapCursor.String()
 	}
 
 	if v.RealType != nil {
 		vv.RealType = v.RealType.String()
 	}
 
+	if v.Unreadable != nil {
+		vv.Unreadable = v.Unreadable.Error()
+	}
+
 	if v.Value != nil {
 		val := v.Value.String()
 		if v.TypeString() == "string" {
@@ -824,11 +1120,12 @@ func (p *Prowler) ToPrintVar(v *proc.Variable) *desc.Variable {
 }
 
 func (p *Prowler) loadValue(v *proc.Variable) error {
-	v.LoadValue(loadFullValue)
+	v.LoadValue(p.LoadConfig)
 
 	return v.Unreadable
 }
 
+// disassemble decodes the instructions of fn, with their source location.
 func (p *Prowler) disassemble(fn *proc.Function) ([]proc.AsmInstruction, error) {
 	bi := p.bi
 	startAddr := fn.Entry
@@ -0,0 +1,141 @@
+package prowler
+
+import (
+	"bufio"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSplitAssignment(t *testing.T) {
+	tests := []struct {
+		expr     string
+		lhs, rhs string
+		ok       bool
+	}{
+		{`main.limit = 200`, "main.limit", "200", true},
+		{`main.cfg.Limits["api"].Burst=200`, `main.cfg.Limits["api"].Burst`, "200", true},
+		{`main.flags["a=b"] = "c=d"`, `main.flags["a=b"]`, `"c=d"`, true},
+		{`main.limit == 200`, "", "", false},
+		// the name and the raw value of a set, holding a '='
+		{`main.secret a=b`, "", "", false},
+		{`main.secret =`, "", "", false},
+	}
+	for _, test := range tests {
+		lhs, rhs, ok := SplitAssignment(test.expr)
+		if lhs != test.lhs || rhs != test.rhs || ok != test.ok {
+			t.Errorf("SplitAssignment(%q) = %q, %q, %v, want %q, %q, %v", test.expr, lhs, rhs, ok, test.lhs, test.rhs, test.ok)
+		}
+	}
+}
+
+func TestParseSet(t *testing.T) {
+	tests := []struct {
+		expr string
+		args []string
+		want *SetArgs
+	}{
+		{`main.limit 200`, []string{"main.limit", "200"}, &SetArgs{Name: "main.limit", Value: "200"}},
+		// raw values holding a '=' are not assignments
+		{`main.secret =abc`, []string{"main.secret", "=abc"}, &SetArgs{Name: "main.secret", Value: "=abc"}},
+		{`main.secret a=b`, []string{"main.secret", "a=b"}, &SetArgs{Name: "main.secret", Value: "a=b"}},
+		{`main.limit = 200`, []string{"main.limit", "=", "200"}, &SetArgs{Name: "main.limit", Value: "200", Assign: true}},
+		{`main.limit=200`, []string{"main.limit=200"}, &SetArgs{Name: "main.limit", Value: "200", Assign: true}},
+		{`main.cfg.Limits["api"] = 2`, []string{`main.cfg.Limits[api]`, "=", "2"}, &SetArgs{Name: `main.cfg.Limits["api"]`, Value: "2", Assign: true}},
+		{`delete(main.flags, "beta")`, []string{"delete(main.flags,", "beta)"}, &SetArgs{Name: "main.flags", Value: `"beta"`, Delete: true}},
+		{`main.limit`, []string{"main.limit"}, nil},
+		{`main.limit == 200`, []string{"main.limit", "==", "200"}, nil},
+	}
+	for _, test := range tests {
+		got, err := ParseSet(test.expr, test.args)
+		switch {
+		case test.want == nil && err == nil:
+			t.Errorf("ParseSet(%q) = %+v, want an error", test.expr, got)
+		case test.want != nil && err != nil:
+			t.Errorf("ParseSet(%q): %v", test.expr, err)
+		case test.want != nil && *got != *test.want:
+			t.Errorf("ParseSet(%q) = %+v, want %+v", test.expr, got, test.want)
+		}
+	}
+}
+
+// startFixture runs the fixture process of the tests of proc, stopped at
+// the end of the test.
+func startFixture(t *testing.T) int {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+	exe := filepath.Join(t.TempDir(), "fixture")
+	if out, err := exec.Command("go", "build", "-o", exe, "../proc/testdata/fixture").CombinedOutput(); err != nil {
+		t.Skipf("could not build the fixture: %v\n%s", err, out)
+	}
+
+	cmd := exec.Command(exe)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		stdin.Close()
+		cmd.Wait()
+	})
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "ready\n" {
+		t.Fatalf("fixture did not start: %q, %v", line, err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestGetAs(t *testing.T) {
+	p, err := NewProwler(startFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultLoadConfig
+
+	for _, test := range []struct {
+		typ, expr string
+		want      string
+	}{
+		// the name of the debug information, and the Go expression
+		{"io/fs.PathError", "main.pathErr", "io/fs.PathError"},
+		{"fs.PathError", "main.pathErr", "io/fs.PathError"},
+		{"*main.Config", "&main.cfg", "*main.Config"},
+		{"[16]byte", "main.digest", "[16]uint8"},
+	} {
+		v, err := p.GetAs(test.typ, test.expr, cfg)
+		if err != nil {
+			t.Errorf("GetAs(%q, %q): %v", test.typ, test.expr, err)
+			continue
+		}
+		if v.Type != test.want {
+			t.Errorf("GetAs(%q, %q) of type %s, want %s", test.typ, test.expr, v.Type, test.want)
+		}
+	}
+
+	v, err := p.GetAs("fs.PathError", "main.pathErr", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Children) < 2 || v.Children[0].Value != "open" || v.Children[1].Value != "/etc/app.conf" {
+		t.Errorf("main.pathErr read as fs.PathError: %s", v.SinglelineString())
+	}
+	v, err = p.GetAs("*main.Config", "&main.cfg", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Children) != 1 || len(v.Children[0].Children) == 0 || v.Children[0].Children[0].Value != "api" {
+		t.Errorf("main.cfg read as *main.Config: %s", v.SinglelineString())
+	}
+	if _, err := p.GetAs("main.Missing", "main.pathErr", cfg); err == nil {
+		t.Error("GetAs of an unknown type did not fail")
+	}
+}
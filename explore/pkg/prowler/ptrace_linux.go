@@ -0,0 +1,95 @@
+package prowler
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+func isEventStop(ws unix.WaitStatus) bool {
+	return ws>>16 == unix.PTRACE_EVENT_STOP
+}
+
+func waitStop(tid int) (unix.WaitStatus, error) {
+	var ws unix.WaitStatus
+	for {
+		_, err := unix.Wait4(tid, &ws, unix.WALL, nil)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return ws, err
+		}
+		if ws.Exited() || ws.Signaled() {
+			return ws, fmt.Errorf("process %d exited", tid)
+		}
+		if ws.Stopped() {
+			return ws, nil
+		}
+	}
+}
+
+// stoppedThread is a thread of the target stopped by stopThreads.
+type stoppedThread struct {
+	tid int
+	sig int // signal reported instead of the interrupt, delivered on resume
+}
+
+// stopThreads stops all the threads of the target with PTRACE_SEIZE and
+// PTRACE_INTERRUPT, threads started while the others are being stopped are
+// stopped too. The threads stopped so far are returned also on errors, they
+// must be resumed with resumeThreads from the same OS thread.
+func (p *Prowler) stopThreads() ([]stoppedThread, error) {
+	var threads []stoppedThread
+	seen := make(map[int]bool)
+	for {
+		entries, err := os.ReadDir(fmt.Sprintf("/proc/%d/task", p.pid))
+		if err != nil {
+			return threads, err
+		}
+
+		added := false
+		for _, entry := range entries {
+			tid, err := strconv.Atoi(entry.Name())
+			if err != nil || seen[tid] {
+				continue
+			}
+			seen[tid] = true
+			if err := unix.PtraceSeize(tid); err != nil {
+				if err == unix.ESRCH {
+					// the thread exited
+					continue
+				}
+				return threads, fmt.Errorf("could not attach to %d: %w", tid, err)
+			}
+			added = true
+			th := stoppedThread{tid: tid}
+			if err := unix.PtraceInterrupt(tid); err != nil {
+				unix.PtraceDetach(tid)
+				return threads, err
+			}
+			ws, err := waitStop(tid)
+			if err != nil {
+				unix.PtraceDetach(tid)
+				return threads, err
+			}
+			if !isEventStop(ws) {
+				th.sig = int(ws.StopSignal())
+			}
+			threads = append(threads, th)
+		}
+		if !added {
+			return threads, nil
+		}
+	}
+}
+
+// resumeThreads detaches from the threads stopped by stopThreads,
+// delivering the signals they received while being stopped.
+func resumeThreads(threads []stoppedThread) {
+	for _, th := range threads {
+		unix.Syscall6(unix.SYS_PTRACE, unix.PTRACE_DETACH, uintptr(th.tid), 0, uintptr(th.sig), 0, 0)
+	}
+}
@@ -0,0 +1,91 @@
+package prowler
+
+import (
+	"explore/pkg/proc"
+	"explore/pkg/proc/desc"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxReferences is the number of references after which References
+// stops.
+const DefaultMaxReferences = 100
+
+// maxGraphObjects is the number of objects after which the object graphs
+// stop growing.
+const maxGraphObjects = 1 << 21
+
+// References returns the package variables and the heap objects reachable
+// from them that hold a pointer to the object containing the address expr
+// evaluates to, with the access path of the pointers, e.g.
+// main.registry.handlers["x"].next. expr is read as by Examine. The lookup
+// stops after maxRefs references.
+func (p *Prowler) References(expr string, maxRefs int) (*desc.References, error) {
+	if maxRefs <= 0 {
+		maxRefs = DefaultMaxReferences
+	}
+	p.refresh()
+	addr, err := p.examineAddr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	g := p.objectGraph()
+	g.Walk(-1)
+
+	res := &desc.References{Addr: addr, Objects: len(g.Objects), Truncated: g.Truncated}
+	start, end := addr, addr+1
+	if i := g.ObjectOf(addr); i >= 0 {
+		o := g.Objects[i]
+		res.Target = &desc.Object{Addr: o.Addr, Size: o.Size, Type: o.Type, Name: o.Name, Static: o.Static}
+		start, end = o.Addr, o.Addr+max(o.Size, 1)
+	}
+
+	for i := range g.Objects {
+		for _, e := range g.Objects[i].Edges {
+			if e.Ptr < start || e.Ptr >= end || e.Addr == 0 {
+				continue
+			}
+			if len(res.Refs) >= maxRefs {
+				res.Truncated = true
+				return res, nil
+			}
+			o := g.Objects[i]
+			res.Refs = append(res.Refs, desc.Reference{
+				Path:   g.Path(i) + e.Label,
+				Addr:   e.Addr,
+				Ptr:    e.Ptr,
+				Holder: desc.Object{Addr: o.Addr, Size: o.Size, Type: o.Type, Name: o.Name, Static: o.Static},
+			})
+		}
+	}
+	return res, nil
+}
+
+// objectGraph returns the graph of the objects reachable from the package
+// variables, not walked yet. The variables of the runtime are left out.
+func (p *Prowler) objectGraph() *proc.ObjectGraph {
+	heap, _ := proc.NewHeap(p.bi, p)
+	g := proc.NewObjectGraph(p.bi, p, heap)
+	g.MaxObjects = maxGraphObjects
+
+	names := make([]string, 0, len(p.vars))
+	for name, v := range p.vars {
+		if v.Addr == 0 || v.ty == nil || *v.ty == nil || !rootPackage(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := p.vars[name]
+		g.AddRoot(name, v.Addr, *v.ty)
+	}
+	return g
+}
+
+// rootPackage reports whether the package variable name is a root of the
+// object graphs.
+func rootPackage(name string) bool {
+	return !strings.HasPrefix(name, "runtime.") && !strings.HasPrefix(name, "internal/")
+}
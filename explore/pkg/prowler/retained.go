@@ -0,0 +1,87 @@
+package prowler
+
+import (
+	"explore/pkg/proc"
+	"explore/pkg/proc/desc"
+	"fmt"
+	"sort"
+)
+
+// DefaultRetainedTop is the number of variables printed by the retained
+// command.
+const DefaultRetainedTop = 30
+
+// RetainedReport returns the bytes retained by the package variables: the
+// heap objects only reachable through them, which would be freed if they
+// were cleared. Objects reachable from several variables are retained by
+// none of them. Only the top variables retaining the most bytes are
+// returned, all the ones retaining any if top is 0.
+func (p *Prowler) RetainedReport(top int) (*desc.RetainedReport, error) {
+	p.refresh()
+	g := p.objectGraph()
+	g.Walk(-1)
+	size, count := g.Retained()
+
+	r := &desc.RetainedReport{Truncated: g.Truncated}
+	for i, o := range g.Objects {
+		if o.Parent < 0 {
+			if ret := retained(g, i, size, count); ret.Bytes > 0 {
+				r.Vars = append(r.Vars, ret)
+			}
+			continue
+		}
+		if o.Static {
+			continue
+		}
+		r.Objects++
+		r.Bytes += o.Size
+	}
+	sort.SliceStable(r.Vars, func(i, j int) bool { return r.Vars[i].Bytes > r.Vars[j].Bytes })
+	if top > 0 && len(r.Vars) > top {
+		r.Others = len(r.Vars) - top
+		r.Vars = r.Vars[:top]
+	}
+	return r, nil
+}
+
+// Retained returns the bytes retained by the package variable expr or by
+// the heap object containing the address expr evaluates to, read as by
+// Examine.
+func (p *Prowler) Retained(expr string) (*desc.Retained, error) {
+	p.refresh()
+	var addr uint64
+	if v, ok := p.vars[expr]; ok && rootPackage(expr) {
+		addr = v.Addr
+	} else {
+		var err error
+		if addr, err = p.examineAddr(expr); err != nil {
+			return nil, err
+		}
+	}
+
+	g := p.objectGraph()
+	g.Walk(-1)
+	i := g.ObjectOf(addr)
+	if i < 0 {
+		return nil, fmt.Errorf("%#x is not reachable from package variables", addr)
+	}
+	size, count := g.Retained()
+	ret := retained(g, i, size, count)
+	return &ret, nil
+}
+
+// retained returns the memory retained by the object i of g, without the
+// memory of package variables themselves.
+func retained(g *proc.ObjectGraph, i int, size, count []uint64) desc.Retained {
+	o := g.Objects[i]
+	r := desc.Retained{
+		Object:  desc.Object{Addr: o.Addr, Size: o.Size, Type: o.Type, Name: o.Name, Static: o.Static},
+		Bytes:   size[i],
+		Objects: count[i],
+	}
+	if o.Parent < 0 {
+		r.Bytes -= o.Size
+		r.Objects--
+	}
+	return r
+}
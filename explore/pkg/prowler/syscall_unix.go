@@ -4,6 +4,17 @@ import (
 	"golang.org/x/sys/unix"
 )
 
+// processMemory is the memory of the live process with this pid.
+type processMemory int
+
+func (pid processMemory) ReadMemory(data []byte, addr uint64) (int, error) {
+	return readMemory(int(pid), data, uintptr(addr))
+}
+
+func (pid processMemory) WriteMemory(addr uint64, data []byte) (int, error) {
+	return writeMemory(int(pid), data, uintptr(addr))
+}
+
 func readMemory(pid int, data []byte, ptr uintptr) (int, error) {
 	localIov := []unix.Iovec{
 		{
@@ -22,6 +33,22 @@ func readMemory(pid int, data []byte, ptr uintptr) (int, error) {
 	return unix.ProcessVMReadv(pid, localIov, remoteIov, 0)
 }
 
+// ReadMemoryv reads the ranges starting at addrs into bufs with a single
+// process_vm_readv call.
+func (pid processMemory) ReadMemoryv(bufs [][]byte, addrs []uint64) (int, error) {
+	localIov := make([]unix.Iovec, 0, len(bufs))
+	remoteIov := make([]unix.RemoteIovec, 0, len(bufs))
+	for i, buf := range bufs {
+		if len(buf) == 0 {
+			continue
+		}
+		localIov = append(localIov, unix.Iovec{Base: &buf[0], Len: uint64(len(buf))})
+		remoteIov = append(remoteIov, unix.RemoteIovec{Base: uintptr(addrs[i]), Len: len(buf)})
+	}
+
+	return unix.ProcessVMReadv(int(pid), localIov, remoteIov, 0)
+}
+
 func writeMemory(pid int, data []byte, ptr uintptr) (int, error) {
 	localIov := []unix.Iovec{
 		{
@@ -39,3 +66,17 @@ func writeMemory(pid int, data []byte, ptr uintptr) (int, error) {
 
 	return unix.ProcessVMWritev(pid, localIov, remoteIov, 0)
 }
+
+// nanotime returns the current value of the monotonic clock, the clock read
+// by nanotime in the runtime of the process.
+func nanotime() int64 {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return 0
+	}
+	return ts.Nano()
+}
+
+func gettid() int {
+	return unix.Gettid()
+}
@@ -0,0 +1,274 @@
+package prowler
+
+import (
+	"explore/pkg/dwarf/godwarf"
+	"explore/pkg/proc"
+	"explore/pkg/proc/desc"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Types returns the types of the process whose name matches the regular
+// expression filter, all of them if it is empty, and whose kind is kind
+// if it is not empty, e.g. struct.
+func (p *Prowler) Types(filter, kind string) (*desc.Types, error) {
+	re, err := regexp.Compile(filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %v", err)
+	}
+	names, err := p.bi.Types()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	res := &desc.Types{}
+	for _, name := range names {
+		if !re.MatchString(name) {
+			continue
+		}
+		typ, err := p.bi.FindType(name)
+		if err != nil {
+			continue
+		}
+		ti := typeInfo(name, typ)
+		if kind != "" && ti.Kind != kind {
+			continue
+		}
+		res.Types = append(res.Types, ti)
+	}
+	return res, nil
+}
+
+// Whatis returns the declaration of the type named expr or, if there is
+// none, of the type of the value expr evaluates to, with the dynamic type
+// of interfaces.
+func (p *Prowler) Whatis(expr string) (*desc.TypeDecl, error) {
+	p.refresh()
+	typ, v, err := p.lookupType(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &desc.TypeDecl{TypeInfo: typeInfo(typeName(typ), typ), Decl: typeDecl(typ)}
+	if v != nil {
+		d.Expr = expr
+		if v.Kind == reflect.Interface && len(v.Children) > 0 && v.Children[0].DwarfType != nil {
+			d.Concrete = typeName(v.Children[0].DwarfType)
+		}
+	}
+	return d, nil
+}
+
+// Layout returns the offset, the size and the alignment of the fields of
+// the struct type named expr, or of the type of the value expr evaluates
+// to, and the padding between them.
+func (p *Prowler) Layout(expr string) (*desc.TypeLayout, error) {
+	p.refresh()
+	typ, _, err := p.lookupType(expr)
+	if err != nil {
+		return nil, err
+	}
+	st, ok := godwarf.ResolveTypedef(typ).(*godwarf.StructType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct", typeName(typ))
+	}
+
+	ptrSize := int64(p.bi.Arch.PtrSize())
+	l := &desc.TypeLayout{Name: typeName(typ), Size: st.Size(), Align: typeAlign(st, ptrSize)}
+	end := int64(0)
+	for i, f := range st.Field {
+		if i > 0 {
+			l.Fields[i-1].Padding = f.ByteOffset - end
+		}
+		l.Padding += f.ByteOffset - end
+		l.Fields = append(l.Fields, desc.FieldLayout{
+			Name:   f.Name,
+			Type:   typeName(f.Type),
+			Offset: f.ByteOffset,
+			Size:   f.Type.Size(),
+			Align:  typeAlign(f.Type, ptrSize),
+		})
+		end = f.ByteOffset + f.Type.Size()
+	}
+	if n := len(l.Fields); n > 0 {
+		l.Fields[n-1].Padding = l.Size - end
+	}
+	l.Padding += l.Size - end
+	return l, nil
+}
+
+// lookupType returns the type named expr or, if there is none, the type
+// of the value expr evaluates to along with the value.
+func (p *Prowler) lookupType(expr string) (godwarf.Type, *proc.Variable, error) {
+	if typ, err := p.bi.FindType(expr); err == nil {
+		return typ, nil, nil
+	}
+	v, err := p.scope().EvalExpression(expr, proc.LoadConfig{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is neither a type nor an expression: %v", expr, err)
+	}
+	if v.DwarfType == nil {
+		return nil, nil, fmt.Errorf("%s has no type", expr)
+	}
+	return v.DwarfType, v, nil
+}
+
+func typeInfo(name string, typ godwarf.Type) desc.TypeInfo {
+	return desc.TypeInfo{Name: name, Package: typePackage(name), Kind: typeKind(typ), Size: typ.Size()}
+}
+
+// typeName returns the name of typ as written in Go.
+func typeName(typ godwarf.Type) string {
+	if name := typ.Common().Name; name != "" {
+		return name
+	}
+	return typ.String()
+}
+
+// typePackage returns the import path of the package of the named type
+// name, e.g. io/fs for io/fs.PathError.
+func typePackage(name string) string {
+	if name == "" || strings.ContainsAny(name[:1], "*[(<") || strings.HasPrefix(name, "map[") || strings.HasPrefix(name, "chan ") ||
+		strings.HasPrefix(name, "func(") || strings.HasPrefix(name, "struct ") || strings.HasPrefix(name, "interface ") {
+		return ""
+	}
+	// the arguments of generic types
+	name, _, _ = strings.Cut(name, "[")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// typeKind returns the kind of typ, as printed by reflect.
+func typeKind(typ godwarf.Type) string {
+	rtyp := godwarf.ResolveTypedef(typ)
+	for _, k := range []reflect.Kind{typ.Common().ReflectKind, rtyp.Common().ReflectKind} {
+		if k != reflect.Invalid {
+			return k.String()
+		}
+	}
+	switch rtyp.(type) {
+	case *godwarf.StructType:
+		return reflect.Struct.String()
+	case *godwarf.ArrayType:
+		return reflect.Array.String()
+	case *godwarf.PtrType:
+		return reflect.Ptr.String()
+	case *godwarf.SliceType:
+		return reflect.Slice.String()
+	case *godwarf.StringType:
+		return reflect.String.String()
+	case *godwarf.MapType:
+		return reflect.Map.String()
+	case *godwarf.ChanType:
+		return reflect.Chan.String()
+	case *godwarf.FuncType:
+		return reflect.Func.String()
+	case *godwarf.InterfaceType:
+		return reflect.Interface.String()
+	}
+	return "unknown"
+}
+
+// typeDecl returns the declaration of typ if it is a named type, e.g.
+// type main.ID int, and otherwise typ with the fields of structs.
+func typeDecl(typ godwarf.Type) string {
+	name := typeName(typ)
+	rtyp := godwarf.ResolveTypedef(typ)
+
+	var def string
+	switch t := rtyp.(type) {
+	case *godwarf.StructType:
+		var buf strings.Builder
+		buf.WriteString("struct {\n")
+		for _, f := range t.Field {
+			if f.Embedded {
+				fmt.Fprintf(&buf, "\t%s\n", typeName(f.Type))
+			} else {
+				fmt.Fprintf(&buf, "\t%s %s\n", f.Name, typeName(f.Type))
+			}
+		}
+		buf.WriteString("}")
+		def = buf.String()
+	case *godwarf.InterfaceType:
+		// the methods of interfaces are not in the debug information
+		def = "interface {...}"
+	default:
+		def = typeDef(typ) + funcParams(rtyp)
+	}
+
+	if typePackage(name) == "" {
+		if _, ok := rtyp.(*godwarf.StructType); ok && strings.HasPrefix(name, "struct ") {
+			return def
+		}
+		return name
+	}
+	return "type " + name + " " + def
+}
+
+// typeDef returns the definition of the named type typ other than a struct
+// or an interface, e.g. map[string]int for type main.Index map[string]int.
+func typeDef(typ godwarf.Type) string {
+	rtyp := godwarf.ResolveTypedef(typ)
+	if def := typeName(rtyp); def != typeName(typ) {
+		return def
+	}
+	// named types other than structs have no typedef in the debug
+	// information, their definition is rebuilt from their elements
+	switch t := rtyp.(type) {
+	case *godwarf.PtrType:
+		return "*" + typeName(t.Type)
+	case *godwarf.SliceType:
+		return "[]" + typeName(t.ElemType)
+	case *godwarf.ArrayType:
+		return fmt.Sprintf("[%d]%s", t.Count, typeName(t.Type))
+	case *godwarf.MapType:
+		return "map[" + typeName(t.KeyType) + "]" + typeName(t.ElemType)
+	case *godwarf.ChanType:
+		return "chan " + typeName(t.ElemType)
+	case *godwarf.FuncType:
+		// the parameters and the results are not told apart, see funcParams
+		return "func()"
+	}
+	return typeKind(rtyp)
+}
+
+// funcParams returns a comment listing the types of the parameters and of
+// the results of the func type typ, which are not told apart in the debug
+// information, empty for other types.
+func funcParams(typ godwarf.Type) string {
+	t, ok := typ.(*godwarf.FuncType)
+	if !ok || len(t.ParamType) == 0 {
+		return ""
+	}
+	params := make([]string, len(t.ParamType))
+	for i, p := range t.ParamType {
+		params[i] = typeName(p)
+	}
+	return " // parameters and results: " + strings.Join(params, ", ")
+}
+
+// typeAlign returns the alignment of the values of type typ in memory.
+func typeAlign(typ godwarf.Type, ptrSize int64) int64 {
+	switch t := godwarf.ResolveTypedef(typ).(type) {
+	case *godwarf.StructType:
+		align := int64(1)
+		for _, f := range t.Field {
+			align = max(align, typeAlign(f.Type, ptrSize))
+		}
+		return align
+	case *godwarf.ArrayType:
+		return typeAlign(t.Type, ptrSize)
+	case *godwarf.ComplexType:
+		return min(max(t.Size()/2, 1), ptrSize)
+	case *godwarf.PtrType, *godwarf.MapType, *godwarf.ChanType, *godwarf.FuncType,
+		*godwarf.SliceType, *godwarf.StringType, *godwarf.InterfaceType:
+		return ptrSize
+	}
+	return min(max(typ.Size(), 1), ptrSize)
+}
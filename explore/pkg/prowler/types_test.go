@@ -0,0 +1,24 @@
+package prowler
+
+import "testing"
+
+func TestTypePackage(t *testing.T) {
+	for name, want := range map[string]string{
+		"main.Config":                "main",
+		"io/fs.PathError":            "io/fs",
+		"main.List[example.com/x.T]": "main",
+		"*main.Config":               "",
+		"[]io/fs.PathError":          "",
+		"map[string]main.Config":     "",
+		"struct { A main.Config }":   "",
+		"int":                        "",
+		"func(main.Config) error":    "",
+		"chan example.com/x.T":       "",
+		"interface { M() main.T }":   "",
+		"example.com/x/v2.Type[int]": "example.com/x/v2",
+	} {
+		if got := typePackage(name); got != want {
+			t.Errorf("typePackage(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
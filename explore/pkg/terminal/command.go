@@ -62,17 +62,64 @@ Type "help" followed by the name of a command for more information about it.`},
 		{
 			aliases: []string{"get", "g"},
 			fn:      get,
-			help:    "retrieve variable, constant, or function information of the target process through remote calling.",
+			help:    "retrieve variable, constant, or function information of the target process through remote calling. Any Go expression over package variables is accepted, e.g. get main.cfg.Servers[2].Addr or get len(main.cache). With get --freeze <expr> all the threads of the process are stopped until the whole value is read. How much of the value is read is set with the options --follow-pointers, --max-variable-recurse, --max-string-len, --max-array-values, --max-struct-fields and --max-map-buckets, e.g. get --max-string-len 4096 main.cfg.Banner. Large arrays, slices, strings and maps are read a page at a time with --offset, --limit and, for maps, --cursor, e.g. get --offset 1000 --limit 50 main.users, the options reading the next page are printed after the value. The memory at an address is read as a value of a type with --as, e.g. get --as main.Config 0xc000123400.",
 		},
 		{
 			aliases: []string{"set", "s"},
 			fn:      set,
-			help:    "modify the corresponding variable information of the process.",
+			help:    "modify the corresponding variable information of the process, either as set <name> <value> or as a Go assignment, e.g. set main.cfg.Limits[\"api\"].Burst = 200. Assigning to a missing map key inserts it, set delete(main.cache, 2) removes a key. set --freeze stops all the threads of the process during the write.",
 		},
 		{
 			aliases: []string{"list", "ls"},
 			fn:      list,
-			help:    "list lists information such as variables, constants, functions, etc. by specifying prefixes, types, etc. Detailed information can be obtained through the get command.",
+			help: `list lists information such as variables, constants, functions, etc. by specifying prefixes, types, etc. Detailed information can be obtained through the get command.
+
+	list <prefix>
+	list vars|consts|funcs|types [prefix ...]
+
+The first form lists the variables, constants and functions starting with prefix, the second one lists the names of one kind, e.g. list types main. lists the types of the main package.`,
+		},
+		{
+			aliases: []string{"goroutines", "grs"},
+			fn:      goroutines,
+			help: `list the goroutines of the process.
+
+	goroutines [-s <status or wait reason>] [-f <start function prefix>]
+
+e.g. goroutines -s "chan receive" -f example.com/pkg/queue. lists the goroutines started in the queue package that are blocked receiving from a channel.`,
+		},
+		{
+			aliases: []string{"goroutine", "gr"},
+			fn:      goroutine,
+			help: `inspect a goroutine of the process.
+
+	goroutine <id> stack [depth]
+	goroutine <id> frame <n> locals|args
+
+Prints the stack of a parked goroutine, innermost frame first, including inlined calls, or the local variables or arguments of the frame numbered n in the stack.`,
+		},
+		{
+			aliases: []string{"x", "examine"},
+			fn:      examine,
+			help: `print the raw memory of the process.
+
+	x [-fmt hex|dec|char|ptr|str] [-count <n>] [-size 1|2|4|8] <address or expression>
+
+The memory is read at the address, or at the address a Go expression evaluates to: the memory pointed to by pointers, the backing array of slices and strings, the value of integers and otherwise the memory of the value itself, e.g. x -fmt ptr -count 4 main.handlers. Pointers printed with -fmt ptr that land on functions or package variables are annotated with their names.`,
+		},
+		{
+			aliases: []string{"heap"},
+			fn:      heap,
+			help: `count the objects of the heap by type.
+
+	heap [-top <n>]
+
+Prints the number and the bytes of the allocated objects of each type, the largest first, the n largest types only (30 by default, 0 for all). The type of small objects without pointers, and of objects of at most 512 bytes, is not recorded by the runtime: they are counted by size class. The allocated objects include the garbage not swept yet by the collector.`,
+		},
+		{
+			aliases: []string{"stats"},
+			fn:      stats,
+			help:    "print the hits and misses of the cache of the memory of the process, and the number of reads issued to the process.",
 		},
 		{
 			aliases: []string{"exit", "quit", "q"},
@@ -170,6 +217,66 @@ func list(t *Term, args string) error {
 	return err
 }
 
+func goroutines(t *Term, args string) error {
+	gs, err := t.client.SendExpr(service.Goroutines, args)
+	if err != nil {
+		t.RedirectTo(os.Stderr)
+		fmt.Fprintln(t.stdout, err.Error())
+		return err
+	}
+
+	_, err = fmt.Fprintln(t.stdout, gs)
+	return err
+}
+
+func goroutine(t *Term, args string) error {
+	st, err := t.client.SendExpr(service.Goroutine, args)
+	if err != nil {
+		t.RedirectTo(os.Stderr)
+		fmt.Fprintln(t.stdout, err.Error())
+		return err
+	}
+
+	_, err = fmt.Fprintln(t.stdout, st)
+	return err
+}
+
+func examine(t *Term, args string) error {
+	m, err := t.client.SendExpr(service.Examine, args)
+	if err != nil {
+		t.RedirectTo(os.Stderr)
+		fmt.Fprintln(t.stdout, err.Error())
+		return err
+	}
+
+	_, err = fmt.Fprintln(t.stdout, m)
+	return err
+}
+
+func heap(t *Term, args string) error {
+	inv, err := t.client.SendExpr(service.Heap, args)
+	if err != nil {
+		t.RedirectTo(os.Stderr)
+		fmt.Fprintln(t.stdout, err.Error())
+		return err
+	}
+
+	_, err = fmt.Fprintln(t.stdout, inv)
+	return err
+}
+
+func stats(t *Term, args string) error {
+	st, err := t.client.SendExpr(service.Stats, args)
+	if err != nil {
+		t.RedirectTo(os.Stderr)
+		fmt.Fprintln(t.stdout, err.Error())
+		return err
+	}
+
+	_, err = fmt.Fprintln(t.stdout, st)
+	return err
+}
+
 type ExitRequestError struct{}
 
 func (ere ExitRequestError) Error() string {
@@ -6,6 +6,11 @@ const (
 	Get CmdType = iota
 	Set
 	List
+	Goroutines
+	Goroutine
+	Stats
+	Examine
+	Heap
 )
 
 type Client interface {
@@ -3,11 +3,16 @@ package http
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"explore/pkg/prowler"
 	"explore/service"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -32,6 +37,23 @@ func NewClient(addr string) (*Client, error) {
 
 func (c *Client) SendExpr(cmdType service.CmdType, args string) (string, error) {
 	var method, path, expr string
+	var query url.Values
+	var opts requestOptions
+	if cmdType == service.Get || cmdType == service.Set {
+		var err error
+		if args, opts, err = getOptions(args); err != nil {
+			return "", err
+		}
+		if opts.page != nil && cmdType != service.Get {
+			return "", errors.New("--offset, --limit and --cursor are only valid for get")
+		}
+		if opts.as != "" && (cmdType != service.Get || opts.page != nil) {
+			return "", errors.New("--as is only valid for get, without --offset, --limit or --cursor")
+		}
+		if opts.freeze {
+			query = url.Values{"consistent": {"1"}}
+		}
+	}
 	switch cmdType {
 	case service.Set:
 		expr = setExpr(args)
@@ -41,6 +63,26 @@ func (c *Client) SendExpr(cmdType service.CmdType, args string) (string, error)
 		expr = listExpr(args)
 		method = http.MethodGet
 		path = "/list"
+	case service.Goroutines:
+		expr = goroutinesExpr(args)
+		method = http.MethodGet
+		path = "/goroutines"
+	case service.Goroutine:
+		expr = goroutineExpr(args)
+		method = http.MethodGet
+		path = "/goroutine"
+	case service.Stats:
+		expr = statsExpr(args)
+		method = http.MethodGet
+		path = "/stats"
+	case service.Examine:
+		expr = examineExpr(args)
+		method = http.MethodGet
+		path = "/examine"
+	case service.Heap:
+		expr = heapExpr(args)
+		method = http.MethodGet
+		path = "/heap"
 	case service.Get:
 		fallthrough
 	default:
@@ -52,12 +94,20 @@ func (c *Client) SendExpr(cmdType service.CmdType, args string) (string, error)
 	resp, err := c.do(&doRequest{
 		method: method,
 		path:   path,
+		query:  query,
 		expr:   expr,
+		load:   opts.load,
+		page:   opts.page,
+		as:     opts.as,
 	})
 	if err != nil {
 		return "", err
 	}
 
+	if resp.Status != http.StatusOK {
+		return "", errors.New(resp.Msg)
+	}
+
 	respStr, ok := resp.Data.(string)
 	if !ok {
 		return "", fmt.Errorf("unexpected response type %T", resp.Data)
@@ -83,6 +133,63 @@ func (c *Client) IsExploreServer() bool {
 	return resp.Status == http.StatusOK
 }
 
+// requestOptions are the options of get and set given before the
+// expression.
+type requestOptions struct {
+	// freeze stops the process during the request
+	freeze bool
+	load   *prowler.LoadOptions
+	page   *prowler.Page
+	// as is the type get reads the memory at an address as
+	as string
+}
+
+// getOptions splits the options of get and set from the expression that
+// follows them: --freeze, to stop the process during the request, the load
+// configuration options, e.g. get --max-string-len 1024 main.s, and the
+// page options, e.g. get --offset 1000 --limit 50 main.users, and the type
+// to read an address as, e.g. get --as main.Config 0xc000123400.
+func getOptions(args string) (expr string, opts requestOptions, err error) {
+	expr = strings.TrimSpace(args)
+	for strings.HasPrefix(expr, "--") {
+		var opt string
+		opt, expr, _ = strings.Cut(expr, " ")
+		expr = strings.TrimLeft(expr, " ")
+
+		name, value, hasValue := strings.Cut(opt[2:], "=")
+		switch {
+		case name == "freeze":
+			opts.freeze = true
+			continue
+		case !hasValue && name == "follow-pointers":
+			value = "true"
+		case !hasValue:
+			value, expr, _ = strings.Cut(expr, " ")
+			expr = strings.TrimLeft(expr, " ")
+		}
+		if name == "as" {
+			opts.as = value
+			continue
+		}
+		if slices.Contains(prowler.PageOptionNames, name) {
+			if opts.page == nil {
+				opts.page = &prowler.Page{}
+			}
+			if err := opts.page.Set(name, value); err != nil {
+				return "", requestOptions{}, err
+			}
+			continue
+		}
+		if opts.load == nil {
+			opts.load = &prowler.LoadOptions{}
+		}
+		if err := opts.load.Set(name, value); err != nil {
+			return "", requestOptions{}, err
+		}
+	}
+	return expr, opts, nil
+}
+
 func getExpr(args string) string {
 	return fmt.Sprintf("get %s", args)
 }
@@ -95,11 +202,35 @@ func listExpr(args string) string {
 	return fmt.Sprintf("list %s", args)
 }
 
+func goroutinesExpr(args string) string {
+	return fmt.Sprintf("goroutines %s", args)
+}
+
+func goroutineExpr(args string) string {
+	return fmt.Sprintf("goroutine %s", args)
+}
+
+func statsExpr(args string) string {
+	return fmt.Sprintf("stats %s", args)
+}
+
+func examineExpr(args string) string {
+	return fmt.Sprintf("x %s", args)
+}
+
+func heapExpr(args string) string {
+	return fmt.Sprintf("heap %s", args)
+}
+
 type doRequest struct {
 	method string
 	path   string
+	query  url.Values
 	header http.Header
 	expr   string
+	load   *prowler.LoadOptions
+	page   *prowler.Page
+	as     string
 }
 
 func (c *Client) jsonHeader() http.Header {
@@ -110,16 +241,22 @@ func (c *Client) jsonHeader() http.Header {
 }
 
 func (c *Client) do(req *doRequest) (resp *response, err error) {
-	url := c.url + req.path
+	u := c.url + req.path
+	if req.query != nil {
+		u += "?" + req.query.Encode()
+	}
 
 	exr := newExpression(req.expr, os.Getpid())
+	exr.Load = req.load
+	exr.Page = req.page
+	exr.As = req.as
 	bs, err := json.Marshal(exr)
 	if err != nil {
 		return
 	}
 
 	bodyReader := bytes.NewReader(bs)
-	r, err := http.NewRequest(req.method, url, bodyReader)
+	r, err := http.NewRequest(req.method, u, bodyReader)
 	if err != nil {
 		return
 	}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"explore/pkg/prowler"
 	"github.com/google/shlex"
 	"strings"
 )
@@ -8,6 +9,13 @@ import (
 type Expression struct {
 	Expr string `json:"expression"`
 	Pid  int    `json:"pid"`
+	// Load overrides the load configuration of the server for get and set
+	Load *prowler.LoadOptions `json:"load,omitempty"`
+	// Page selects the elements of a collection read by get
+	Page *prowler.Page `json:"page,omitempty"`
+	// As is the type get reads the memory at the address of the expression
+	// as, see Prowler.GetAs
+	As string `json:"as,omitempty"`
 }
 
 func newExpression(expr string, pid int) *Expression {
@@ -19,3 +27,13 @@ func (e *Expression) resolve() (string, []string) {
 	args, _ := shlex.Split(cmds[1])
 	return cmds[0], args
 }
+
+// rest returns the arguments of the expression as a single string, without
+// splitting or unquoting them, so that Go expressions are kept intact.
+func (e *Expression) rest() string {
+	cmds := strings.SplitN(e.Expr, " ", 2)
+	if len(cmds) < 2 {
+		return ""
+	}
+	return strings.TrimSpace(cmds[1])
+}
@@ -1,14 +1,29 @@
 package http
 
 import (
+	"explore/pkg/proc/desc"
 	"explore/pkg/prowler"
 	"explore/utils"
 	"fmt"
 	"github.com/derekparker/trie"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
+const defaultStackDepth = 50
+
+// lsTypes are the kinds of names the list command can be restricted to, as
+// in ls funcs main.
+var lsTypes = map[string]prowler.LsType{
+	"vars":   prowler.Variable,
+	"consts": prowler.Constant,
+	"funcs":  prowler.Function,
+	"types":  prowler.Type,
+}
+
 type Router struct {
 	method string
 	path   string
@@ -77,15 +92,29 @@ func register(p *processor) {
 					return
 				}
 
-				name := args[0]
-				res, err := p.prowler.Get(name)
+				var res *desc.Variable
+				err := p.consistent(ctx, func() (err error) {
+					cfg := expr.Load.Apply(p.prowler.LoadConfig)
+					if expr.As != "" {
+						res, err = p.prowler.GetAs(expr.As, expr.rest(), cfg)
+					} else if expr.Page != nil {
+						res, err = p.prowler.GetPage(expr.rest(), cfg, *expr.Page)
+					} else {
+						res, err = p.prowler.GetWithConfig(expr.rest(), cfg)
+					}
+					return err
+				})
 				if err != nil {
 					ctx.respFailed(http.StatusInternalServerError, err.Error())
 					return
 				}
 
 				// ctx.w.WriteHeader(http.StatusOK)
-				ctx.respSuccess(res.MultilineString("", ""))
+				out := res.MultilineString("", "")
+				if next := res.NextPage(); expr.Page != nil && next != "" {
+					out += fmt.Sprintf("\nnext page: %s", next)
+				}
+				ctx.respSuccess(out)
 			},
 		},
 		{
@@ -100,19 +129,20 @@ func register(p *processor) {
 					return
 				}
 
-				if len(args) < 2 {
-					ctx.respFailed(http.StatusBadRequest, fmt.Sprintf("invalid number of arguments: %d", len(args)))
-					return
-				}
-
-				name, val := args[0], args[1]
-				err := p.prowler.Set(name, val)
+				w, err := prowler.ParseSet(expr.rest(), args)
 				if err != nil {
-					ctx.respFailed(http.StatusInternalServerError, err.Error())
+					ctx.respFailed(http.StatusBadRequest, err.Error())
 					return
 				}
 
-				res, err := p.prowler.Get(name)
+				var res *desc.Variable
+				err = p.consistent(ctx, func() (err error) {
+					if err := p.prowler.Write(w); err != nil {
+						return err
+					}
+					res, err = p.prowler.GetWithConfig(w.Name, expr.Load.Apply(p.prowler.LoadConfig))
+					return err
+				})
 				if err != nil {
 					ctx.respFailed(http.StatusInternalServerError, err.Error())
 					return
@@ -139,8 +169,13 @@ func register(p *processor) {
 					return
 				}
 
-				ex := args[0]
-				ls := p.prowler.ListFuzzy(ex)
+				var ls []string
+				if t, ok := lsTypes[args[0]]; ok {
+					ls = p.prowler.List(t, args[1:], nil)
+					sort.Strings(ls)
+				} else {
+					ls = p.prowler.ListFuzzy(args[0])
+				}
 				var buf strings.Builder
 				for _, elem := range ls {
 					line := elem + "\n"
@@ -151,6 +186,158 @@ func register(p *processor) {
 				ctx.respSuccess(buf.String())
 			},
 		},
+		{
+			method: http.MethodGet,
+			path:   "/goroutines",
+			fn: func(ctx *Context) {
+				expr := ctx.expr
+				cmd, args := expr.resolve()
+				cmdStr := strings.ToLower(cmd)
+				if cmdStr != "goroutines" {
+					ctx.respFailed(http.StatusBadRequest, fmt.Sprintf("invalid command: %s", cmdStr))
+					return
+				}
+
+				filter, err := goroutineFilter(args)
+				if err != nil {
+					ctx.respFailed(http.StatusBadRequest, err.Error())
+					return
+				}
+
+				gs, err := p.prowler.Goroutines(filter)
+				if err != nil {
+					ctx.respFailed(http.StatusInternalServerError, err.Error())
+					return
+				}
+
+				ctx.respSuccess(gs.String())
+			},
+		},
+		{
+			method: http.MethodGet,
+			path:   "/goroutine",
+			fn: func(ctx *Context) {
+				expr := ctx.expr
+				cmd, args := expr.resolve()
+				cmdStr := strings.ToLower(cmd)
+				if cmdStr != "goroutine" {
+					ctx.respFailed(http.StatusBadRequest, fmt.Sprintf("invalid command: %s", cmdStr))
+					return
+				}
+
+				if len(args) < 2 {
+					ctx.respFailed(http.StatusBadRequest, fmt.Sprintf("invalid number of arguments: %d", len(args)))
+					return
+				}
+
+				gid, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil {
+					ctx.respFailed(http.StatusBadRequest, fmt.Sprintf("invalid goroutine id: %s", args[0]))
+					return
+				}
+
+				switch args[1] {
+				case "stack":
+					depth := defaultStackDepth
+					if len(args) > 2 {
+						depth, err = strconv.Atoi(args[2])
+						if err != nil {
+							ctx.respFailed(http.StatusBadRequest, fmt.Sprintf("invalid depth: %s", args[2]))
+							return
+						}
+					}
+
+					st, err := p.prowler.Stacktrace(gid, depth)
+					if err != nil {
+						ctx.respFailed(http.StatusInternalServerError, err.Error())
+						return
+					}
+
+					ctx.respSuccess(st.String())
+				case "frame":
+					if len(args) != 4 || (args[3] != "locals" && args[3] != "args") {
+						ctx.respFailed(http.StatusBadRequest, "usage: goroutine <id> frame <n> locals|args")
+						return
+					}
+					frame, err := strconv.Atoi(args[2])
+					if err != nil {
+						ctx.respFailed(http.StatusBadRequest, fmt.Sprintf("invalid frame: %s", args[2]))
+						return
+					}
+
+					vars, err := p.prowler.FrameVariables(gid, frame, args[3] == "args")
+					if err != nil {
+						ctx.respFailed(http.StatusInternalServerError, err.Error())
+						return
+					}
+
+					ctx.respSuccess(vars.String())
+				default:
+					ctx.respFailed(http.StatusBadRequest, fmt.Sprintf("unknown goroutine command: %s", args[1]))
+				}
+			},
+		},
+		{
+			method: http.MethodGet,
+			path:   "/examine",
+			fn: func(ctx *Context) {
+				expr := ctx.expr
+				cmd, _ := expr.resolve()
+				cmdStr := strings.ToLower(cmd)
+				if cmdStr != "x" && cmdStr != "examine" {
+					ctx.respFailed(http.StatusBadRequest, fmt.Sprintf("invalid command: %s", cmdStr))
+					return
+				}
+
+				addr, opts, err := examineOptions(expr.rest())
+				if err != nil {
+					ctx.respFailed(http.StatusBadRequest, err.Error())
+					return
+				}
+
+				m, err := p.prowler.Examine(addr, opts)
+				if err != nil {
+					ctx.respFailed(http.StatusInternalServerError, err.Error())
+					return
+				}
+
+				ctx.respSuccess(m.String())
+			},
+		},
+		{
+			method: http.MethodGet,
+			path:   "/heap",
+			fn: func(ctx *Context) {
+				expr := ctx.expr
+				cmd, args := expr.resolve()
+				cmdStr := strings.ToLower(cmd)
+				if cmdStr != "heap" {
+					ctx.respFailed(http.StatusBadRequest, fmt.Sprintf("invalid command: %s", cmdStr))
+					return
+				}
+
+				top, err := heapTop(args)
+				if err != nil {
+					ctx.respFailed(http.StatusBadRequest, err.Error())
+					return
+				}
+
+				inv, err := p.prowler.HeapInventory(top)
+				if err != nil {
+					ctx.respFailed(http.StatusInternalServerError, err.Error())
+					return
+				}
+
+				ctx.respSuccess(inv.String())
+			},
+		},
+		{
+			method: http.MethodGet,
+			path:   "/stats",
+			fn: func(ctx *Context) {
+				ctx.respSuccess(p.prowler.CacheStats().String())
+			},
+		},
 	}
 
 	p.router = r
@@ -164,6 +351,115 @@ func register(p *processor) {
 	p.trie = t
 }
 
+// consistent runs fn with the process frozen if the request asks for a
+// consistent read or write with ?consistent=1. How long the memory of the
+// stopped process is accessed can be bounded with ?timeout=, e.g. 200ms,
+// the memory accesses after it fail and so does the request.
+func (p *processor) consistent(ctx *Context, fn func() error) error {
+	query := ctx.read.URL.Query()
+	if query.Get("consistent") != "1" {
+		return fn()
+	}
+
+	var timeout time.Duration
+	if t := query.Get("timeout"); t != "" {
+		var err error
+		if timeout, err = time.ParseDuration(t); err != nil {
+			return fmt.Errorf("invalid timeout %q: %v", t, err)
+		}
+	}
+	return p.prowler.Freeze(timeout, fn)
+}
+
+// goroutineFilter parses the arguments of the goroutines command:
+//
+//	goroutines [-s <status or wait reason>] [-f <start function prefix>]
+func goroutineFilter(args []string) (prowler.GoroutineFilter, error) {
+	var filter prowler.GoroutineFilter
+	for i := 0; i < len(args); i += 2 {
+		var field *string
+		switch args[i] {
+		case "-s", "-status", "--status":
+			field = &filter.Status
+		case "-f", "-start", "--start":
+			field = &filter.StartFunc
+		default:
+			return filter, fmt.Errorf("unknown argument: %s", args[i])
+		}
+		if i+1 >= len(args) {
+			return filter, fmt.Errorf("missing value for %s", args[i])
+		}
+		*field = args[i+1]
+	}
+
+	return filter, nil
+}
+
+// examineOptions splits the options of the examine command from the
+// address or expression that follows them:
+//
+//	x [-fmt hex|dec|char|ptr|str] [-count <n>] [-size 1|2|4|8] <address or expression>
+func examineOptions(args string) (string, prowler.ExamineOptions, error) {
+	var opts prowler.ExamineOptions
+	args = strings.TrimSpace(args)
+	for strings.HasPrefix(args, "-") {
+		var opt, value string
+		opt, args, _ = strings.Cut(args, " ")
+		name, value, hasValue := strings.Cut(strings.TrimLeft(opt, "-"), "=")
+		if !hasValue {
+			value, args, _ = strings.Cut(strings.TrimLeft(args, " "), " ")
+		}
+		args = strings.TrimLeft(args, " ")
+
+		var err error
+		switch name {
+		case "fmt":
+			opts.Format = value
+		case "count":
+			opts.Count, err = strconv.Atoi(value)
+		case "size":
+			opts.Size, err = strconv.Atoi(value)
+		default:
+			return "", opts, fmt.Errorf("unknown argument: %s", opt)
+		}
+		if err != nil {
+			return "", opts, fmt.Errorf("invalid value %q for %s", value, name)
+		}
+	}
+	if args == "" {
+		return "", opts, fmt.Errorf("an address or an expression is needed")
+	}
+
+	return args, opts, nil
+}
+
+// heapTop parses the arguments of the heap command:
+//
+//	heap [-top <n>]
+func heapTop(args []string) (int, error) {
+	top := prowler.DefaultHeapTop
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if name != "top" || !strings.HasPrefix(args[i], "-") {
+			return 0, fmt.Errorf("unknown argument: %s", args[i])
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return 0, fmt.Errorf("missing value for %s", args[i])
+			}
+			i++
+			value = args[i]
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid value %q for top", value)
+		}
+		top = n
+	}
+
+	return top, nil
+}
+
 func methodPath(method, path string) string {
 	return fmt.Sprintf("%s:%s", method, path)
 }
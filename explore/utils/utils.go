@@ -12,8 +12,8 @@ import (
 
 const (
 	ExactArgs = iota
-	minArgs
-	maxArgs
+	MinArgs
+	MaxArgs
 )
 
 func CheckArgs(context *cli.Context, expected, checkType int, fn func(args cli.Args) error) error {
@@ -24,11 +24,11 @@ func CheckArgs(context *cli.Context, expected, checkType int, fn func(args cli.A
 		if context.NArg() != expected {
 			err = fmt.Errorf("%s: %q requires exactly %d argument(s)", os.Args[0], cmdName, expected)
 		}
-	case minArgs:
+	case MinArgs:
 		if context.NArg() < expected {
 			err = fmt.Errorf("%s: %q requires a minimum of %d argument(s)", os.Args[0], cmdName, expected)
 		}
-	case maxArgs:
+	case MaxArgs:
 		if context.NArg() > expected {
 			err = fmt.Errorf("%s: %q requires a maximum of %d argument(s)", os.Args[0], cmdName, expected)
 		}